@@ -1,8 +1,17 @@
 package code
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"go/types"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,6 +45,295 @@ func TestIncSliceStat(t *testing.T) {
 	}
 }
 
+func TestCountLines(t *testing.T) {
+	const src = "// Package p ...\npackage p\n\n/*\n  doc\n*/\nvar x = `a\n\nb` // c\n\n\t// d\nfunc f() {}"
+	stats := countLines([]byte(src))
+	expected := LineStats{Lines: 12, BlankLines: 2, CommentLines: 5}
+	if stats != expected {
+		t.Errorf("line stats not match: %v vs. %v", stats, expected)
+	}
+	if stats = countLines([]byte(src + "\n")); stats != expected {
+		t.Errorf("line stats (with a trailing newline) not match: %v vs. %v", stats, expected)
+	}
+}
+
+func TestPushRankedItem(t *testing.T) {
+	var items []RankedItem
+	for i := 0; i < TopN*3; i++ {
+		items = pushRankedItem(items, RankedItem{Name: strconv.Itoa(i), Value: int32(i % (TopN + 1))})
+	}
+	if len(items) != TopN {
+		t.Fatalf("list length not match: %d vs. %d", len(items), TopN)
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i-1].Value < items[i].Value {
+			t.Fatalf("list is not sorted: %v", items)
+		}
+	}
+	if items[0].Value != TopN || items[0].Name != strconv.Itoa(TopN) || items[1].Name != strconv.Itoa(TopN*2+1) {
+		t.Errorf("list items not match: %v", items)
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	var a, b Stats
+	a.Packages, b.Packages = 1, 2
+	a.FilesByImportCount[3], b.FilesByImportCount[3] = 4, 5
+	b.roughTypeNameCount = 6
+	a.add(&b)
+	if a.Packages != 3 || a.FilesByImportCount[3] != 9 || a.roughTypeNameCount != 6 {
+		t.Errorf("stats are not added correctly: %d, %d, %d", a.Packages, a.FilesByImportCount[3], a.roughTypeNameCount)
+	}
+}
+
+func TestFunctionMetrics(t *testing.T) {
+	const src = `package p
+
+func f(a, b int) int {
+	for i := 0; i < a; i++ {
+		if i > b && b > 0 {
+			return i
+		} else if i < 0 {
+			go func() {
+				return
+			}()
+		}
+	}
+	switch {
+	case a > 0:
+	default:
+	}
+	return 0
+}`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := computeFunctionMetrics(fset, file.Decls[0].(*ast.FuncDecl))
+	expected := FunctionMetrics{Statements: 10, Lines: 16, Complexity: 6, MaxNesting: 2, Returns: 2}
+	if m != expected {
+		t.Errorf("function metrics not match: %+v vs. %+v", m, expected)
+	}
+}
+
+func TestCollectLineDirectives(t *testing.T) {
+	const src = `package p
+
+//line parser.y:10
+var a = 1
+
+/*
+//line ignored.y:100
+*/
+var b = 2 /*line parser.y:20:5*/; var c = 3
+	//line ignored.y:200
+var d = 4
+//line bad.y
+var e = 5
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "/p/parser.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directives := collectLineDirectives(fset, file)
+	if len(directives) != 2 {
+		t.Fatalf("2 directives are expected, but got %d: %+v", len(directives), directives)
+	}
+	expected := [...]struct {
+		line     int
+		filename string
+		origLine int
+	}{
+		{4, filepath.Join("/p", "parser.y"), 10},
+		{9, filepath.Join("/p", "parser.y"), 20},
+	}
+	for i, ld := range directives {
+		e := expected[i]
+		p := fset.PositionFor(ld.Pos, false)
+		if p.Line != e.line || ld.Original.Filename != e.filename || ld.Original.Line != e.origLine {
+			t.Errorf("directive %d: expected %d => %s:%d, got %d => %s:%d",
+				i, e.line, e.filename, e.origLine, p.Line, ld.Original.Filename, ld.Original.Line)
+		}
+	}
+}
+
+func TestParseAsmTextDirectives(t *testing.T) {
+	const src = `#include "textflag.h"
+
+/*
+TEXT ·Fake(SB),NOSPLIT,$0
+*/
+// func Sqrt(x float64) float64
+TEXT ·Sqrt(SB),NOSPLIT,$0
+	RET
+TEXT	runtime∕internal∕atomic·Load<ABIInternal>(SB),NOSPLIT,$0
+	RET
+TEXT _rt0_amd64(SB),NOSPLIT,$-8
+`
+	texts := parseAsmTextDirectives([]byte(src))
+	expected := []asmTextDirective{
+		{7, "", "Sqrt"},
+		{9, "runtime∕internal∕atomic", "Load"},
+	}
+	if len(texts) != len(expected) {
+		t.Fatalf("TEXT directives not match: %v vs. %v", texts, expected)
+	}
+	for i := range texts {
+		if texts[i] != expected[i] {
+			t.Errorf("TEXT directive %d not match: %v vs. %v", i, texts[i], expected[i])
+		}
+	}
+
+	if arch := asmFileArch("sqrt_arm64.s", nil); arch != "arm64" {
+		t.Errorf("arch of sqrt_arm64.s: %q", arch)
+	}
+	if arch := asmFileArch("sqrt.s", []byte("// Copyright\n\n//go:build amd64 || arm64\n\nTEXT ·Sqrt(SB),0,$0")); arch != "amd64 || arm64" {
+		t.Errorf("arch of sqrt.s: %q", arch)
+	}
+}
+
+func TestLinknameDirectives(t *testing.T) {
+	const src = `package p
+
+import _ "unsafe"
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+//go:linkname exported
+//go:linkname  sleep  time.Sleep
+func sleep(ns int64) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directives := collectLinknameDirectives(file)
+	expected := []linknameDirective{
+		{"nanotime", "runtime.nanotime"},
+		{"sleep", "time.Sleep"},
+	}
+	if len(directives) != len(expected) {
+		t.Fatalf("linkname directives not match: %v vs. %v", directives, expected)
+	}
+	for i := range directives {
+		if directives[i] != expected[i] {
+			t.Errorf("linkname directive %d not match: %v vs. %v", i, directives[i], expected[i])
+		}
+	}
+
+	for target, expected := range map[string][2]string{
+		"runtime.nanotime":       {"runtime", "nanotime"},
+		"gopkg.in/yaml.v3.parse": {"gopkg.in/yaml.v3", "parse"},
+		"runtime.(*m).lock":      {"", ""},
+		"nodot":                  {"", ""},
+	} {
+		if pkgPath, name := splitLinknameTarget(target); pkgPath != expected[0] || name != expected[1] {
+			t.Errorf("split %s: got %s and %s", target, pkgPath, name)
+		}
+	}
+}
+
+func TestEmbedPatterns(t *testing.T) {
+	patterns := splitEmbedPatterns(` hello.txt  "a b.txt" static/* ` + "`all:c d`")
+	expected := []string{"hello.txt", "a b.txt", "static/*", "all:c d"}
+	if len(patterns) != len(expected) {
+		t.Fatalf("embed patterns not match: %q vs. %q", patterns, expected)
+	}
+	for i := range patterns {
+		if patterns[i] != expected[i] {
+			t.Errorf("embed pattern %d not match: %q vs. %q", i, patterns[i], expected[i])
+		}
+	}
+
+	for _, c := range []struct {
+		pattern, file string
+		expected      bool
+	}{
+		{"hello.txt", "hello.txt", true},
+		{"*.txt", "static/a.txt", false},
+		{"static", "static/css/site.css", true},
+		{"static", "static/_hidden/a.txt", false},
+		{"static", "static/.a.txt", false},
+		{"all:static", "static/_hidden/a.txt", true},
+		{"static/_hidden/a.txt", "static/_hidden/a.txt", true},
+		{"stat*", "static/a.txt", true},
+	} {
+		if matched := matchEmbedPattern(c.pattern, c.file); matched != c.expected {
+			t.Errorf("matchEmbedPattern(%q, %q) = %v, expected %v", c.pattern, c.file, matched, c.expected)
+		}
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	const src = `//go:build linux
+
+package p
+
+// F is a function.
+//
+//go:noinline
+//go:nosplit
+func F() {}
+
+//go:generate stringer -type=K
+//export G
+
+// K is a type.
+//
+//line a.go:1
+//not:Directive
+//go:notinheap
+type K int
+
+var (
+	//go:embed hello.txt
+	V string
+)
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var check = func(what string, directives []Directive, expected ...string) {
+		if len(directives) != len(expected) {
+			t.Errorf("%s: directives not match: %v vs. %v", what, directives, expected)
+			return
+		}
+		for i, d := range directives {
+			if d.String() != expected[i] {
+				t.Errorf("%s: directive %d not match: %s vs. %s", what, i, d, expected[i])
+			}
+		}
+	}
+	check("file", collectFileDirectives(file), "//go:build linux", "//go:generate stringer -type=K", "//export G")
+	check("F", directivesInComments(file.Decls[0].(*ast.FuncDecl).Doc), "//go:noinline", "//go:nosplit")
+	gd := file.Decls[1].(*ast.GenDecl)
+	check("K", directivesInComments(genDeclSpecDoc(gd, gd.Specs[0].(*ast.TypeSpec).Doc)), "//go:notinheap")
+	gd = file.Decls[2].(*ast.GenDecl)
+	check("V", directivesInComments(genDeclSpecDoc(gd, gd.Specs[0].(*ast.ValueSpec).Doc)), "//go:embed hello.txt")
+}
+
+func TestDeprecationNotice(t *testing.T) {
+	for _, c := range []struct {
+		doc, expected string
+	}{
+		{"F is a function.\n", ""},
+		{"Deprecated: use G.\n", "Deprecated: use G."},
+		{"F is a function.\n\nDeprecated: use G\ninstead.\n\nMore docs.\n", "Deprecated: use G\ninstead."},
+		{"F is a function. Deprecated: use G.\n", ""},
+		{"Deprecated:use G.\n", ""},
+	} {
+		if notice := DeprecationNotice(c.doc); notice != c.expected {
+			t.Errorf("DeprecationNotice(%q) = %q, expected %q", c.doc, notice, c.expected)
+		}
+	}
+}
+
 func TestID(t *testing.T) {
 	var analyzer CodeAnalyzer
 	var check1 = func(pkg *Package, id string, expected string) {
@@ -49,7 +347,7 @@ func TestID(t *testing.T) {
 		}
 	}
 
-	analyzer.ParsePackages(nil, "builtin", "math")
+	analyzer.ParsePackages(nil, ParseOptions{}, "builtin", "math")
 	stdPkg := analyzer.PackageByPath("builtin")
 	mathPkg := analyzer.PackageByPath("math")
 
@@ -80,13 +378,914 @@ func TestRegisterType(t *testing.T) {
 	}
 }
 
+func TestParseTests(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, ParseOptions{Tests: true}, "errors")
+	analyzer.AnalyzePackages(nil)
+
+	errorsPkg := analyzer.PackageByPath("errors")
+	if errorsPkg == nil {
+		t.Fatal("package errors is not found")
+	}
+	xtestPkg := analyzer.PackageByPath("errors_test")
+	if xtestPkg == nil {
+		t.Fatal("package errors_test is not found")
+	}
+
+	var numPkgs = make(map[string]int, analyzer.NumPackages())
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		numPkgs[analyzer.PackageAt(i).Path()]++
+	}
+	for path, n := range numPkgs {
+		if n > 1 {
+			t.Errorf("package %s is duplicated %d times", path, n)
+		}
+	}
+
+	var numTestFuncs [TestFunc_Example + 1]int
+	for _, pkg := range []*Package{errorsPkg, xtestPkg} {
+		for _, f := range pkg.AllFunctions {
+			numTestFuncs[f.TestFuncKind()]++
+		}
+	}
+	if numTestFuncs[TestFunc_Test] == 0 {
+		t.Errorf("no tests are found")
+	}
+	if numTestFuncs[TestFunc_Example] == 0 {
+		t.Errorf("no examples are found")
+	}
+
+	newFunc := errorsPkg.PPkg.Types.Scope().Lookup("New")
+	var usedInTests bool
+	for _, ref := range analyzer.ObjectReferences(newFunc) {
+		if strings.HasSuffix(ref.FileInfo.OriginalFile, "_test.go") {
+			usedInTests = true
+			break
+		}
+	}
+	if !usedInTests {
+		t.Errorf("uses of errors.New in test files are not collected")
+	}
+}
+
+func TestParseTestsDeclaringMethodsForNonTestTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/tests\n\ngo 1.22\n",
+		"p/p.go": "package p\n\ntype T struct{}\n",
+		"p/p_test.go": `package p
+
+import "testing"
+
+func (T) m() {}
+
+type testT struct{}
+
+func (testT) m() {}
+
+func TestT(t *testing.T) { testT{}.m() }
+`,
+		"q/q.go": "package q\n\ntype T struct{}\n",
+		"q/q_test.go": `package q
+
+var TestOnly = 1
+
+func helper() { undefined() }
+`,
+		"q/x_test.go": `package q_test
+
+import "example.com/tests/q"
+
+func Ident[E any](e E) E { return e }
+
+var _ = Ident(q.T{})
+`,
+	})
+
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackages(nil, ParseOptions{Dir: dir, Tests: true}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/tests/p")
+	if pkg == nil {
+		t.Fatal("package example.com/tests/p is not found")
+	}
+	if f := analyzer.FunctionByName(pkg, "TestT"); f == nil || f.TestFuncKind() != TestFunc_Test {
+		t.Errorf("the test function TestT is not found")
+	}
+	scope := pkg.PPkg.Types.Scope()
+	if obj, _, _ := types.LookupFieldOrMethod(scope.Lookup("T").Type(), false, pkg.PPkg.Types, "m"); obj != nil {
+		t.Errorf("the method T.m declared in a test file should be ignored")
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(scope.Lookup("testT").Type(), false, pkg.PPkg.Types, "m"); obj == nil {
+		t.Errorf("the method testT.m declared in a test file should be kept")
+	}
+	if len(pkg.Errors) != 1 || !strings.Contains(pkg.Errors[0].Msg, "T.m") || pkg.Errors[0].Position.Line != 5 {
+		t.Errorf("the ignored method T.m should be reported: %v", pkg.Errors)
+	}
+
+	// The test files having errors leave nothing in the tested package.
+	qPkg := analyzer.PackageByPath("example.com/tests/q")
+	if qPkg == nil {
+		t.Fatal("package example.com/tests/q is not found")
+	}
+	if qPkg.PPkg.Types.Scope().Lookup("TestOnly") != nil {
+		t.Errorf("the objects declared in the failed test files should not be in the package scope")
+	}
+	for id := range qPkg.PPkg.TypesInfo.Defs {
+		if strings.HasSuffix(qPkg.PPkg.Fset.Position(id.Pos()).Filename, "_test.go") {
+			t.Errorf("the identifiers in the failed test files should not be in TypesInfo: %s", id.Name)
+		}
+	}
+	var reported bool
+	for _, e := range qPkg.Errors {
+		reported = reported || strings.Contains(e.Msg, "undefined")
+	}
+	if !reported {
+		t.Errorf("the errors in the failed test files should be reported: %v", qPkg.Errors)
+	}
+
+	xPkg := analyzer.PackageByPath("example.com/tests/q_test")
+	if xPkg == nil {
+		t.Fatal("package example.com/tests/q_test is not found")
+	}
+	if len(xPkg.PPkg.TypesInfo.Instances) == 0 || len(xPkg.PPkg.TypesInfo.FileVersions) == 0 {
+		t.Errorf("the Instances and FileVersions of the external test package are not recorded")
+	}
+}
+
+func TestConfirmPackageModules(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, ParseOptions{}, "go101.org/gold")
+	analyzer.AnalyzePackages(nil)
+
+	stdMod := analyzer.StandardModule()
+	if analyzer.NumModules() == 0 || analyzer.ModuleAt(0) != stdMod {
+		t.Fatal("the std module should be the first one")
+	}
+	if pkg := analyzer.PackageByPath("errors"); pkg == nil || pkg.Mod != stdMod {
+		t.Errorf("package errors should belong to the std module")
+	}
+
+	goldMod := analyzer.ModuleByPath("go101.org/gold")
+	if goldMod == nil {
+		t.Fatal("module go101.org/gold is not found")
+	}
+	if !goldMod.IsMain {
+		t.Errorf("module go101.org/gold should be the main module")
+	}
+	if pkg := analyzer.PackageByPath("go101.org/gold"); pkg == nil || pkg.Mod != goldMod {
+		t.Errorf("package go101.org/gold should belong to module go101.org/gold")
+	}
+
+	var toolsRequired bool
+	for _, r := range goldMod.Requires {
+		if r.Path == "golang.org/x/tools" {
+			toolsRequired = r.Module != nil
+		}
+	}
+	if !toolsRequired {
+		t.Errorf("the requirement of golang.org/x/tools is not confirmed")
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	abs := func(f string) string {
+		f, _ = filepath.Abs(f)
+		return f
+	}
+	for _, c := range []struct {
+		pos      string
+		expected token.Position
+	}{
+		{"", token.Position{}},
+		{"-", token.Position{}},
+		{"/a/b.go:12:5", token.Position{Filename: "/a/b.go", Line: 12, Column: 5}},
+		{"/a/b.go:12", token.Position{Filename: "/a/b.go", Line: 12}},
+		{"/a/b.go", token.Position{Filename: "/a/b.go"}},
+		{"b.go:3:1", token.Position{Filename: abs("b.go"), Line: 3, Column: 1}},
+	} {
+		if p := parseErrorPosition(c.pos, ""); p != c.expected {
+			t.Errorf("parseErrorPosition(%q) = %+v, expected %+v", c.pos, p, c.expected)
+		}
+	}
+	if p, expected := parseErrorPosition("b.go:3", "/d"), (token.Position{Filename: abs("/d/b.go"), Line: 3}); p != expected {
+		t.Errorf("parseErrorPosition(%q, %q) = %+v, expected %+v", "b.go:3", "/d", p, expected)
+	}
+}
+
+func TestAnalyzeIllTypedPackage(t *testing.T) {
+	dir := t.TempDir()
+	var files = map[string]string{
+		"go.mod": "module example.com/bad\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+import "nosuch/pkg"
+
+type T struct{ X int }
+
+func (T) M() {}
+
+type G[V any] struct{ v V }
+
+type E struct {
+	Missing
+	*T
+	other.Thing
+	*Gone
+	G[Missing2]
+	pkg.X
+}
+
+type I interface {
+	MissingIface
+	pkg.Y
+	M()
+}
+
+type A = Missing3
+
+func (r Missing4) Method() {}
+
+func (r *A) Method2() {}
+
+func F(m Missing5) int { return undefinedVar }
+
+func (**T) M2() {}
+
+func ([]int) M3() {}
+
+func (T, T) M4() {}
+`,
+	}
+	writeTestFiles(t, dir, files)
+
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackages(nil, ParseOptions{Dir: dir}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/bad/p")
+	if pkg == nil {
+		t.Fatal("package example.com/bad/p is not found")
+	}
+	if !pkg.IllTyped() {
+		t.Fatal("package example.com/bad/p should be ill-typed")
+	}
+	for _, e := range pkg.Errors {
+		if e.Position.Line == 0 {
+			t.Errorf("error position is unknown: %s", e)
+		}
+	}
+	var listed bool
+	for _, p := range analyzer.IllTypedPackages() {
+		listed = listed || p == pkg
+	}
+	if !listed {
+		t.Errorf("package example.com/bad/p is not listed as ill-typed")
+	}
+
+	// The resolved embedded fields still contribute selectors.
+	var e *TypeName
+	for _, tn := range pkg.AllTypeNames {
+		if tn.Name() == "E" {
+			e = tn
+		}
+	}
+	if e == nil {
+		t.Fatal("type E is not found")
+	}
+	var hasM bool
+	for _, sel := range e.Denoting().AllMethods {
+		if sel.Name() == "M" {
+			hasM = true
+		}
+	}
+	if !hasM {
+		t.Errorf("method M should be promoted to type E through *T")
+	}
+	if analyzer.FunctionByName(pkg, "F") == nil {
+		t.Errorf("function F is not found")
+	}
+
+	// Methods with invalid receivers are skipped without panicking.
+	for _, f := range pkg.AllFunctions {
+		if !f.IsMethod() || f.AstDecl == nil {
+			continue
+		}
+		_, id, _, ok := f.ReceiverTypeName()
+		switch f.Name() {
+		case "M2", "M3", "M4":
+			if ok {
+				t.Errorf("the receiver of method %s should be invalid, but got %s", f.Name(), id.Name)
+			}
+		case "M":
+			if !ok || id.Name != "T" {
+				t.Errorf("the receiver type of method M should be T")
+			}
+		}
+	}
+	analyzer.UnusedExporteds([]*Package{pkg})
+}
+
+func TestMultiPlatforms(t *testing.T) {
+	linux := Platform{GOOS: "linux", GOARCH: "amd64"}
+	platforms, err := ParsePlatforms("windows/amd64, linux/amd64")
+	if err != nil {
+		t.Fatalf("parse platforms error: %s", err)
+	}
+	if _, err := ParsePlatforms("linux"); err == nil {
+		t.Errorf("platform without GOARCH should be invalid")
+	}
+
+	var analyzer CodeAnalyzer
+	options := ParseOptions{GOOS: linux.GOOS, GOARCH: linux.GOARCH, Platforms: platforms}
+	analyzer.ParsePackages(nil, options, "syscall")
+	if ps := analyzer.Platforms(); len(ps) != 2 || ps[0] != linux {
+		t.Fatalf("analyzed platforms not match: %v", ps)
+	}
+
+	syscallPkg := analyzer.PackageByPath("syscall")
+	if syscallPkg == nil {
+		t.Fatal("package syscall is not found")
+	}
+	if set := analyzer.FilePlatforms(syscallPkg, "syscall_linux.go"); set != 1 {
+		t.Errorf("syscall_linux.go should be only for linux, but the set is %b", set)
+	}
+	if set := analyzer.DeclarationPlatforms(syscallPkg, "Mmap"); set != 1 {
+		t.Errorf("syscall.Mmap should be only for linux, but the set is %b", set)
+	}
+	if set := analyzer.DeclarationPlatforms(syscallPkg, "Getpagesize"); set != 0 {
+		t.Errorf("syscall.Getpagesize should be for all platforms, but the set is %b", set)
+	}
+	if set := analyzer.DeclarationPlatforms(syscallPkg, "Signal.String"); set != 0 {
+		t.Errorf("syscall.Signal.String should be for all platforms, but the set is %b", set)
+	}
+
+	// The ones only for the other platforms are also collected.
+	var foundFile bool
+	for _, filename := range analyzer.OtherPlatformFiles(syscallPkg) {
+		if filename == "syscall_linux.go" {
+			t.Errorf("syscall_linux.go should not be an other-platform file")
+		}
+		foundFile = foundFile || filename == "syscall_windows.go"
+	}
+	if !foundFile {
+		t.Errorf("syscall_windows.go should be an other-platform file")
+	}
+	var foundDecl bool
+	for _, decl := range analyzer.OtherPlatformDeclarations(syscallPkg) {
+		if decl.Name == "Mmap" {
+			t.Errorf("syscall.Mmap should not be an other-platform declaration")
+		}
+		if decl.Name == "CreateFile" {
+			foundDecl = true
+			if decl.Kind != token.FUNC || decl.Platforms != 2 {
+				t.Errorf("syscall.CreateFile should be a function only for windows, but got %v %b", decl.Kind, decl.Platforms)
+			}
+		}
+	}
+	if !foundDecl {
+		t.Errorf("syscall.CreateFile should be an other-platform declaration")
+	}
+}
+
+func TestCallGraph(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, ParseOptions{}, "io")
+	analyzer.AnalyzePackages(nil)
+
+	ioPkg := analyzer.PackageByPath("io")
+	if ioPkg == nil {
+		t.Fatal("package io is not found")
+	}
+	readFull := analyzer.FunctionByName(ioPkg, "ReadFull")
+	readAtLeast := analyzer.FunctionByName(ioPkg, "ReadAtLeast")
+	limitedRead := analyzer.FunctionByName(ioPkg, "LimitedReader.Read")
+	if readFull == nil || readAtLeast == nil || limitedRead == nil {
+		t.Fatal("functions in package io are not found")
+	}
+
+	var calledByReadFull bool
+	for _, site := range analyzer.Callers(readAtLeast) {
+		if site.Caller == readFull {
+			calledByReadFull = true
+		}
+	}
+	if !calledByReadFull {
+		t.Errorf("io.ReadAtLeast should be called by io.ReadFull")
+	}
+
+	var dynamicSite *CallSite
+	for _, site := range analyzer.Callees(readAtLeast) {
+		if site.Dynamic() && site.Interface.Name() == "Read" {
+			dynamicSite = site
+		}
+	}
+	if dynamicSite == nil {
+		t.Fatal("io.ReadAtLeast should call io.Reader.Read")
+	}
+	var possible bool
+	for _, f := range analyzer.PossibleCallees(dynamicSite) {
+		if f == limitedRead {
+			possible = true
+		}
+	}
+	if !possible {
+		t.Errorf("io.LimitedReader.Read should be a possible callee of io.Reader.Read calls")
+	}
+
+	var calledDynamically bool
+	for _, site := range analyzer.Callers(limitedRead) {
+		if site == dynamicSite {
+			calledDynamically = true
+		}
+	}
+	if !calledDynamically {
+		t.Errorf("io.LimitedReader.Read should be called in io.ReadAtLeast through io.Reader.Read")
+	}
+}
+
+func TestCallGraphThroughEmbeddedInterface(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/calls\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+type I interface{ M() }
+
+type S struct{ I }
+
+type W struct{ *S }
+
+type impl struct{}
+
+func (impl) M() {}
+
+func F(s S, w *W) {
+	s.M()
+	w.M()
+	S.M(s)
+}
+
+var _ I = impl{}
+`,
+	})
+
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackages(nil, ParseOptions{Dir: dir}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/calls/p")
+	if pkg == nil {
+		t.Fatal("package example.com/calls/p is not found")
+	}
+	f, implM := analyzer.FunctionByName(pkg, "F"), analyzer.FunctionByName(pkg, "impl.M")
+	if f == nil || implM == nil {
+		t.Fatal("functions in package example.com/calls/p are not found")
+	}
+
+	var numSites int
+	for _, site := range analyzer.Callees(f) {
+		if !site.Dynamic() || site.Interface.Name() != "M" {
+			t.Errorf("the call at %v should be a call through I.M", site.AstIdent.Pos())
+			continue
+		}
+		numSites++
+		var possible bool
+		for _, callee := range analyzer.PossibleCallees(site) {
+			possible = possible || callee == implM
+		}
+		if !possible {
+			t.Errorf("impl.M should be a possible callee of the call at %v", site.AstIdent.Pos())
+		}
+	}
+	if numSites != 3 {
+		t.Errorf("3 calls through the promoted method I.M are expected, but %d", numSites)
+	}
+}
+
+func TestUnusedExporteds(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, ParseOptions{}, "io")
+	analyzer.AnalyzePackages(nil)
+
+	errorsPkg := analyzer.PackageByPath("errors")
+	if errorsPkg == nil {
+		t.Fatal("package errors is not found")
+	}
+	unuseds := make(map[string]string)
+	for _, u := range analyzer.UnusedExporteds([]*Package{errorsPkg}) {
+		unuseds[u.Name] = u.Kind
+	}
+	if _, ok := unuseds["New"]; ok {
+		t.Errorf("errors.New is used by package io")
+	}
+	if kind := unuseds["Join"]; kind != "func" {
+		t.Errorf("errors.Join should be reported as an unused func, but the kind is %q", kind)
+	}
+}
+
+func TestStructLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/layout\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+type A struct {
+	a bool
+	b int64
+	c bool
+}
+
+type B struct {
+	b int64
+	a bool
+	c bool
+}
+
+type C struct {
+	a bool
+	z struct{}
+}
+
+type D struct{ x, y int32 }
+
+type E[T any] struct{ x T }
+
+type F int
+`,
+	})
+
+	var analyzer CodeAnalyzer
+	options := ParseOptions{Dir: dir, GOOS: "linux", GOARCH: "amd64"}
+	if !analyzer.ParsePackages(nil, options, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/layout/p")
+	if pkg == nil {
+		t.Fatal("package example.com/layout/p is not found")
+	}
+	typeNames := make(map[string]*TypeName)
+	for _, tn := range pkg.AllTypeNames {
+		typeNames[tn.Name()] = tn
+	}
+
+	testCases := []struct {
+		typeName string
+		noLayout bool
+
+		offsets, paddings []int64
+		size, padding     int64
+
+		suggestedOrder []int
+		suggestedSize  int64
+	}{
+		{
+			typeName:       "A",
+			offsets:        []int64{0, 8, 16},
+			paddings:       []int64{7, 0, 7},
+			size:           24,
+			padding:        14,
+			suggestedOrder: []int{1, 0, 2},
+			suggestedSize:  16,
+		},
+		{
+			typeName: "B",
+			offsets:  []int64{0, 8, 9},
+			paddings: []int64{0, 0, 6},
+			size:     16,
+			padding:  6,
+		},
+		{
+			// A trailing zero-size field needs padding.
+			typeName:       "C",
+			offsets:        []int64{0, 1},
+			paddings:       []int64{0, 1},
+			size:           2,
+			padding:        1,
+			suggestedOrder: []int{1, 0},
+			suggestedSize:  1,
+		},
+		{
+			typeName: "D",
+			offsets:  []int64{0, 4},
+			paddings: []int64{0, 0},
+			size:     8,
+		},
+		{typeName: "E", noLayout: true},
+		{typeName: "F", noLayout: true},
+	}
+
+	for _, tc := range testCases {
+		tn := typeNames[tc.typeName]
+		if tn == nil {
+			t.Errorf("type %s is not found", tc.typeName)
+			continue
+		}
+		layout := analyzer.StructLayout(tn)
+		if tc.noLayout {
+			if layout != nil {
+				t.Errorf("type %s should have no struct layout", tc.typeName)
+			}
+			continue
+		}
+		if layout == nil {
+			t.Errorf("type %s should have a struct layout", tc.typeName)
+			continue
+		}
+
+		if len(layout.Fields) != len(tc.offsets) {
+			t.Errorf("type %s: expects %d fields, but got %d", tc.typeName, len(tc.offsets), len(layout.Fields))
+			continue
+		}
+		for i, f := range layout.Fields {
+			if f.Offset != tc.offsets[i] || f.Padding != tc.paddings[i] {
+				t.Errorf("type %s: field %s: expects offset %d and padding %d, but got %d and %d",
+					tc.typeName, f.Name, tc.offsets[i], tc.paddings[i], f.Offset, f.Padding)
+			}
+		}
+		if layout.Size != tc.size || layout.Padding != tc.padding {
+			t.Errorf("type %s: expects size %d and padding %d, but got %d and %d",
+				tc.typeName, tc.size, tc.padding, layout.Size, layout.Padding)
+		}
+		if !reflect.DeepEqual(layout.SuggestedOrder, tc.suggestedOrder) || layout.SuggestedSize != tc.suggestedSize {
+			t.Errorf("type %s: expects suggested order %v (size %d), but got %v (size %d)",
+				tc.typeName, tc.suggestedOrder, tc.suggestedSize, layout.SuggestedOrder, layout.SuggestedSize)
+		}
+	}
+}
+
+func TestAPIElementsInTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/apis\n\ngo 1.22\n",
+		"p/p.go": "package p\n\ntype T struct{ X int }\n\nfunc (T) M() {}\n\nfunc F() {}\n\nvar V int\n\nconst C = 1\n",
+		"p/p_test.go": `package p
+
+type TestT struct{ Y int }
+
+func (TestT) N() {}
+
+func TestF() {}
+
+var TestV int
+
+const TestC = 1
+`,
+	})
+
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackages(nil, ParseOptions{Dir: dir, Tests: true}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/apis/p")
+	if pkg == nil {
+		t.Fatal("package example.com/apis/p is not found")
+	}
+	if analyzer.FunctionByName(pkg, "TestF") == nil {
+		t.Fatal("the test files are not analyzed")
+	}
+	var names []string
+	for name := range analyzer.apiElements(pkg) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if expected := "C F T T.M T.X V"; strings.Join(names, " ") != expected {
+		t.Errorf("API elements: %v, expected: %s", names, expected)
+	}
+}
+
+func TestDiffAPIElements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		old, new *apiElement
+		change   *APIChange // nil means no changes
+	}{
+		{
+			name:   "F",
+			old:    &apiElement{kind: "func", desc: "func(int) error"},
+			new:    &apiElement{kind: "func", desc: "func(int) error"},
+			change: nil,
+		},
+		{
+			name:   "F",
+			old:    &apiElement{kind: "func", desc: "func(int) error"},
+			new:    nil,
+			change: &APIChange{Kind: "func", Name: "F", Old: "func(int) error", Breaking: true},
+		},
+		{
+			name:   "F",
+			old:    nil,
+			new:    &apiElement{kind: "func", desc: "func(int) error"},
+			change: &APIChange{Kind: "func", Name: "F", New: "func(int) error"},
+		},
+		{
+			name:   "F",
+			old:    &apiElement{kind: "func", desc: "func(int) error"},
+			new:    &apiElement{kind: "func", desc: "func(int64) error"},
+			change: &APIChange{Kind: "func", Name: "F", Old: "func(int) error", New: "func(int64) error", Breaking: true},
+		},
+		{
+			name:   "V",
+			old:    &apiElement{kind: "var", desc: "int"},
+			new:    &apiElement{kind: "func", desc: "func() int"},
+			change: &APIChange{Kind: "func", Name: "V", Old: "var int", New: "func func() int", Breaking: true},
+		},
+		{
+			name:   "T.X",
+			old:    &apiElement{kind: "field", desc: "int"},
+			new:    &apiElement{kind: "field", desc: "string"},
+			change: &APIChange{Kind: "field", Name: "T.X", Old: "int", New: "string", Breaking: true},
+		},
+		{
+			name:   "T.X",
+			old:    nil,
+			new:    &apiElement{kind: "field", desc: "int"},
+			change: &APIChange{Kind: "field", Name: "T.X", New: "int"},
+		},
+		{
+			name:   "T.X",
+			old:    &apiElement{kind: "field", desc: "int"},
+			new:    nil,
+			change: &APIChange{Kind: "field", Name: "T.X", Old: "int", Breaking: true},
+		},
+		{
+			name:   "T.M",
+			old:    &apiElement{kind: "method", desc: "func()"},
+			new:    &apiElement{kind: "method", desc: "func() error"},
+			change: &APIChange{Kind: "method", Name: "T.M", Old: "func()", New: "func() error", Breaking: true},
+		},
+		{
+			name:   "T.M",
+			old:    &apiElement{kind: "method", desc: "func()"},
+			new:    nil,
+			change: &APIChange{Kind: "method", Name: "T.M", Old: "func()", Breaking: true},
+		},
+		{
+			name:   "T.M",
+			old:    nil,
+			new:    &apiElement{kind: "method", desc: "func()"},
+			change: &APIChange{Kind: "method", Name: "T.M", New: "func()"},
+		},
+		{
+			name:   "I.M",
+			old:    nil,
+			new:    &apiElement{kind: "method", desc: "func()", openInterfaceMethod: true},
+			change: &APIChange{Kind: "method", Name: "I.M", New: "func()", Breaking: true},
+		},
+		{
+			name:   "T.M",
+			old:    &apiElement{kind: "method", desc: "func()", pointerOnly: true},
+			new:    &apiElement{kind: "method", desc: "func()"},
+			change: &APIChange{Kind: "method", Name: "T.M", Old: "(pointer receiver) func()", New: "func()"},
+		},
+		{
+			name:   "T.M",
+			old:    &apiElement{kind: "method", desc: "func()"},
+			new:    &apiElement{kind: "method", desc: "func()", pointerOnly: true},
+			change: &APIChange{Kind: "method", Name: "T.M", Old: "func()", New: "(pointer receiver) func()", Breaking: true},
+		},
+	}
+
+	for i, tc := range testCases {
+		olds, news := map[string]*apiElement{}, map[string]*apiElement{}
+		if tc.old != nil {
+			olds[tc.name] = tc.old
+		}
+		if tc.new != nil {
+			news[tc.name] = tc.new
+		}
+		changes := diffAPIElements(olds, news)
+		switch {
+		case tc.change == nil && len(changes) != 0:
+			t.Errorf("case %d: expects no changes, but got %+v", i, changes)
+		case tc.change != nil && (len(changes) != 1 || changes[0] != *tc.change):
+			t.Errorf("case %d: expects change %+v, but got %+v", i, *tc.change, changes)
+		}
+	}
+}
+
+func TestInstantiatedTypes(t *testing.T) {
+	var analyzer CodeAnalyzer
+	analyzer.ParsePackages(nil, ParseOptions{}, "sync")
+	analyzer.AnalyzePackages(nil)
+
+	atomicPkg := analyzer.PackageByPath("sync/atomic")
+	if atomicPkg == nil {
+		t.Fatalf("package sync/atomic is not found")
+	}
+	var pointer *TypeInfo
+	for _, tn := range atomicPkg.AllTypeNames {
+		if tn.Name() == "Pointer" {
+			pointer = tn.Denoting()
+		}
+	}
+	if pointer == nil {
+		t.Fatalf("type sync/atomic.Pointer is not found")
+	}
+
+	const instName = "sync/atomic.Pointer[sync.poolChainElt]"
+	var inst *TypeInfo
+	for _, it := range pointer.Instantiations {
+		if it.TT.String() == instName {
+			inst = it
+		}
+		if involvesTypeParams(it.TT) {
+			t.Errorf("%v should not be listed as an instantiation", it.TT)
+		}
+	}
+	if inst == nil {
+		t.Fatalf("%s is not found in the instantiations of sync/atomic.Pointer", instName)
+	}
+	if inst.Origin != pointer {
+		t.Errorf("the origin of %s should be sync/atomic.Pointer", instName)
+	}
+	if len(inst.DirectSelectors) != len(pointer.DirectSelectors) {
+		t.Errorf("%s should have %d direct selectors, but has %d", instName, len(pointer.DirectSelectors), len(inst.DirectSelectors))
+	}
+	if tn, _ := analyzer.RetrieveTypeName(inst); tn == nil || tn.Denoting() != pointer {
+		t.Errorf("the type name of %s should be sync/atomic.Pointer", instName)
+	}
+}
+
+func TestSubstitutedInterfaceTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/subst\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+type I[T any] interface{ M() T }
+
+// The underlying type of A is an instantiated one.
+type A[T any] I[T]
+
+var _ A[int]
+
+type G[T any] struct{}
+
+// The result type of G[string].N is produced by substitution.
+func (G[T]) N() interface{ M() T } { return nil }
+
+var _ G[string]
+`,
+	})
+
+	var analyzer CodeAnalyzer
+	if !analyzer.ParsePackages(nil, ParseOptions{Dir: dir}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	pkg := analyzer.PackageByPath("example.com/subst/p")
+	if pkg == nil {
+		t.Fatal("package example.com/subst/p is not found")
+	}
+	var checkMethodM = func(what string, tt types.Type, mType string) {
+		it := analyzer.RegisterType(tt)
+		if len(it.AllMethods) != 1 || it.AllMethods[0].Name() != "M" {
+			t.Errorf("%s should have one method M, but has %d methods", what, len(it.AllMethods))
+			return
+		}
+		if mt := it.AllMethods[0].Method.Type; mt == nil || mt.TT.String() != mType {
+			t.Errorf("the method M of %s should be of type %s", what, mType)
+		}
+	}
+
+	scope := pkg.PPkg.Types.Scope()
+	aInt, err := types.Instantiate(nil, scope.Lookup("A").Type(), []types.Type{types.Typ[types.Int]}, true)
+	if err != nil {
+		t.Fatalf("instantiate A[int]: %s", err)
+	}
+	checkMethodM("A[int]", aInt, "func() int")
+
+	gString, err := types.Instantiate(nil, scope.Lookup("G").Type(), []types.Type{types.Typ[types.String]}, true)
+	if err != nil {
+		t.Fatalf("instantiate G[string]: %s", err)
+	}
+	n, _, _ := types.LookupFieldOrMethod(gString, false, pkg.PPkg.Types, "N")
+	if n == nil {
+		t.Fatal("method G[string].N is not found")
+	}
+	checkMethodM("the result type of G[string].N", n.Type().(*types.Signature).Results().At(0).Type(), "func() string")
+}
+
 // ToDo: also check method signatures.
 // There is a bug in std types.MethodSet implementation (Go SDK 1.14-)
 // https://github.com/golang/go/issues/37081
 // Luckily, his test is okay to test with the results of standard packages.
 func TestAnalyzeStandardPackage(t *testing.T) {
 	var analyzer CodeAnalyzer
-	analyzer.ParsePackages(nil, "std")
+	analyzer.ParsePackages(nil, ParseOptions{}, "std")
 	analyzer.AnalyzePackages(nil)
 
 	var cache = &typeutil.MethodSetCache{}
@@ -132,3 +1331,101 @@ func TestAnalyzeStandardPackage(t *testing.T) {
 		}
 	}
 }
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAnalysisCache(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/cached\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+import "errors"
+
+// F returns an error.
+func F(n int) error {
+	if n > 0 {
+		return errors.New("positive")
+	}
+	return nil
+}
+`,
+		"r/r.go": "package r\n\nimport \"example.com/cached/p\"\n\nvar _ = p.F\n",
+	})
+	options := ParseOptions{Dir: dir, CacheDir: filepath.Join(t.TempDir(), "cache")}
+
+	analyze := func() *CodeAnalyzer {
+		var analyzer CodeAnalyzer
+		if !analyzer.ParsePackages(nil, options, "./...") {
+			t.Fatal("failed to parse packages")
+		}
+		analyzer.AnalyzePackages(nil)
+		return &analyzer
+	}
+
+	first := analyze()
+	if first.cache == nil || first.cache.numReused != 0 {
+		t.Fatal("the analysis cache should be created but not used")
+	}
+	if _, err := os.Stat(first.cache.file); err != nil {
+		t.Fatalf("the analysis cache is not saved: %s", err)
+	}
+
+	second := analyze()
+	if second.cache.numReused != second.NumPackages() || second.cache.modified {
+		t.Fatalf("the statistics of all packages should be reused: %d of %d", second.cache.numReused, second.NumPackages())
+	}
+	if second.NumPackages() != first.NumPackages() {
+		t.Errorf("package counts not match: %d vs. %d", second.NumPackages(), first.NumPackages())
+	}
+	if second.Statistics() != first.Statistics() {
+		t.Errorf("statistics not match:\n%+v\nvs.\n%+v", second.Statistics(), first.Statistics())
+	}
+	pkg := second.PackageByPath("example.com/cached/p")
+	if pkg == nil {
+		t.Fatal("package example.com/cached/p is not found")
+	}
+	metrics, _ := second.FunctionByName(pkg, "F").Metrics()
+	firstMetrics, _ := first.FunctionByName(first.PackageByPath("example.com/cached/p"), "F").Metrics()
+	if metrics != firstMetrics || metrics.Complexity != 2 {
+		t.Errorf("function metrics not match: %+v vs. %+v", metrics, firstMetrics)
+	}
+
+	// Modifying a source file invalidates the statistics of
+	// the package and the packages depending on it.
+	writeTestFiles(t, dir, map[string]string{
+		"p/p.go": "package p\n\nfunc F() {}\n",
+	})
+	third := analyze()
+	if n := third.NumPackages() - 2; third.cache.numReused != n {
+		t.Errorf("the statistics of %d packages should be reused, but %d", n, third.cache.numReused)
+	}
+	if metrics, _ := third.FunctionByName(third.PackageByPath("example.com/cached/p"), "F").Metrics(); metrics.Complexity != 1 {
+		t.Errorf("the metrics of the modified function are not updated: %+v", metrics)
+	}
+	if n := analyze().cache.numReused; n != third.NumPackages() {
+		t.Errorf("the statistics of all packages should be reused again, but %d", n)
+	}
+
+	// Adding a package doesn't invalidate the others.
+	writeTestFiles(t, dir, map[string]string{
+		"q/q.go": "package q\n",
+	})
+	fourth := analyze()
+	if fourth.PackageByPath("example.com/cached/q") == nil {
+		t.Fatal("package example.com/cached/q is not found")
+	}
+	if n := fourth.NumPackages() - 1; fourth.cache.numReused != n {
+		t.Errorf("the statistics of %d packages should be reused, but %d", n, fourth.cache.numReused)
+	}
+}
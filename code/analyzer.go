@@ -7,6 +7,7 @@ import (
 	"go/types"
 	"log"
 	"reflect"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/types/typeutil"
@@ -29,11 +30,13 @@ const (
 	SubTask_CollectSourceFiles
 	SubTask_CollectObjectReferences
 	SubTask_CacheSourceFiles
+	SubTask_BuildCallGraph
 )
 
 type CodeAnalyzer struct {
-	allModules []*Module
-	stdModule  *Module
+	allModules  []*Module // sorted by paths, except the std module is always the first one
+	moduleTable map[string]*Module
+	stdModule   *Module
 
 	//stdPackages  map[string]struct{}
 	packageTable map[string]*Package
@@ -68,15 +71,29 @@ type CodeAnalyzer struct {
 	// Why not put []RefPos in TypeInfo, Variable, ...?
 	refPositions map[interface{}][]RefPos
 
-	// ToDo: some TopN lists
-	stats Stats
+	stats     Stats
+	topNLists TopNLists
 
 	// Identifer references (ToDo: need optimizations)
 	objectRefs map[types.Object][]Identifier
 
+	deprecatedObjects map[types.Object]deprecation
+
+	// Static calls and calls through interface methods.
+	callGraph callGraph
+
 	// Not concurrent safe.
 	tempTypeLookup map[uint32]struct{}
 
+	// The platform on which packages are fully analyzed.
+	targetPlatform Platform
+
+	// The analyzed platforms in the multi-platform mode.
+	platforms []Platform
+
+	// Nil if caching is disabled.
+	cache *analysisCache
+
 	//
 	forbidRegisterTypes bool // for debug
 
@@ -94,9 +111,11 @@ type Stats struct {
 
 	FilesWithoutGenerateds int32 // without generated ones
 	FilesWithGenerateds    int32 // with generated ones
-	CodeLines              int32 // ToDo: stat code lines. Use the info in AstFile?
+	CodeLines              int32 // all lines of Go source files
 	BlankCodeLines         int32
 	CommentCodeLines       int32
+	FilesByCodeLines       [60]int32 // by FileCodeLinesStep
+	PackagesByCodeLines    [50]int32 // by PackageCodeLinesStep
 
 	// To calculate imports per file.
 	// Deps per packages are available in other ways.
@@ -179,10 +198,42 @@ func incSliceStat(stats []int32, index int) {
 	}
 }
 
+// Statistics returns the statistics of all the analyzed packages.
 func (d *CodeAnalyzer) Statistics() Stats {
 	return d.stats
 }
 
+// StatisticsOf returns the statistics of the specified packages.
+func (d *CodeAnalyzer) StatisticsOf(pkgs []*Package) Stats {
+	var stats Stats
+	for _, pkg := range pkgs {
+		stats.add(&pkg.stats)
+	}
+	return stats
+}
+
+// add adds the values of all the (int32 and [N]int32) fields of another Stats.
+func (s *Stats) add(other *Stats) {
+	v, o := reflect.ValueOf(s).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Int32:
+			f.SetInt(f.Int() + o.Field(i).Int())
+		case reflect.Array:
+			for k := 0; k < f.Len(); k++ {
+				e := f.Index(k)
+				e.SetInt(e.Int() + o.Field(i).Index(k).Int())
+			}
+		}
+	}
+	s.roughTypeNameCount += other.roughTypeNameCount
+	s.roughExportedIdentifierCount += other.roughExportedIdentifierCount
+}
+
 func (d *CodeAnalyzer) RoughTypeNameCount() int32 {
 	return d.stats.roughTypeNameCount
 }
@@ -237,6 +288,23 @@ func (d *CodeAnalyzer) resetTempTypeLookupTable() {
 	}
 }
 
+func (d *CodeAnalyzer) NumModules() int {
+	return len(d.allModules)
+}
+
+func (d *CodeAnalyzer) ModuleAt(i int) *Module {
+	return d.allModules[i]
+}
+
+// The path of the std module is "std".
+func (d *CodeAnalyzer) ModuleByPath(path string) *Module {
+	return d.moduleTable[path]
+}
+
+func (d *CodeAnalyzer) StandardModule() *Module {
+	return d.stdModule
+}
+
 func (d *CodeAnalyzer) NumPackages() int {
 	return len(d.packageList)
 }
@@ -250,6 +318,20 @@ func (d *CodeAnalyzer) PackageByPath(path string) *Package {
 	return d.packageTable[path]
 }
 
+// IllTypedPackages returns the packages having errors, sorted by paths.
+func (d *CodeAnalyzer) IllTypedPackages() []*Package {
+	var pkgs []*Package
+	for _, pkg := range d.packageList {
+		if pkg.IllTyped() {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Path() < pkgs[j].Path()
+	})
+	return pkgs
+}
+
 func (d *CodeAnalyzer) IsStandardPackage(pkg *Package) bool {
 	return pkg.Mod == d.stdModule
 }
@@ -356,6 +438,9 @@ func (d *CodeAnalyzer) RegisterType(t types.Type) *TypeInfo {
 //var numNameds, numNamedInterfaces = 0, 0
 
 func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *TypeInfo {
+	// Aliases (including the builtin any) denote the types they alias.
+	t = types.Unalias(t)
+
 	typeInfo, _ := d.ttype2TypeInfoTable.At(t).(*TypeInfo)
 	if typeInfo == nil && createOnNonexist {
 		if d.forbidRegisterTypes {
@@ -379,6 +464,10 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 		case *types.Named:
 			//typeInfo.Name = t.Obj().Name()
 
+			if origin := t.Origin(); origin != t {
+				typeInfo.Origin = d.RegisterType(origin)
+			}
+
 			underlying := d.RegisterType(t.Underlying())
 			typeInfo.Underlying = underlying
 			//underlying.Underlying = underlying // already done
@@ -404,13 +493,20 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 	return typeInfo
 }
 
+// For an instantiated type, the type name of its generic type is returned.
 func (d *CodeAnalyzer) RetrieveTypeName(t *TypeInfo) (*TypeName, bool) {
+	if t.Origin != nil {
+		t = t.Origin
+	}
 	if tn := t.TypeName; tn != nil {
 		return tn, false
 	}
 
 	if ptt, ok := t.TT.(*types.Pointer); ok {
 		bt := d.RegisterType(ptt.Elem())
+		if bt.Origin != nil {
+			bt = bt.Origin
+		}
 		if btn := bt.TypeName; btn != nil {
 			return btn, true
 		}
@@ -457,6 +553,18 @@ func (d *CodeAnalyzer) CheckTypeMethodContributingToTypeImplementations(pkg, typ
 	return ok
 }
 
+// HasCleanImplements is a cheap version of len(d.CleanImplements(self)) > 0.
+func (d *CodeAnalyzer) HasCleanImplements(self *TypeInfo) bool {
+	// The only ones removed from a non-blank list
+	// are self and its underlying interface type.
+	for _, impl := range self.Implements {
+		if it := impl.Interface; it != self && it != self.Underlying {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *CodeAnalyzer) CleanImplements(self *TypeInfo) []Implementation {
 	// remove:
 	// * self
@@ -536,6 +644,14 @@ func (d *CodeAnalyzer) iterateTypenames(typeLiteral ast.Expr, pkg *Package, onTy
 		d.iterateTypenames(node.Value, pkg, onTypeName)
 	case *ast.ChanType:
 		d.iterateTypenames(node.Value, pkg, onTypeName)
+	case *ast.IndexExpr: // G[T]
+		d.iterateTypenames(node.X, pkg, onTypeName)
+		d.iterateTypenames(node.Index, pkg, onTypeName)
+	case *ast.IndexListExpr: // G[T1, T2]
+		d.iterateTypenames(node.X, pkg, onTypeName)
+		for _, index := range node.Indices {
+			d.iterateTypenames(index, pkg, onTypeName)
+		}
 	// To avoid return too much weak-related results, the following types are ignored now.
 	case *ast.FuncType:
 	case *ast.StructType:
@@ -573,6 +689,17 @@ func (d *CodeAnalyzer) lookForAndRegisterUnnamedInterfaceAndStructTypes(typeLite
 	case *ast.MapType:
 		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Key, pkg)
 		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Value, pkg)
+	case *ast.IndexExpr: // G[T]
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Index, pkg)
+	case *ast.IndexListExpr: // G[T1, T2]
+		for _, index := range node.Indices {
+			d.lookForAndRegisterUnnamedInterfaceAndStructTypes(index, pkg)
+		}
+	case *ast.UnaryExpr: // ~T in constraints
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.X, pkg)
+	case *ast.BinaryExpr: // T1 | T2 in constraints
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.X, pkg)
+		d.lookForAndRegisterUnnamedInterfaceAndStructTypes(node.Y, pkg)
 	case *ast.StructType:
 		tv := pkg.PPkg.TypesInfo.Types[node]
 		typeInfo := d.RegisterType(tv.Type)
@@ -616,24 +743,38 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 		typeInfo.DirectSelectors = append(typeInfo.DirectSelectors, sel)
 	}
 
+	// Embedded fields with unresolved types (in ill-typed packages) are skipped.
+NextField:
 	for _, field := range astStructNode.Fields.List {
 		if len(field.Names) == 0 {
 			var id string
 
-			var isStar = false
-			for ok, node := true, field.Type; ok; ok = isStar {
+			var isStar, instantiated = false, false
+			for node := field.Type; id == ""; {
 				switch expr := node.(type) {
 				default:
+					if pkg.IllTyped() {
+						continue NextField
+					}
 					panic("not an embedded field but should be. type: " + fmt.Sprintf("%T", expr))
 				case *ast.Ident:
 					//id = d.Id1b(pkg, expr.Name) // incorrect for builtin typenames
 
 					tn := pkg.PPkg.TypesInfo.Uses[expr]
+					if tn == nil {
+						continue NextField
+					}
 					id = d.Id2(tn.Pkg(), expr.Name)
 
 				case *ast.SelectorExpr:
-					srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-					srcPkg := srcObj.(*types.PkgName)
+					x, _ := expr.X.(*ast.Ident)
+					if x == nil {
+						continue NextField
+					}
+					srcPkg, ok := pkg.PPkg.TypesInfo.ObjectOf(x).(*types.PkgName)
+					if !ok {
+						continue NextField
+					}
 					id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
 				case *ast.StarExpr:
 					if isStar {
@@ -642,14 +783,20 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 
 					node = expr.X
 					isStar = true
-					continue
+				case *ast.IndexExpr: // G[T]
+					node = expr.X
+					instantiated = true
+				case *ast.IndexListExpr: // G[T1, T2]
+					node = expr.X
+					instantiated = true
 				}
-
-				break
 			}
 
 			tn := d.allTypeNameTable[id]
 			if tn == nil {
+				if pkg.IllTyped() {
+					continue
+				}
 				panic("TypeName for " + id + " not found")
 			}
 
@@ -658,12 +805,24 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 			//}
 
 			fieldTypeInfo := tn.Named
-			if fieldTypeInfo == nil {
+			if instantiated {
+				tt := pkg.PPkg.TypesInfo.TypeOf(field.Type)
+				if tt == nil || tt == types.Typ[types.Invalid] {
+					continue
+				}
+				fieldTypeInfo = d.RegisterType(tt)
+				if isStar {
+					// The base type must be also registered before collecting selectors.
+					d.RegisterType(fieldTypeInfo.TT.(*types.Pointer).Elem())
+				}
+			} else if fieldTypeInfo == nil {
 				fieldTypeInfo = tn.Alias.Denoting
 			}
 			embedMode := EmbedMode_Direct
 			if isStar {
-				fieldTypeInfo = d.RegisterType(types.NewPointer(fieldTypeInfo.TT))
+				if !instantiated {
+					fieldTypeInfo = d.RegisterType(types.NewPointer(fieldTypeInfo.TT))
+				}
 				embedMode = EmbedMode_Indirect
 			}
 
@@ -846,31 +1005,71 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 
 	//log.Println("!!!!!! registerExplicitlySpecifiedMethods:", typeInfo)
 
+NextMethod:
 	for _, method := range astInterfaceNode.Methods.List {
 		// method is a *ast.Field.
 
 		if len(method.Names) == 0 { // embed interface type (annoymous field)
 
+			// Since Go 1.18, the embedded elements might be type set terms,
+			// such as ~int, int | string and non-interface type names.
+			// They contribute no methods.
+			embeddedTT := pkg.PPkg.TypesInfo.TypeOf(method.Type)
+			if embeddedTT == nil {
+				continue
+			}
+			if _, ok := embeddedTT.(*types.TypeParam); ok {
+				continue
+			}
+			if _, ok := embeddedTT.Underlying().(*types.Interface); !ok {
+				continue
+			}
+
+			// Embedded interfaces with unresolved types (in ill-typed packages) are skipped.
 			var id string
-			switch expr := method.Type.(type) {
-			default:
-				panic("not a valid embedding interface type name")
-			case *ast.Ident:
-				ttn := pkg.PPkg.TypesInfo.Uses[expr]
-				id = d.Id2(ttn.Pkg(), ttn.Name())
-			case *ast.SelectorExpr:
-				srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-				srcPkg := srcObj.(*types.PkgName)
-				id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+			var instantiated bool
+			for typeExpr := method.Type; id == ""; {
+				switch expr := typeExpr.(type) {
+				default:
+					if pkg.IllTyped() {
+						continue NextMethod
+					}
+					panic("not a valid embedding interface type name")
+				case *ast.Ident:
+					ttn := pkg.PPkg.TypesInfo.Uses[expr]
+					if ttn == nil {
+						continue NextMethod
+					}
+					id = d.Id2(ttn.Pkg(), ttn.Name())
+				case *ast.SelectorExpr:
+					x, _ := expr.X.(*ast.Ident)
+					if x == nil {
+						continue NextMethod
+					}
+					srcPkg, ok := pkg.PPkg.TypesInfo.ObjectOf(x).(*types.PkgName)
+					if !ok {
+						continue NextMethod
+					}
+					id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+				case *ast.IndexExpr: // I[T]
+					typeExpr, instantiated = expr.X, true
+				case *ast.IndexListExpr: // I[T1, T2]
+					typeExpr, instantiated = expr.X, true
+				}
 			}
 
 			tn := d.allTypeNameTable[id]
 			if tn == nil {
+				if pkg.IllTyped() {
+					continue
+				}
 				panic("TypeName for " + id + " not found")
 			}
 
 			fieldTypeInfo := tn.Named
-			if fieldTypeInfo == nil {
+			if instantiated {
+				fieldTypeInfo = d.RegisterType(embeddedTT)
+			} else if fieldTypeInfo == nil {
 				fieldTypeInfo = tn.Alias.Denoting
 			}
 			embedMode := EmbedMode_Direct
@@ -956,11 +1155,21 @@ func (d *CodeAnalyzer) registerExplicitlyDeclaredMethod(f *Function) {
 		baseTT = tt
 		ptrRecv = false
 	case *types.Pointer:
-		baseTT = tt.Elem().(*types.Named)
+		named, ok := tt.Elem().(*types.Named)
+		if !ok && pkg.IllTyped() {
+			return // the receiver base type is unresolved
+		}
+		baseTT = named
 		ptrRecv = true
 	default:
+		if pkg.IllTyped() {
+			return // the receiver type is unresolved
+		}
 		panic("impossible")
 	}
+	// The receiver base type of a method of a generic type is
+	// instantiated with the receiver type parameters.
+	baseTT = baseTT.Origin()
 
 	// ToDo: using sig.Params() and sig.Results() instead of funcObj.Type()
 
@@ -1069,6 +1278,10 @@ func (d *CodeAnalyzer) registerFunctionForInvolvedTypeNames(f FunctionResource)
 
 func (d *CodeAnalyzer) registerValueForItsTypeName(res ValueResource) {
 	t := res.TypeInfo(d)
+	if t.Origin != nil {
+		// Values of instantiated types are listed for their generic types.
+		t = t.Origin
+	}
 	toRegsiter := t.TypeName != nil
 	if !toRegsiter {
 		// ToDo: also for []T, [N]T, chan T, etc.
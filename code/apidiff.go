@@ -0,0 +1,340 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// APIChange is a change of an exported API element between two revisions.
+type APIChange struct {
+	// "package", "type", "field", "method", "func", "var", "const" or "implements".
+	Kind string
+
+	// "Name", "Type.Selector" or "Type implements pkg.Interface".
+	// Blank for package changes.
+	Name string
+
+	// Old is blank for added elements. New is blank for removed ones.
+	Old, New string
+
+	// Whether or not the change might break the code of package users,
+	// per the Go 1 compatibility rules.
+	Breaking bool
+}
+
+func (c *APIChange) Added() bool {
+	return c.Old == ""
+}
+
+func (c *APIChange) Removed() bool {
+	return c.New == ""
+}
+
+// PackageAPIDiff is the API changes of a package, sorted by names.
+type PackageAPIDiff struct {
+	Path    string
+	Changes []APIChange
+}
+
+func (pd *PackageAPIDiff) NumBreakingChanges() int {
+	var n int
+	for i := range pd.Changes {
+		if pd.Changes[i].Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+// apiElement is an exported API element of a package.
+type apiElement struct {
+	kind string
+	desc string
+
+	// For methods, whether or not the method is only in the method set of
+	// the pointer type. For implementation relations, whether or not only
+	// the pointer type implements the interface.
+	pointerOnly bool
+
+	// For methods of interfaces which can be implemented by other packages.
+	// Adding such methods is a breaking change.
+	openInterfaceMethod bool
+}
+
+func (e *apiElement) String() string {
+	if e.pointerOnly {
+		if e.kind == "implements" {
+			return "*" + e.desc
+		}
+		return "(pointer receiver) " + e.desc
+	}
+	return e.desc
+}
+
+// DiffAPIs compares the exported APIs of the packages with the same
+// paths in two analyses. Only the packages with changes are returned,
+// sorted by paths. Main packages and internal packages are ignored.
+//
+// The rules of breaking changes are simplified from the Go 1 compatibility
+// promise: removing or changing an element is breaking (except making
+// a pointer-only method or implementation available to the value type),
+// and adding a method to an interface type which can be implemented
+// by other packages is breaking. Adding other elements is compatible.
+func DiffAPIs(oldAnalyzer, newAnalyzer *CodeAnalyzer, oldPkgs, newPkgs []*Package) []PackageAPIDiff {
+	var olds = make(map[string]*Package, len(oldPkgs))
+	for _, pkg := range oldPkgs {
+		if isAPIPackage(pkg) {
+			olds[pkg.Path()] = pkg
+		}
+	}
+	var news = make(map[string]*Package, len(newPkgs))
+	for _, pkg := range newPkgs {
+		if isAPIPackage(pkg) {
+			news[pkg.Path()] = pkg
+		}
+	}
+
+	var diffs []PackageAPIDiff
+	for path, oldPkg := range olds {
+		newPkg := news[path]
+		if newPkg == nil {
+			diffs = append(diffs, PackageAPIDiff{
+				Path:    path,
+				Changes: []APIChange{{Kind: "package", Old: path, Breaking: true}},
+			})
+			continue
+		}
+		changes := diffAPIElements(oldAnalyzer.apiElements(oldPkg), newAnalyzer.apiElements(newPkg))
+		if len(changes) > 0 {
+			diffs = append(diffs, PackageAPIDiff{Path: path, Changes: changes})
+		}
+	}
+	for path := range news {
+		if olds[path] == nil {
+			diffs = append(diffs, PackageAPIDiff{
+				Path:    path,
+				Changes: []APIChange{{Kind: "package", New: path}},
+			})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+func isAPIPackage(pkg *Package) bool {
+	path := pkg.Path()
+	if pkg.PPkg.Name == "main" || strings.HasSuffix(path, "_test") {
+		return false
+	}
+	return !strings.HasSuffix(path, "/internal") && !strings.Contains(path, "/internal/") && !strings.HasPrefix(path, "internal/")
+}
+
+func diffAPIElements(olds, news map[string]*apiElement) []APIChange {
+	var changes []APIChange
+	for name, o := range olds {
+		n := news[name]
+		switch {
+		case n == nil:
+			changes = append(changes, APIChange{Kind: o.kind, Name: name, Old: o.String(), Breaking: true})
+		case o.kind != n.kind:
+			changes = append(changes, APIChange{Kind: n.kind, Name: name, Old: o.kind + " " + o.String(), New: n.kind + " " + n.String(), Breaking: true})
+		case o.desc != n.desc:
+			changes = append(changes, APIChange{Kind: n.kind, Name: name, Old: o.String(), New: n.String(), Breaking: true})
+		case o.pointerOnly != n.pointerOnly:
+			// Becoming available to the value type is compatible.
+			changes = append(changes, APIChange{Kind: n.kind, Name: name, Old: o.String(), New: n.String(), Breaking: n.pointerOnly})
+		}
+	}
+	for name, n := range news {
+		if olds[name] == nil {
+			changes = append(changes, APIChange{Kind: n.kind, Name: name, New: n.String(), Breaking: n.openInterfaceMethod})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// apiElements collects the exported API elements of a package.
+func (d *CodeAnalyzer) apiElements(pkg *Package) map[string]*apiElement {
+	var qualifier = func(p *types.Package) string {
+		if p == pkg.PPkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+	var typeString = func(t types.Type) string {
+		return types.TypeString(t, qualifier)
+	}
+	// Like UnusedExporteds, the identifiers declared
+	// in _test.go files are not viewed as APIs.
+	var declaredInTestFile = func(pos token.Pos) bool {
+		return strings.HasSuffix(pkg.PPkg.Fset.PositionFor(pos, false).Filename, "_test.go")
+	}
+
+	var elements = make(map[string]*apiElement, 256)
+	for _, tn := range pkg.AllTypeNames {
+		if !tn.Exported() || declaredInTestFile(tn.TypeName.Pos()) {
+			continue
+		}
+
+		name := tn.Name()
+		if tn.Alias != nil {
+			elements[name] = &apiElement{kind: "type", desc: "= " + typeString(tn.TypeName.Type())}
+			continue
+		}
+
+		named, ok := tn.TypeName.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		var desc strings.Builder
+		if tps := named.TypeParams(); tps.Len() > 0 {
+			desc.WriteByte('[')
+			for i := 0; i < tps.Len(); i++ {
+				if i > 0 {
+					desc.WriteString(", ")
+				}
+				desc.WriteString(tps.At(i).Obj().Name())
+				desc.WriteByte(' ')
+				desc.WriteString(typeString(tps.At(i).Constraint()))
+			}
+			desc.WriteString("] ")
+		}
+		var isInterface bool
+		switch u := named.Underlying().(type) {
+		case *types.Struct:
+			desc.WriteString("struct")
+		case *types.Interface:
+			desc.WriteString("interface")
+			isInterface = true
+		default:
+			desc.WriteString(typeString(u))
+		}
+		elements[name] = &apiElement{kind: "type", desc: desc.String()}
+
+		t := tn.Denoting()
+		if t == nil {
+			continue
+		}
+
+		var openInterface = isInterface
+		for _, sel := range t.AllMethods {
+			if !token.IsExported(sel.Name()) {
+				openInterface = false
+			}
+		}
+		for _, sel := range t.AllFields {
+			if !token.IsExported(sel.Name()) || sel.Field.Type == nil {
+				continue
+			}
+			if sel.Field.AstField != nil && declaredInTestFile(sel.Field.AstField.Pos()) {
+				continue
+			}
+			elements[name+"."+sel.Name()] = &apiElement{kind: "field", desc: typeString(sel.Field.Type.TT)}
+		}
+		for _, sel := range t.AllMethods {
+			if !token.IsExported(sel.Name()) || sel.Method.Type == nil {
+				continue
+			}
+			if m := sel.Method; m.AstFunc != nil && declaredInTestFile(m.AstFunc.Pos()) || m.AstField != nil && declaredInTestFile(m.AstField.Pos()) {
+				continue
+			}
+			sig, ok := sel.Method.Type.TT.(*types.Signature)
+			if !ok {
+				continue
+			}
+			elements[name+"."+sel.Name()] = &apiElement{
+				kind:                "method",
+				desc:                signatureString(sig, qualifier),
+				pointerOnly:         !isInterface && sel.PointerReceiverOnly(),
+				openInterfaceMethod: openInterface,
+			}
+		}
+
+		if !isInterface {
+			for _, impl := range d.CleanImplements(t) {
+				itn := impl.Interface.TypeName
+				if itn == nil || !itn.Exported() || !isAPIPackage(itn.Pkg) || declaredInTestFile(itn.TypeName.Pos()) {
+					continue
+				}
+				_, isPointer := impl.Impler.TT.(*types.Pointer)
+				elements[name+" implements "+typeString(itn.TypeName.Type())] = &apiElement{
+					kind:        "implements",
+					desc:        name,
+					pointerOnly: isPointer,
+				}
+			}
+		}
+	}
+
+	for _, f := range pkg.AllFunctions {
+		if f.Func == nil || f.IsMethod() || !f.Func.Exported() || declaredInTestFile(f.Func.Pos()) {
+			continue
+		}
+		elements[f.Name()] = &apiElement{kind: "func", desc: signatureString(f.Func.Type().(*types.Signature), qualifier)}
+	}
+	for _, v := range pkg.AllVariables {
+		if v.Exported() && !declaredInTestFile(v.Var.Pos()) {
+			elements[v.Name()] = &apiElement{kind: "var", desc: typeString(v.Var.Type())}
+		}
+	}
+	for _, c := range pkg.AllConstants {
+		if c.Exported() && !declaredInTestFile(c.Const.Pos()) {
+			elements[c.Name()] = &apiElement{kind: "const", desc: typeString(c.Const.Type()) + " = " + c.Const.Val().ExactString()}
+		}
+	}
+	return elements
+}
+
+// signatureString returns the text of a function signature without
+// the parameter names, which are not parts of APIs.
+func signatureString(sig *types.Signature, qualifier types.Qualifier) string {
+	var b strings.Builder
+	b.WriteString("func")
+	if tps := sig.TypeParams(); tps.Len() > 0 {
+		b.WriteByte('[')
+		for i := 0; i < tps.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(tps.At(i).Obj().Name())
+			b.WriteByte(' ')
+			b.WriteString(types.TypeString(tps.At(i).Constraint(), qualifier))
+		}
+		b.WriteByte(']')
+	}
+	var writeTuple = func(tuple *types.Tuple, variadic bool) {
+		for i := 0; i < tuple.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			t := tuple.At(i).Type()
+			if variadic && i == tuple.Len()-1 {
+				b.WriteString("...")
+				t = t.(*types.Slice).Elem()
+			}
+			b.WriteString(types.TypeString(t, qualifier))
+		}
+	}
+	b.WriteByte('(')
+	writeTuple(sig.Params(), sig.Variadic())
+	b.WriteByte(')')
+	switch results := sig.Results(); results.Len() {
+	case 0:
+	case 1:
+		b.WriteByte(' ')
+		writeTuple(results, false)
+	default:
+		b.WriteString(" (")
+		writeTuple(results, false)
+		b.WriteByte(')')
+	}
+	return b.String()
+}
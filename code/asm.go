@@ -0,0 +1,199 @@
+package code
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An AsmFunction is a function implemented in an assembly (.s) file
+// by a TEXT directive.
+type AsmFunction struct {
+	Pkg  *Package // the package containing the assembly file
+	File string   // the full path of the assembly file
+	Line int
+
+	// The name without the package qualifier.
+	Name string
+
+	// The path of the package the function belongs to.
+	// It is different from Pkg.Path() for some runtime functions.
+	PkgPath string
+
+	// The GOARCH in the filename, or the //go:build constraint
+	// expression in the file, or blank.
+	Arch string
+
+	// The body-less Go declaration. Nil if it is not found.
+	Func *Function
+}
+
+// AsmImplementations returns the assembly implementations of a body-less
+// function, one for each architecture the function is implemented for.
+func (f *Function) AsmImplementations() []*AsmFunction {
+	return f.asmImpls
+}
+
+// AsmFunctions returns the functions implemented in the assembly files of the package.
+func (p *Package) AsmFunctions() []*AsmFunction {
+	return p.asmFuncs
+}
+
+var knownGoArchs = map[string]bool{
+	"386": true, "amd64": true, "arm": true, "arm64": true,
+	"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+	"ppc64": true, "ppc64le": true, "riscv64": true, "s390x": true, "wasm": true,
+}
+
+// collectAssemblyFiles makes the assembly files in the package directory
+// (not only the ones for the current GOARCH) source files of the package.
+func (d *CodeAnalyzer) collectAssemblyFiles(pkg *Package) {
+	if len(pkg.PPkg.GoFiles) == 0 {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(pkg.PPkg.GoFiles[0]), "*.s"))
+	if err != nil {
+		return
+	}
+	for _, path := range files {
+		if _, ok := d.sourceFile2PackageTable[path]; ok {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		d.sourceFile2PackageTable[path] = pkg
+		pkg.SourceFiles = append(pkg.SourceFiles,
+			SourceFileInfo{
+				Pkg:          pkg,
+				BareFilename: filepath.Base(path),
+				OriginalFile: path,
+			},
+		)
+	}
+}
+
+// collectAssemblyFunctions parses the TEXT directives in the assembly files
+// of a package and links the found functions with their Go declarations.
+// It must be called after the source files are cached.
+func (d *CodeAnalyzer) collectAssemblyFunctions(pkg *Package) {
+	pkg.asmFuncs = nil
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		if info.AstFile != nil || !strings.HasSuffix(info.OriginalFile, ".s") {
+			continue
+		}
+		arch := asmFileArch(info.BareFilename, info.Content)
+		for _, text := range parseAsmTextDirectives(info.Content) {
+			pkgPath := pkg.Path()
+			if text.qualifier != "" {
+				pkgPath = strings.ReplaceAll(text.qualifier, "∕", "/")
+			}
+			pkg.asmFuncs = append(pkg.asmFuncs, &AsmFunction{
+				Pkg:     pkg,
+				File:    info.OriginalFile,
+				Line:    text.line,
+				Name:    text.name,
+				PkgPath: pkgPath,
+				Arch:    arch,
+			})
+		}
+	}
+
+	for _, af := range pkg.asmFuncs {
+		goPkg := pkg
+		if af.PkgPath != pkg.Path() {
+			if goPkg = d.PackageByPath(af.PkgPath); goPkg == nil {
+				continue
+			}
+		}
+		for _, f := range goPkg.AllFunctions {
+			if f.Name() == af.Name && !f.IsMethod() && f.AstDecl != nil && f.AstDecl.Body == nil {
+				af.Func = f
+				f.asmImpls = append(f.asmImpls, af)
+				break
+			}
+		}
+	}
+}
+
+// asmFileArch returns the architecture an assembly file is for.
+func asmFileArch(bareFilename string, content []byte) string {
+	name := strings.TrimSuffix(bareFilename, ".s")
+	if i := strings.LastIndexByte(name, '_'); i >= 0 && knownGoArchs[name[i+1:]] {
+		return name[i+1:]
+	}
+	for data := content; len(data) > 0; {
+		var line []byte
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			line, data = data, nil
+		}
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("//go:build ")) {
+			return string(bytes.TrimSpace(line[len("//go:build "):]))
+		}
+		if len(line) > 0 && !bytes.HasPrefix(line, []byte("//")) {
+			break // build constraints must precede other content
+		}
+	}
+	return ""
+}
+
+type asmTextDirective struct {
+	line      int
+	qualifier string // blank for the current package
+	name      string
+}
+
+// parseAsmTextDirectives finds the TEXT directives in assembly code,
+// such as "TEXT ·Sqrt(SB),NOSPLIT,$0" and "TEXT runtime·memmove<ABIInternal>(SB)".
+func parseAsmTextDirectives(content []byte) []asmTextDirective {
+	var texts []asmTextDirective
+	var inComment bool
+	for lineNumber, data := 1, content; len(data) > 0; lineNumber++ {
+		var line []byte
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			line, data = data, nil
+		}
+		if inComment {
+			i := bytes.Index(line, []byte("*/"))
+			if i < 0 {
+				continue
+			}
+			line, inComment = line[i+2:], false
+		}
+		if i := bytes.Index(line, []byte("/*")); i >= 0 && !bytes.Contains(line[i:], []byte("*/")) {
+			line, inComment = line[:i], true
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("TEXT")) || len(line) == 4 || (line[4] != ' ' && line[4] != '\t') {
+			continue
+		}
+		line = bytes.TrimSpace(line[4:])
+		end := bytes.Index(line, []byte("(SB)"))
+		if end < 0 {
+			continue
+		}
+		symbol := string(line[:end])
+		if i := strings.IndexByte(symbol, '<'); i >= 0 {
+			symbol = symbol[:i] // ABI selector
+		}
+		i := strings.LastIndex(symbol, "·")
+		if i < 0 {
+			continue // not a Go symbol
+		}
+		name := symbol[i+len("·"):]
+		if name == "" {
+			continue
+		}
+		texts = append(texts, asmTextDirective{lineNumber, symbol[:i], name})
+	}
+	return texts
+}
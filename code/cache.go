@@ -0,0 +1,257 @@
+package code
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"go101.org/gold/internal/util"
+)
+
+// The analysis results hold go/types objects and AST nodes, which can't be
+// serialized, and many of them cross packages (implementations, references,
+// call graphs, ...). So packages are always loaded and analyzed, and what are
+// cached on disk are the statistics of packages, files and functions, which
+// are costly to collect but only depend on the source code of packages.
+//
+// A cache file is keyed by the arguments, the parse options and the Go
+// environment. In a cache file, the statistics of a package are keyed by the
+// hash of the contents of the package's source files and the hashes of the
+// packages it depends on, so they are reused only if none of them changes.
+
+// Increase it when the format of the cached data changes.
+const analysisCacheFormat = 2
+
+type analysisCache struct {
+	file string // the path of the cache file
+
+	// The data loaded from the cache file.
+	data analysisCacheData
+
+	// package path -> statistics, for the current packages.
+	stats    map[string]*packageStats
+	modified bool
+	// The number of the packages whose statistics are restored.
+	numReused int
+
+	// package -> content hash
+	hashes map[*Package]string
+}
+
+type analysisCacheData struct {
+	Key string
+
+	// package path -> statistics
+	Stats map[string]*packageStats
+}
+
+type packageStats struct {
+	Hash string
+
+	Stats                        Stats
+	RoughTypeNameCount           int32
+	RoughExportedIdentifierCount int32
+
+	Lines LineStats
+	// bare filename (of the ast file) -> line stats
+	FileLines map[string]LineStats
+	// position (filename:offset) -> metrics
+	FunctionMetrics map[string]FunctionMetrics
+}
+
+// newAnalysisCache returns nil if caching is disabled or not possible.
+// The data in the cache file is loaded if it exists.
+func newAnalysisCache(options *ParseOptions, args []string) *analysisCache {
+	if options.CacheDir == "" {
+		return nil
+	}
+
+	// The Go toolchain and the environment also affect the results.
+	goEnv, err := util.RunShellCommand(time.Second*10, options.Dir, nil, "go", "env",
+		"GOVERSION", "GOROOT", "GOMODCACHE", "GOPATH", "GOFLAGS",
+		"GOEXPERIMENT", "CGO_ENABLED", "GO111MODULE", "GOMOD", "GOWORK",
+	)
+	if err != nil {
+		log.Println("analysis cache is disabled, for go env error:", err)
+		return nil
+	}
+	dir, err := filepath.Abs(options.Dir)
+	if err != nil {
+		log.Println("analysis cache is disabled:", err)
+		return nil
+	}
+
+	var opts = *options
+	opts.CacheDir = ""
+	var key bytes.Buffer
+	fmt.Fprintf(&key, "%d\x00%s\x00%s\x00%s\x00%s\x00%+v\x00",
+		analysisCacheFormat, runtime.Version(), goEnv, dir, options.primaryPlatform(), opts)
+	for _, arg := range args {
+		fmt.Fprintf(&key, "%s\x00", arg)
+	}
+	sum := sha256.Sum256(key.Bytes())
+
+	c := &analysisCache{
+		file:   filepath.Join(options.CacheDir, hex.EncodeToString(sum[:])+".gob"),
+		stats:  make(map[string]*packageStats, 1024),
+		hashes: make(map[*Package]string, 1024),
+	}
+	c.load(key.String())
+	return c
+}
+
+func (c *analysisCache) load(key string) {
+	c.data.Key = key
+	f, err := os.Open(c.file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var data analysisCacheData
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		log.Printf("decode analysis cache (%s) error: %s", c.file, err)
+		return
+	}
+	if data.Key == key {
+		c.data = data
+	}
+}
+
+// packageHash must be called after the source files are cached
+// (see CodeAnalyzer.CacheSourceFiles).
+func (c *analysisCache) packageHash(pkg *Package) string {
+	if hash, ok := c.hashes[pkg]; ok {
+		return hash
+	}
+	// Import cycles are not allowed, but just in case.
+	c.hashes[pkg] = ""
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00", pkg.Path(), len(pkg.SourceFiles))
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", info.OriginalFile, info.GeneratedFile, len(info.Content))
+		h.Write(info.Content)
+	}
+	// The order of pkg.Deps is not deterministic.
+	depHashes := make([]string, len(pkg.Deps))
+	for i, dep := range pkg.Deps {
+		depHashes[i] = c.packageHash(dep)
+	}
+	sort.Strings(depHashes)
+	for _, hash := range depHashes {
+		fmt.Fprintf(h, "%s\x00", hash)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	c.hashes[pkg] = hash
+	return hash
+}
+
+// recordPackageStats must be called before analyzePackage_CollectMoreStatisticsFinal.
+func (c *analysisCache) recordPackageStats(pkg *Package) {
+	ps := &packageStats{
+		Stats:                        pkg.stats,
+		RoughTypeNameCount:           pkg.stats.roughTypeNameCount,
+		RoughExportedIdentifierCount: pkg.stats.roughExportedIdentifierCount,
+		Lines:                        pkg.Lines,
+		FileLines:                    make(map[string]LineStats, len(pkg.SourceFiles)),
+		FunctionMetrics:              make(map[string]FunctionMetrics, len(pkg.AllFunctions)),
+	}
+	for i := range pkg.SourceFiles {
+		if info := &pkg.SourceFiles[i]; info.AstFile != nil {
+			ps.FileLines[info.AstBareFileName()] = info.Lines
+		}
+	}
+	for _, f := range pkg.AllFunctions {
+		if f.AstDecl != nil && f.AstDecl.Body != nil {
+			ps.FunctionMetrics[functionMetricsKey(pkg, f)] = f.metrics
+		}
+	}
+
+	ps.Hash = c.packageHash(pkg)
+	c.stats[pkg.Path()] = ps
+	c.modified = true
+}
+
+// restorePackageStats returns false if the statistics of the package
+// are not cached or the package (or one of its dependencies) changes.
+func (c *analysisCache) restorePackageStats(pkg *Package) bool {
+	ps := c.data.Stats[pkg.Path()]
+	if ps == nil || ps.Hash != c.packageHash(pkg) {
+		return false
+	}
+	c.stats[pkg.Path()] = ps
+	c.numReused++
+
+	pkg.stats = ps.Stats
+	pkg.stats.roughTypeNameCount = ps.RoughTypeNameCount
+	pkg.stats.roughExportedIdentifierCount = ps.RoughExportedIdentifierCount
+	pkg.Lines = ps.Lines
+	for i := range pkg.SourceFiles {
+		if info := &pkg.SourceFiles[i]; info.AstFile != nil {
+			info.Lines = ps.FileLines[info.AstBareFileName()]
+		}
+	}
+	for _, f := range pkg.AllFunctions {
+		if f.AstDecl != nil && f.AstDecl.Body != nil {
+			f.metrics = ps.FunctionMetrics[functionMetricsKey(pkg, f)]
+		}
+	}
+	return true
+}
+
+func functionMetricsKey(pkg *Package, f *Function) string {
+	pos := pkg.PPkg.Fset.PositionFor(f.AstDecl.Pos(), false)
+	return pos.Filename + ":" + fmt.Sprint(pos.Offset)
+}
+
+// save writes the statistics of the current packages to the cache file,
+// if they are not the same as the ones in the file.
+func (c *analysisCache) save() {
+	if c.numReused > 0 {
+		log.Printf("The cached statistics of %d packages are used: %s", c.numReused, c.file)
+	}
+	if !c.modified && len(c.stats) == len(c.data.Stats) {
+		return
+	}
+	c.data.Stats = c.stats
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&c.data); err != nil {
+		log.Println("encode analysis cache error:", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file), 0700); err != nil {
+		log.Println("save analysis cache error:", err)
+		return
+	}
+	if err := writeFileAtomically(c.file, buf.Bytes()); err != nil {
+		log.Println("save analysis cache error:", err)
+	}
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,291 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// The call graph is built from the ASTs and type info of function bodies.
+// Calls through interface methods are resolved by class hierarchy analysis
+// (CHA): such a call might call the corresponding method of any type which
+// implements the interface type. The results of the implementation analysis
+// are used to do this.
+//
+// Calls through function values (including func-typed fields and variables)
+// are not tracked. Calls in package-level variable initializers are not
+// tracked either, for they are not in any function bodies.
+
+// CallSite is a call of a package-level function or a method.
+type CallSite struct {
+	Caller   *Function
+	FileInfo *SourceFileInfo
+	AstIdent *ast.Ident // the callee name in the call expression
+
+	// For static calls, Callee is the called function and Interface is nil.
+	// For calls through interface methods, Callee is nil and Interface is
+	// the interface method. Receiver is the interface type, it might be
+	// nil if the interface type is a type parameter constraint which is
+	// not registered.
+	Callee    *Function
+	Interface *types.Func
+	Receiver  *TypeInfo
+}
+
+func (cs *CallSite) Position() token.Position {
+	return cs.Caller.Pkg.PPkg.Fset.PositionFor(cs.AstIdent.Pos(), false)
+}
+
+// Dynamic reports whether or not the call is made through an interface method.
+func (cs *CallSite) Dynamic() bool {
+	return cs.Interface != nil
+}
+
+type dynamicCallKey struct {
+	receiver *TypeInfo
+	method   *types.Func
+}
+
+type callGraph struct {
+	functions map[*types.Func]*Function
+
+	callees map[*Function][]*CallSite // caller -> all calls in its body
+	callers map[*Function][]*CallSite // callee -> static calls of it
+
+	// Dynamic calls are indexed by method names. Edges to the possible
+	// callees are not built in advance, for there might be too many of
+	// them (think of the calls of error.Error).
+	dynamicCalls map[string]map[dynamicCallKey][]*CallSite
+}
+
+// FuncDeclName returns the name of a function declaration,
+// in the form "Func" or "Type.Method".
+func FuncDeclName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	return receiverTypeName(fd.Recv.List[0].Type) + "." + fd.Name.Name
+}
+
+// FunctionByName looks up a function declared in a package.
+// The name is in the form "Func" or "Type.Method".
+// init and blank functions can't be looked up, for their names are not unique.
+func (d *CodeAnalyzer) FunctionByName(pkg *Package, name string) *Function {
+	if name == "init" || name == "_" || strings.HasSuffix(name, "._") {
+		return nil
+	}
+	for _, f := range pkg.AllFunctions {
+		if f.Func != nil && f.AstDecl != nil && FuncDeclName(f.AstDecl) == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FunctionOf returns the Function for a types.Func.
+// Nil is returned for functions declared in function bodies
+// or not analyzed.
+func (d *CodeAnalyzer) FunctionOf(obj *types.Func) *Function {
+	return d.callGraph.functions[obj.Origin()]
+}
+
+// Callers returns the calls of a function, including the calls through
+// interface methods which might call the function (if it is a method).
+func (d *CodeAnalyzer) Callers(f *Function) []*CallSite {
+	sites := append([]*CallSite(nil), d.callGraph.callers[f]...)
+	if !f.IsMethod() {
+		return sites
+	}
+
+	for key, calls := range d.callGraph.dynamicCalls[f.Name()] {
+		if key.receiver == nil || key.method.Pkg() != f.Func.Pkg() && !token.IsExported(f.Name()) {
+			continue
+		}
+		for _, impl := range key.receiver.ImplementedBys {
+			if d.methodOf(impl, key.method) == f {
+				sites = append(sites, calls...)
+				break
+			}
+		}
+	}
+	return sites
+}
+
+// Callees returns the calls in the body of a function.
+func (d *CodeAnalyzer) Callees(f *Function) []*CallSite {
+	return d.callGraph.callees[f]
+}
+
+// PossibleCallees returns the methods which might be called by a call
+// through an interface method. For static calls, the callee is returned.
+func (d *CodeAnalyzer) PossibleCallees(cs *CallSite) []*Function {
+	if !cs.Dynamic() {
+		return []*Function{cs.Callee}
+	}
+	if cs.Receiver == nil {
+		return nil
+	}
+
+	var callees []*Function
+	var seen = make(map[*Function]struct{}, len(cs.Receiver.ImplementedBys))
+	for _, impl := range cs.Receiver.ImplementedBys {
+		if f := d.methodOf(impl, cs.Interface); f != nil {
+			if _, ok := seen[f]; !ok {
+				seen[f] = struct{}{}
+				callees = append(callees, f)
+			}
+		}
+	}
+	return callees
+}
+
+// methodOf returns the declared method which is selected by calling
+// the specified interface method on a value of a non-interface type.
+func (d *CodeAnalyzer) methodOf(t *TypeInfo, method *types.Func) *Function {
+	if types.IsInterface(t.TT) {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t.TT, false, method.Pkg(), method.Name())
+	if fn, ok := obj.(*types.Func); ok {
+		return d.FunctionOf(fn)
+	}
+	return nil
+}
+
+func (d *CodeAnalyzer) BuildCallGraph() {
+	g := &d.callGraph
+	g.functions = make(map[*types.Func]*Function, 8192)
+	for _, pkg := range d.packageList {
+		for _, f := range pkg.AllFunctions {
+			if f.Func != nil {
+				g.functions[f.Func] = f
+			}
+		}
+	}
+	g.callees = make(map[*Function][]*CallSite, len(g.functions))
+	g.callers = make(map[*Function][]*CallSite, len(g.functions))
+	g.dynamicCalls = make(map[string]map[dynamicCallKey][]*CallSite, 1024)
+
+	for _, pkg := range d.packageList {
+		for i := range pkg.SourceFiles {
+			info := &pkg.SourceFiles[i]
+			if info.AstFile == nil {
+				continue
+			}
+			for _, decl := range info.AstFile.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				obj, _ := pkg.PPkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if caller := g.functions[obj]; caller != nil {
+					d.collectCallSites(pkg, info, caller, fd.Body)
+				}
+			}
+		}
+	}
+}
+
+func (d *CodeAnalyzer) collectCallSites(pkg *Package, info *SourceFileInfo, caller *Function, body *ast.BlockStmt) {
+	g := &d.callGraph
+	typesInfo := pkg.PPkg.TypesInfo
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		fun := ast.Unparen(call.Fun)
+		switch e := fun.(type) {
+		case *ast.IndexExpr: // f[T](...)
+			fun = e.X
+		case *ast.IndexListExpr: // f[T1, T2](...)
+			fun = e.X
+		}
+
+		var ident *ast.Ident
+		var recv types.Type // the receiver type for method calls
+		switch e := fun.(type) {
+		case *ast.Ident:
+			ident = e
+		case *ast.SelectorExpr:
+			ident = e.Sel
+			if sel := typesInfo.Selections[e]; sel != nil {
+				switch sel.Kind() {
+				case types.MethodVal:
+					recv = sel.Recv()
+				case types.MethodExpr: // T.M(t, ...)
+					recv = sel.Recv()
+				default: // calling a func-typed field
+					return true
+				}
+				// s.M() where M is promoted from an embedded interface field.
+				if iface := embeddedInterfaceOf(sel); iface != nil {
+					recv = iface
+				}
+			}
+		default:
+			return true
+		}
+
+		fn, ok := typesInfo.Uses[ident].(*types.Func)
+		if !ok {
+			return true // builtin functions, conversions, func values, ...
+		}
+
+		site := &CallSite{
+			Caller:   caller,
+			FileInfo: info,
+			AstIdent: ident,
+		}
+		if recv != nil && types.IsInterface(recv) {
+			site.Interface = fn.Origin()
+			if tp, ok := types.Unalias(recv).(*types.TypeParam); ok {
+				recv = tp.Constraint()
+			}
+			site.Receiver = d.TryRegisteringType(recv, false)
+
+			calls := g.dynamicCalls[fn.Name()]
+			if calls == nil {
+				calls = make(map[dynamicCallKey][]*CallSite, 4)
+				g.dynamicCalls[fn.Name()] = calls
+			}
+			key := dynamicCallKey{receiver: site.Receiver, method: site.Interface}
+			calls[key] = append(calls[key], site)
+		} else {
+			site.Callee = d.FunctionOf(fn)
+			if site.Callee == nil {
+				return true // local or not analyzed functions
+			}
+			g.callers[site.Callee] = append(g.callers[site.Callee], site)
+		}
+		g.callees[caller] = append(g.callees[caller], site)
+		return true
+	})
+}
+
+// embeddedInterfaceOf returns the type of the embedded interface field
+// through which the method selected by sel is promoted, or nil if the
+// method is not promoted from an embedded interface field.
+func embeddedInterfaceOf(sel *types.Selection) types.Type {
+	index := sel.Index()
+	if len(index) < 2 || types.IsInterface(sel.Recv()) {
+		return nil
+	}
+	typ := sel.Recv()
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		st, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			return nil
+		}
+		typ = st.Field(i).Type()
+	}
+	if !types.IsInterface(typ) {
+		return nil
+	}
+	return typ
+}
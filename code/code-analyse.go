@@ -6,11 +6,15 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"log"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/types/typeutil"
 
 	"go101.org/gold/internal/util"
@@ -38,6 +42,7 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	for _, pkg := range d.packageList {
 		d.analyzePackage_CollectDeclarations(pkg)
 	}
+	d.resolveLinknames()
 
 	logProgress(SubTask_CollectDeclarations)
 
@@ -47,6 +52,7 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 
 	for _, pkg := range d.packageList {
 		d.analyzePackage_FindTypeSources(pkg)
+		d.analyzePackage_CollectInstantiatedTypes(pkg)
 	}
 
 	logProgress(SubTask_FindTypeSources)
@@ -83,21 +89,40 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_CollectSourceFiles)
 
 	d.CollectObjectReferences()
+	d.collectDeprecatedObjects()
 
 	logProgress(SubTask_CollectObjectReferences)
 
+	d.BuildCallGraph()
+
+	logProgress(SubTask_BuildCallGraph)
+
 	d.CacheSourceFiles()
 
 	logProgress(SubTask_CacheSourceFiles)
 
+	for _, pkg := range d.packageList {
+		d.collectAssemblyFunctions(pkg)
+	}
+
 	d.analyzePackage_CollectSomeRuntimeFunctionPositions()
 
 	logProgress(SubTask_CollectRuntimeFunctionPositions)
 
 	for _, pkg := range d.packageList {
+		if d.cache != nil && d.cache.restorePackageStats(pkg) {
+			continue
+		}
 		d.analyzePackage_CollectMoreStatistics(pkg)
+		if d.cache != nil {
+			d.cache.recordPackageStats(pkg)
+		}
 	}
 	d.analyzePackage_CollectMoreStatisticsFinal()
+	d.collectTopNLists()
+	if d.cache != nil {
+		d.cache.save()
+	}
 
 	logProgress(SubTask_MakeStatistics)
 
@@ -171,13 +196,21 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations() { // (resultMethodC
 	for i := 0; i < len(d.allTypeInfos); i++ {
 		t := d.allTypeInfos[i]
 
+		// Type parameters are not involved in implementation relations.
+		if _, ok := t.TT.(*types.TypeParam); ok {
+			continue
+		}
+
 		// ToDo: auto register underlying type in RegisterType.
 		underlying := t.TT.Underlying()
 		underlyingTypeInfo := d.RegisterType(underlying) // underlying must have been already registered
 		t.Underlying = underlyingTypeInfo
 		underlyingTypeInfo.Underlying = underlyingTypeInfo
 
-		if i, ok := underlying.(*types.Interface); ok && i.NumMethods() > 0 {
+		// Constraint interfaces with type sets can't be implemented by value types.
+		// The methods of the interfaces produced by type argument substitution
+		// might be not collected (see collectSelectorsForInterfaceType).
+		if i, ok := underlying.(*types.Interface); ok && i.NumMethods() > 0 && i.IsMethodSet() && len(underlyingTypeInfo.AllMethods) > 0 {
 			var uiInfo *UnderlyingInterfaceInfo
 			info := interfaceUnderlyings.At(i)
 			if interfaceUnderlyings.At(i) == nil {
@@ -547,13 +580,19 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations_Old() (resultMethodCa
 	for i := 0; i < len(d.allTypeInfos); i++ {
 		t := d.allTypeInfos[i]
 
+		// Type parameters are not involved in implementation relations.
+		if _, ok := t.TT.(*types.TypeParam); ok {
+			continue
+		}
+
 		// ToDo: auto register underlying type in RegisterType.
 		underlying := t.TT.Underlying()
 		underlyingTypeInfo := d.RegisterType(underlying) // underlying must have been already registered
 		t.Underlying = underlyingTypeInfo
 		underlyingTypeInfo.Underlying = underlyingTypeInfo
 
-		if i, ok := underlying.(*types.Interface); ok && i.NumMethods() > 0 {
+		// Constraint interfaces with type sets can't be implemented by value types.
+		if i, ok := underlying.(*types.Interface); ok && i.NumMethods() > 0 && i.IsMethodSet() {
 			var uiInfo *UnderlyingInterfaceInfo
 			info := interfaceUnderlyings.At(i)
 			if interfaceUnderlyings.At(i) == nil {
@@ -874,6 +913,8 @@ func (d *CodeAnalyzer) analyzePackages_CollectSelectors() {
 	//	}
 	//}
 
+	d.registerDirectSelectorsForInstantiatedTypes()
+
 	var selectorMaps []map[string]*Selector
 
 	var smm = &SeleterMapManager{
@@ -1038,6 +1079,12 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 		return
 	}
 
+	// The underlying types of type parameters are their constraints.
+	// Type parameters have no selectors to collect.
+	if _, ok := t.TT.(*types.TypeParam); ok {
+		return
+	}
+
 	// ToDo: maintain an interface type list in the outer loop to avoid the assertion.
 	itt, ok := t.TT.Underlying().(*types.Interface)
 	if !ok {
@@ -1107,8 +1154,14 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 			//if depth == 0 {
 			//	return // ToDo: temp ignore field and paramter/result unnamed interface types
 			//}
-			log.Println("!!! t.index:", t.index, t.TT)
-			panic("unnamed interface should have collected direct selectors now. " + fmt.Sprintf("%#v", t))
+
+			// Since Go 1.18, an unnamed interface type might be only
+			// produced by type argument substitution, so there are no
+			// AST nodes for it to collect selectors from. The selectors
+			// of most of such types are built from the interfaces they
+			// are substituted from (see registerDirectSelectorsForInstantiatedTypes),
+			// except the ones only with embeddings.
+			return
 		}
 
 		hasEmbeddings := false
@@ -1555,90 +1608,120 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 	}
 }
 
-// ToDo
+// confirmPackageModules fills the Mod fields of non-std packages with the
+// module info reported by go/packages, and builds module dependency relations
+// by parsing the go.mod files of the involved modules.
+// The std module and the std packages are confirmed in ParsePackages.
+//
+// Packages parsed in GOPATH mode don't belong to any modules.
 func (d *CodeAnalyzer) confirmPackageModules() {
-	// Two cases:
-	// 1. check the .../vendor/modules.txt files
-	// 2. check GOPATH/pkg/mod/...
-
-	// # list all module dependency relations
-	// go mod graph
-	//	k8s.io/kubernetes sigs.k8s.io/yaml@v1.1.0
-	//	...
-	//	k8s.io/apiserver@v0.0.0 go.uber.org/zap@v1.10.0
-	//	...
-
-	// # from Michael Matloob
-	// go list -f '{{.Module.Path}} {{.Module.Dir}}' pkg-import-path
-
-	// # list all involved modules
-	// go list -m all
-	//	volcano.sh/volcano
-	//	modernc.org/xc v1.0.0 => modernc.org/xc v1.0.0
-	//	...
-	// go list -f '{{.ImportPath}} {{.Module}}' all
-	//	go101.org/gold/tests/n go101.org/gold
-	//	golang.org/x/mod/internal/lazyregexp golang.org/x/mod v0.1.1-0.20191105210325-c90efee705ee
-	//	unsafe <nil>
-	//	vendor/golang.org/x/crypto/chacha20 <nil>
-	//	...
-	// go list -json all
-	//	.Module
-	//
-	// Maybe, it is still better to analyze it manauuly.
-	// Temp not to show module pages, module info is only used to find asParamsOf/asResultsOf
+	if d.moduleTable == nil {
+		d.moduleTable = make(map[string]*Module, 64)
+	}
 
-	// # get the module at CWD
-	// go list -m
-	//	volcano.sh/volcano
-	//   or
-	//	go list -m: not using modules
+	var goModFiles = make(map[*Module]string, 64)
+	var moduleOf = func(m *packages.Module) *Module {
+		if mod := d.moduleTable[m.Path]; mod != nil {
+			return mod
+		}
 
-	// # find GOROOT to find std module info
-	// go env
+		mod := &Module{
+			Dir:       m.Dir,
+			Root:      m.Path,
+			Version:   m.Version,
+			GoVersion: m.GoVersion,
+			IsMain:    m.Main,
+		}
+		goModFile := m.GoMod
+		if r := m.Replace; r != nil {
+			mod.Replacement = ModuleVersion{Path: r.Path, Version: r.Version}
+			if r.Dir != "" {
+				mod.Dir = r.Dir
+			}
+			if r.GoMod != "" {
+				goModFile = r.GoMod
+			}
+			if r.GoVersion != "" {
+				mod.GoVersion = r.GoVersion
+			}
+		}
 
-	//findPkgModule := func(pkg *Package) {
-	//	// d.stdPackages
-	//}
-	//_ = findPkgModule
+		d.moduleTable[mod.Root] = mod
+		d.allModules = append(d.allModules, mod)
+		goModFiles[mod] = goModFile
+		return mod
+	}
 
-	//for _, pkg := range d.packageList {
-	//	if len(pkg.PPkg.GoFiles) == 0 {
-	//		continue
-	//	}
-	//	dir := filepath.Dir(pkg.PPkg.GoFiles[0])
-	//	filename := filepath.Join(dir, "go.mod")
-	//	filedata, err := ioutil.ReadFile(filename)
-	//	if err != nil {
-	//		if !errors.Is(err, os.ErrNotExist) {
-	//			log.Printf("ioutil.ReadFile %s error: %s", filename, err)
-	//		}
-	//		continue
-	//	}
-	//
-	//	modFile, err := modfile.ParseLax(filename, filedata, nil)
-	//	if err != nil {
-	//	}
-	//
-	//	mod := Module{
-	//		Dir:     dir,
-	//		Root:    modFile.Module.Mod.Path,
-	//		Version: modFile.Module.Mod.Version,
-	//	}
-	//
-	//	_ = mod
-	//}
+	for _, pkg := range d.packageList {
+		if pkg.Mod == nil && pkg.PPkg.Module != nil {
+			pkg.Mod = moduleOf(pkg.PPkg.Module)
+		}
+	}
 
-	// I decided to delay the impplementation of this funciton now.
-	// One intention to confirm module information is
-	// to support module pages, but this is not very essential.
-	// Another intention to confirm module information
-	// is to calculate the distances of pacakges.
-	// However, it might be not perfect to determine
-	// the distance of two packages by checking if
-	// they are in the same module.
-	//
-	// The module info confirmed in this funciton will be only for showing.
+	// External test packages belong to the modules of the packages they test.
+	for _, pkg := range d.packageList {
+		if pkg.Mod == nil && strings.HasSuffix(pkg.Path(), "_test") {
+			if tested := d.packageTable[strings.TrimSuffix(pkg.Path(), "_test")]; tested != nil {
+				pkg.Mod = tested.Mod
+			}
+		}
+		if pkg.Mod != nil {
+			pkg.Mod.Pkgs = append(pkg.Mod.Pkgs, pkg)
+		}
+	}
+
+	for mod, goModFile := range goModFiles {
+		if goModFile == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(goModFile)
+		if err != nil {
+			log.Printf("read go.mod file for module %s error: %s", mod.Root, err)
+			continue
+		}
+		modFile, err := modfile.ParseLax(goModFile, data, nil)
+		if err != nil {
+			log.Printf("parse go.mod file for module %s error: %s", mod.Root, err)
+			continue
+		}
+
+		mod.Requires = make([]ModuleRequire, 0, len(modFile.Require))
+		for _, r := range modFile.Require {
+			required := d.moduleTable[r.Mod.Path]
+			mod.Requires = append(mod.Requires, ModuleRequire{
+				ModuleVersion: ModuleVersion{Path: r.Mod.Path, Version: r.Mod.Version},
+				Indirect:      r.Indirect,
+				Module:        required,
+			})
+			if required != nil {
+				required.RequiredBys = append(required.RequiredBys, mod)
+			}
+		}
+
+		mod.Replaces = make([]ModuleReplace, 0, len(modFile.Replace))
+		for _, r := range modFile.Replace {
+			mod.Replaces = append(mod.Replaces, ModuleReplace{
+				Old: ModuleVersion{Path: r.Old.Path, Version: r.Old.Version},
+				New: ModuleVersion{Path: r.New.Path, Version: r.New.Version},
+			})
+		}
+	}
+
+	// The std module is always the first one.
+	if len(d.allModules) > 0 && d.allModules[0] == d.stdModule {
+		mods := d.allModules[1:]
+		sort.Slice(mods, func(a, b int) bool {
+			return mods[a].Root < mods[b].Root
+		})
+	}
+	for _, mod := range d.allModules {
+		sort.Slice(mod.Pkgs, func(a, b int) bool {
+			return mod.Pkgs[a].Path() < mod.Pkgs[b].Path()
+		})
+		sort.Slice(mod.RequiredBys, func(a, b int) bool {
+			return mod.RequiredBys[a].Root < mod.RequiredBys[b].Root
+		})
+	}
 }
 
 // Important for registerFunctionForInvolvedTypeNames and registerValueForItsTypeName.
@@ -1719,9 +1802,11 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	// ToDo: use info.TypeOf, info.ObjectOf
 
 	for _, file := range pkg.PPkg.Syntax {
-		d.stats.AstFiles++
-		d.stats.Imports += int32(len(file.Imports))
-		incSliceStat(d.stats.FilesByImportCount[:], len(file.Imports))
+		pkg.linknames = append(pkg.linknames, collectLinknameDirectives(file)...)
+
+		pkg.stats.AstFiles++
+		pkg.stats.Imports += int32(len(file.Imports))
+		incSliceStat(pkg.stats.FilesByImportCount[:], len(file.Imports))
 
 		//if len(file.Imports) == 0 {
 		//	log.Println("----", pkg.PPkg.Fset.PositionFor(file.Pos(), false))
@@ -1789,16 +1874,17 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 						}
 
 						tv := pkg.PPkg.TypesInfo.Types[typeSpec.Type]
-						if !tv.IsType() {
+						// Since Go 1.18, declarations like "type bool bool" in
+						// the builtin package are reported as invalid recursive
+						// types. Their source types are replaced below.
+						if !tv.IsType() && !isBuiltinPkg {
 							if pkg.Path() != "unsafe" {
 								panic(typeSpec.Name.Name + ": not type")
-							}
-
-							// Now, unsafe AST expressions are the only ast.Expr(s)
-							// which are allowed to not associate with a TypeAndValue.
-							// For unsafe, although tv.IsType() == false, tv.Type is valid.
-							// See fillUnsafePackage for details.
-							if tv.Type == nil {
+							} else if tv.Type == nil {
+								// Now, unsafe AST expressions are the only ast.Expr(s)
+								// which are allowed to not associate with a TypeAndValue.
+								// For unsafe, although tv.IsType() == false, tv.Type is valid.
+								// See fillUnsafePackage for details.
 								panic(typeSpec.Name.Name + ": tv.Type is nil")
 							}
 						}
@@ -1819,6 +1905,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							if !ok {
 								panic("builtin " + objName + " not found")
 							}
+							if srcType == nil || srcType == types.Typ[types.Invalid] {
+								srcType = typeObj.Type()
+							}
 							//log.Println(srcType, srcType.Underlying(), srcType == srcType.Underlying()) // true
 
 							//srcType = typeObj.Type().Underlying() // why underlying here? error and its underlying is different.
@@ -1919,6 +2008,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 								AstDecl: gd,
 								AstSpec: valueSpec,
 							}
+							if v.embedPatterns = parseEmbedDirectives(gd, valueSpec); v.embedPatterns != nil {
+								v.embedFiles = collectEmbedFiles(pkg, v.embedPatterns)
+							}
 
 							registerVariable(v)
 						}
@@ -2004,24 +2096,8 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 		}
 
 		if f.IsMethod() && f.AstDecl.Recv != nil {
-			if len(f.AstDecl.Recv.List) != 1 {
-				panic("should not")
-			}
-			field := f.AstDecl.Recv.List[0]
-			var id *ast.Ident
-			switch expr := field.Type.(type) {
-			default:
-				panic("should not")
-			case *ast.Ident:
-				id = expr
-			case *ast.StarExpr:
-				tid, ok := expr.X.(*ast.Ident)
-				if !ok {
-					panic("should not")
-				}
-				id = tid
-			}
-			if !token.IsExported(id.Name) {
+			_, id, _, ok := f.ReceiverTypeName()
+			if !ok || !token.IsExported(id.Name) {
 				// ToDo: If it is proved that some values of this type are
 				//       exposed to other packages, then should not continue here.
 				continue
@@ -2036,24 +2112,24 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 		// ToDo: sometimes unexported ones are also needed to read code.
 		if f.Exported() {
 			if f.IsMethod() {
-				d.stats.ExportedMethods++
-				//incSliceStat(d.stats.MethodsByParameterCount[:], numParams)
-				//incSliceStat(d.stats.FunctionsByResultCount[:], numResults)
+				pkg.stats.ExportedMethods++
+				//incSliceStat(pkg.stats.MethodsByParameterCount[:], numParams)
+				//incSliceStat(pkg.stats.FunctionsByResultCount[:], numResults)
 			} else {
-				d.stats.ExportedFunctions++
-				//incSliceStat(d.stats.FunctionsByParameterCount[:], numParams)
-				//incSliceStat(d.stats.MethodsByResultCount[:], numResults)
+				pkg.stats.ExportedFunctions++
+				//incSliceStat(pkg.stats.FunctionsByParameterCount[:], numParams)
+				//incSliceStat(pkg.stats.MethodsByResultCount[:], numResults)
 			}
 			if lastResultIsError {
-				d.stats.ExportedFunctionWithLastErrorResult++
-			}
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(f.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(f.Name()))
-			d.stats.ExportedIdentifers++
-			d.stats.ExportedFunctionParameters += int32(numParams)
-			d.stats.ExportedFunctionResults += int32(numResults)
-			incSliceStat(d.stats.ExportedFunctionsByParameterCount[:], numParams)
-			incSliceStat(d.stats.ExportedFunctionsByResultCount[:], numResults)
+				pkg.stats.ExportedFunctionWithLastErrorResult++
+			}
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(f.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(f.Name()))
+			pkg.stats.ExportedIdentifers++
+			pkg.stats.ExportedFunctionParameters += int32(numParams)
+			pkg.stats.ExportedFunctionResults += int32(numResults)
+			incSliceStat(pkg.stats.ExportedFunctionsByParameterCount[:], numParams)
+			incSliceStat(pkg.stats.ExportedFunctionsByResultCount[:], numResults)
 		}
 	}
 	//for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
@@ -2086,13 +2162,13 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
 		if v.Exported() {
 			d.registerValueForItsTypeName(v)
-			d.stats.ExportedVariables++
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(v.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(v.Name()))
-			d.stats.ExportedIdentifers++
+			pkg.stats.ExportedVariables++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(v.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(v.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			kind := Kind(v.TType())
-			d.stats.ExportedVariablesByTypeKind[kind]++
+			pkg.stats.ExportedVariablesByTypeKind[kind]++
 		}
 		if v.AstSpec.Type != nil {
 			d.lookForAndRegisterUnnamedInterfaceAndStructTypes(v.AstSpec.Type, v.Pkg)
@@ -2101,13 +2177,13 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
 		if c.Exported() {
 			d.registerValueForItsTypeName(c)
-			d.stats.ExportedConstants++
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(c.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(c.Name()))
-			d.stats.ExportedIdentifers++
+			pkg.stats.ExportedConstants++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(c.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(c.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			kind := Kind(c.TType())
-			d.stats.ExportedConstantsByTypeKind[kind]++
+			pkg.stats.ExportedConstantsByTypeKind[kind]++
 		}
 	}
 }
@@ -2118,20 +2194,23 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 	}
 	var isBuiltinPkg = pkg.Path() == "builtin"
 
+	d.collectLineStats(pkg)
+	d.collectFunctionMetrics(pkg)
+
 	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
-		d.stats.roughTypeNameCount++
+		pkg.stats.roughTypeNameCount++
 
 		if isBuiltinPkg != token.IsExported(tn.Name()) {
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(tn.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(tn.Name()))
-			d.stats.ExportedIdentifers++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(tn.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(tn.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			denoting := tn.Denoting()
 			kind := denoting.Kind()
-			d.stats.ExportedTypeNamesByKind[kind]++
+			pkg.stats.ExportedTypeNamesByKind[kind]++
 
 			if tn.Alias != nil {
-				d.stats.ExportedTypeAliases++
+				pkg.stats.ExportedTypeAliases++
 				if t := tn.Alias.Denoting; t.TypeName != nil && t.TypeName.Exported() {
 					continue // to avoid duplicated statistics
 				}
@@ -2144,24 +2223,24 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 				}
 			}
 			if kind == reflect.Interface {
-				incSliceStat(d.stats.ExportedNamedInterfacesByMethodCount[:], len(denoting.AllMethods))
-				incSliceStat(d.stats.ExportedNamedInterfacesByExportedMethodCount[:], numExportedMethods)
-				d.stats.ExportedNamedInterfacesExportedMethods += int32(numExportedMethods)
-				d.stats.roughExportedIdentifierCount += int32(numExportedMethods)
+				incSliceStat(pkg.stats.ExportedNamedInterfacesByMethodCount[:], len(denoting.AllMethods))
+				incSliceStat(pkg.stats.ExportedNamedInterfacesByExportedMethodCount[:], numExportedMethods)
+				pkg.stats.ExportedNamedInterfacesExportedMethods += int32(numExportedMethods)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedMethods)
 				continue
 			}
-			incSliceStat(d.stats.ExportedNamedNonInterfaceTypesByMethodCount[:], len(denoting.AllMethods))
-			incSliceStat(d.stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], numExportedMethods)
+			incSliceStat(pkg.stats.ExportedNamedNonInterfaceTypesByMethodCount[:], len(denoting.AllMethods))
+			incSliceStat(pkg.stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], numExportedMethods)
 
 			if numExportedMethods > 0 {
-				d.stats.ExportedNamedNonInterfacesExportedMethods += int32(numExportedMethods)
-				d.stats.roughExportedIdentifierCount += int32(numExportedMethods)
-				d.stats.ExportedNamedNonInterfacesWithExportedMethods++
+				pkg.stats.ExportedNamedNonInterfacesExportedMethods += int32(numExportedMethods)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedMethods)
+				pkg.stats.ExportedNamedNonInterfacesWithExportedMethods++
 			}
 
 			if kind == reflect.Struct {
-				incSliceStat(d.stats.ExportedNamedStructsByFieldCount[:], len(denoting.AllFields))
-				d.stats.ExportedNamedStructTypeFields += int32(len(denoting.AllFields))
+				incSliceStat(pkg.stats.ExportedNamedStructsByFieldCount[:], len(denoting.AllFields))
+				pkg.stats.ExportedNamedStructTypeFields += int32(len(denoting.AllFields))
 
 				hasEmbeddeds, numExportedPromoteds := false, 0
 				numExporteds, numExpliciteds, numExportedExpliciteds := 0, 0, 0
@@ -2175,9 +2254,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 						}
 					}
 					if sel.Depth == 0 {
-						incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(sel.Name()))
-						d.stats.ExportedIdentifersSumLength += int32(len(sel.Name()))
-						d.stats.ExportedIdentifers++
+						incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(sel.Name()))
+						pkg.stats.ExportedIdentifersSumLength += int32(len(sel.Name()))
+						pkg.stats.ExportedIdentifers++
 
 						numExpliciteds++
 					} else {
@@ -2185,23 +2264,23 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 					}
 				}
 				if hasEmbeddeds {
-					d.stats.ExportedNamedStructTypesWithPromotedFields++
+					pkg.stats.ExportedNamedStructTypesWithPromotedFields++
 				}
 				ut := d.RegisterType(denoting.TT.Underlying())
 				if ut.EmbeddingFields > 0 {
-					d.stats.ExportedNamedStructTypesWithEmbeddingFields++
+					pkg.stats.ExportedNamedStructTypesWithEmbeddingFields++
 				}
-				incSliceStat(d.stats.ExportedNamedStructsByEmbeddingFieldCount[:], int(ut.EmbeddingFields))
+				incSliceStat(pkg.stats.ExportedNamedStructsByEmbeddingFieldCount[:], int(ut.EmbeddingFields))
 
-				incSliceStat(d.stats.ExportedNamedStructsByExplicitFieldCount[:], numExpliciteds)
-				d.stats.ExportedNamedStructTypeExplicitFields += int32(numExpliciteds)
-				incSliceStat(d.stats.ExportedNamedStructsByExportedFieldCount[:], numExporteds)
-				d.stats.ExportedNamedStructTypeExportedFields += int32(numExporteds)
-				incSliceStat(d.stats.ExportedNamedStructsByExportedExplicitFieldCount[:], numExportedExpliciteds)
-				d.stats.ExportedNamedStructTypeExportedExplicitFields += int32(numExportedExpliciteds)
-				d.stats.roughExportedIdentifierCount += int32(numExportedExpliciteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExplicitFieldCount[:], numExpliciteds)
+				pkg.stats.ExportedNamedStructTypeExplicitFields += int32(numExpliciteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedFieldCount[:], numExporteds)
+				pkg.stats.ExportedNamedStructTypeExportedFields += int32(numExporteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedExplicitFieldCount[:], numExportedExpliciteds)
+				pkg.stats.ExportedNamedStructTypeExportedExplicitFields += int32(numExportedExpliciteds)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedExpliciteds)
 
-				incSliceStat(d.stats.ExportedNamedStructsByExportedPromotedFieldCount[:], numExportedPromoteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedPromotedFieldCount[:], numExportedPromoteds)
 				//if numExportedPromoteds >= 5 {
 				//	log.Println(numExportedPromoteds, tn.Package().Path(), tn.Name())
 				//}
@@ -2211,55 +2290,84 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 }
 
 func (d *CodeAnalyzer) analyzePackage_CollectMoreStatisticsFinal() {
-	var sum = func(kinds ...reflect.Kind) (r int32) {
-		for _, k := range kinds {
-			r += d.stats.ExportedTypeNamesByKind[k]
-		}
-		return
-	}
-	d.stats.ExportedUnsignedTypeNames = sum(reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
-	d.stats.ExportedIntergerTypeNames = d.stats.ExportedUnsignedTypeNames + sum(reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64)
-	d.stats.ExportedNumericTypeNames = d.stats.ExportedIntergerTypeNames + sum(reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128)
-	d.stats.ExportedBasicTypeNames = d.stats.ExportedNumericTypeNames + sum(reflect.Bool, reflect.String)
-	d.stats.ExportedCompositeTypeNames = sum(reflect.Array, reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.UnsafePointer)
-	d.stats.ExportedTypeNames = d.stats.ExportedCompositeTypeNames + d.stats.ExportedBasicTypeNames
-
-	d.stats.Packages = int32(len(d.packageList))
 	for _, pkg := range d.packageList {
+		stats := &pkg.stats
+		var sum = func(kinds ...reflect.Kind) (r int32) {
+			for _, k := range kinds {
+				r += stats.ExportedTypeNamesByKind[k]
+			}
+			return
+		}
+		stats.ExportedUnsignedTypeNames = sum(reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
+		stats.ExportedIntergerTypeNames = stats.ExportedUnsignedTypeNames + sum(reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64)
+		stats.ExportedNumericTypeNames = stats.ExportedIntergerTypeNames + sum(reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128)
+		stats.ExportedBasicTypeNames = stats.ExportedNumericTypeNames + sum(reflect.Bool, reflect.String)
+		stats.ExportedCompositeTypeNames = sum(reflect.Array, reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.UnsafePointer)
+		stats.ExportedTypeNames = stats.ExportedCompositeTypeNames + stats.ExportedBasicTypeNames
+
+		stats.Packages = 1
 		if d.IsStandardPackage(pkg) {
-			d.stats.StdPackages++
+			stats.StdPackages = 1
 		}
-		d.stats.FilesWithGenerateds += int32(len(pkg.SourceFiles))
-		d.stats.AllPackageDeps += int32(len(pkg.Deps))
-		incSliceStat(d.stats.PackagesByDeps[:], len(pkg.Deps))
+		stats.FilesWithGenerateds = int32(len(pkg.SourceFiles))
+		stats.AllPackageDeps = int32(len(pkg.Deps))
+		incSliceStat(stats.PackagesByDeps[:], len(pkg.Deps))
+
+		stats.roughExportedIdentifierCount += stats.ExportedIdentifers
 	}
 
-	d.stats.roughExportedIdentifierCount += d.stats.ExportedIdentifers
+	d.stats = d.StatisticsOf(d.packageList)
 }
 
 func (d *CodeAnalyzer) analyzePackage_CollectSomeRuntimeFunctionPositions() {
 	// ...
 	if runtimePkg := d.packageTable["runtime"]; runtimePkg != nil {
 		fnames := []string{
-			"selectgo",      // for select blocks (except one-case-plus-default ones)
-			"selectnbsend",  // one-case-plus-default select blocks
-			"selectnbrecv",  // select {case v = <-c:; default:}
-			"selectnbrecv2", // select {case v, ok = <-c:; default:}
-			"chansend",      // c <- v
-			"chanrecv1",     // v = <- c
-			"chanrecv2",     // v, ok = <-c
+			"selectgo",     // for select blocks (except one-case-plus-default ones)
+			"selectnbsend", // one-case-plus-default select blocks
+			"selectnbrecv", // select {case v = <-c:; default:} and select {case v, ok = <-c:; default:}
+			"chansend",     // c <- v
+			"chanrecv1",    // v = <- c
+			"chanrecv2",    // v, ok = <-c
 			"makechan",
 			"closechan",
 			"gopanic",
 			"gorecover",
-			// gave up other built-in functions
+			"makemap",    // make(map[K]V)
+			"makeslice",  // make([]T, n)
+			"growslice",  // append(s, ...)
+			"slicecopy",  // copy(dst, src)
+			"newobject",  // new(T)
+			"mapdelete",  // delete(m, k)
+			"mapclear",   // clear(m)
+			"mapaccess1", // v = m[k]
+			"mapaccess2", // v, ok = m[k]
+			"mapassign",  // m[k] = v
+			// len(m) is compiled inline. This function shows how it is got.
+			"reflect_maplen",
+			"slicebytetostring", // string(bytes)
+			"slicerunetostring", // string(runes)
+			"intstring",         // string(rune(r))
+			"stringtoslicebyte", // []byte(s)
+			"stringtoslicerune", // []rune(s)
+			"convT",             // any(v)
+			"convTnoptr",        // any(v), v contains no pointers
+			"convT16",           // any(int16(n))
+			"convT32",           // any(int32(n))
+			"convT64",           // any(int64(n))
+			"convTstring",       // any(s)
+			"convTslice",        // any(slice)
+			"assertE2I",         // v = x.(I)
+			"assertE2I2",        // v, ok = x.(I)
+			"newproc",           // go f()
 		}
-		d.runtimeFuncPositions = make(map[string]token.Position, 32)
+		d.runtimeFuncPositions = make(map[string]token.Position, 64)
 
 		for _, f := range fnames {
 			obj := runtimePkg.PPkg.Types.Scope().Lookup(f)
 			if obj == nil {
 				log.Printf("!!! runtime.%s is not found", f)
+				continue
 			}
 			d.runtimeFuncPositions[f] = runtimePkg.PPkg.Fset.PositionFor(obj.Pos(), false)
 		}
@@ -2321,18 +2429,30 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 
 							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr)
 							if srcObj == nil {
-								if pkg.Path() != "unsafe" {
+								if isBuiltin {
+									// Declarations like "type bool bool" are invalid recursive types.
+									srcObj = types.Universe.Lookup(expr.Name)
+								} else if pkg.IllTyped() {
+									return // unresolved source type
+								} else if pkg.Path() != "unsafe" {
 									panic("srcObj is nil but package is not unsafe")
+								} else {
+									return
 								}
-								return
 							}
-							srcTypeObj := srcObj.(*types.TypeName)
+							srcTypeObj, ok := srcObj.(*types.TypeName)
+							if !ok && pkg.IllTyped() {
+								return // not a type
+							}
 
 							//log.Println("   srcTypeObj.Pkg() =", srcTypeObj.Pkg())
 							// if srcType is a built type, srcTypeObj.Pkg() == nil
 
 							tn := d.allTypeNameTable[d.Id2(srcTypeObj.Pkg(), expr.Name)]
 							if tn == nil {
+								if pkg.IllTyped() {
+									return
+								}
 								panic("type name " + expr.Name + " not found")
 							}
 							source.TypeName = tn
@@ -2342,11 +2462,20 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 							return
 						case *ast.SelectorExpr:
 							//log.Println("selector,", pkg.Path()+"."+typeSpec.Name.Name, "source is:")
-							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-							srcPkg := srcObj.(*types.PkgName)
+							x, _ := expr.X.(*ast.Ident)
+							if x == nil && pkg.IllTyped() {
+								return
+							}
+							srcPkg, ok := pkg.PPkg.TypesInfo.ObjectOf(x).(*types.PkgName)
+							if !ok && pkg.IllTyped() {
+								return // unresolved package name
+							}
 
 							tn := d.allTypeNameTable[d.Id2(srcPkg.Imported(), expr.Sel.Name)]
 							if tn == nil {
+								if pkg.IllTyped() {
+									return
+								}
 								panic("type name " + expr.Sel.Name + " not found")
 							}
 							source.TypeName = tn
@@ -2403,6 +2532,11 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 
 								d.registerExplicitlySpecifiedMethods(srcTypeInfo, ittNode, pkg)
 								srcTypeInfo = d.RegisterType(types.Universe.Lookup("error").(*types.TypeName).Type().Underlying())
+							} else if isBuiltin && typeSpec.Name.Name == "comparable" {
+								// Same as error, the underlying type of the universe
+								// comparable is not the one shown in builtin.go.
+								d.registerExplicitlySpecifiedMethods(srcTypeInfo, ittNode, pkg)
+								srcTypeInfo = d.RegisterType(types.Universe.Lookup("comparable").(*types.TypeName).Type().Underlying())
 							}
 							d.registerExplicitlySpecifiedMethods(srcTypeInfo, ittNode, pkg)
 						}
@@ -2415,3 +2549,302 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 
 	return
 }
+
+// analyzePackage_CollectInstantiatedTypes registers the instantiated types
+// seen in the code of a package to their respective generic types.
+func (d *CodeAnalyzer) analyzePackage_CollectInstantiatedTypes(pkg *Package) {
+	for _, inst := range pkg.PPkg.TypesInfo.Instances {
+		named, ok := inst.Type.(*types.Named)
+		if !ok {
+			continue // an instantiated function
+		}
+
+		// Instantiations like List[T] within generic code are not listed.
+		if involvesTypeParams(named) {
+			continue
+		}
+
+		t := d.RegisterType(named)
+		origin := t.Origin
+		if origin == nil {
+			continue
+		}
+
+		var found bool
+		for _, it := range origin.Instantiations {
+			if it == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			origin.Instantiations = append(origin.Instantiations, t)
+		}
+	}
+}
+
+// involvesTypeParams reports whether or not a type involves type parameters.
+func involvesTypeParams(tt types.Type) bool {
+	switch t := tt.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if involvesTypeParams(args.At(i)) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return involvesTypeParams(types.Unalias(t))
+	case *types.Pointer:
+		return involvesTypeParams(t.Elem())
+	case *types.Slice:
+		return involvesTypeParams(t.Elem())
+	case *types.Array:
+		return involvesTypeParams(t.Elem())
+	case *types.Chan:
+		return involvesTypeParams(t.Elem())
+	case *types.Map:
+		return involvesTypeParams(t.Key()) || involvesTypeParams(t.Elem())
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if involvesTypeParams(t.At(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return involvesTypeParams(t.Params()) || involvesTypeParams(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if involvesTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if involvesTypeParams(t.ExplicitMethod(i).Type()) {
+				return true
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if involvesTypeParams(t.EmbeddedType(i)) {
+				return true
+			}
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if involvesTypeParams(t.Term(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registerDirectSelectorsForInstantiatedTypes builds the direct selectors of
+// instantiated types (and their underlying types) from their generic types.
+// The AST nodes of the selectors are shared with the generic types, but the
+// selector types are the instantiated ones, so that the selectors of the
+// instantiated types can be used in implementation analysis.
+func (d *CodeAnalyzer) registerDirectSelectorsForInstantiatedTypes() {
+	// New instantiated types might be registered in the loop,
+	// so traditional for-loop is used here.
+	for i := 0; i < len(d.allTypeInfos); i++ {
+		t := d.allTypeInfos[i]
+		if t.Origin == nil {
+			// The base types of pointer types might be not registered yet.
+			if ptr, ok := t.TT.(*types.Pointer); ok {
+				if named, ok := ptr.Elem().(*types.Named); ok && named.Origin() != named {
+					d.RegisterType(named)
+				}
+			}
+			continue
+		}
+
+		named := t.TT.(*types.Named)
+		origin := t.Origin
+
+		if len(origin.DirectSelectors) > 0 {
+			methods := make(map[string]*types.Func, named.NumMethods())
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				methods[m.Name()] = m
+			}
+
+			t.DirectSelectors = make([]*Selector, 0, len(origin.DirectSelectors))
+			for _, sel := range origin.DirectSelectors {
+				m := methods[sel.Method.Name]
+				if m == nil {
+					continue
+				}
+				method := *sel.Method
+				method.Type = d.registerInstantiatedMethodType(m)
+				t.DirectSelectors = append(t.DirectSelectors, &Selector{
+					Id:     sel.Id,
+					Method: &method,
+				})
+			}
+		}
+
+		d.registerDirectSelectorsFromGenericType(t.Underlying, origin.Underlying)
+	}
+
+	// The above might miss some types. The underlying type of a generic
+	// type might be an instantiated one whose selectors are built later,
+	// and unnamed interface types are also produced by substitution in
+	// other places, such as the signatures of instantiated functions and
+	// methods. So repeat until no more selectors can be built.
+	for progressed := true; progressed; {
+		progressed = false
+		for i := 0; i < len(d.allTypeInfos); i++ {
+			t := d.allTypeInfos[i]
+			if t.Origin != nil {
+				if d.registerDirectSelectorsFromGenericType(t.Underlying, t.Origin.Underlying) {
+					progressed = true
+				}
+			} else if _, ok := t.TT.(*types.Interface); ok && (t.attributes&directSelectorsCollected) == 0 {
+				if origin := d.substitutionOriginOfInterface(t); origin != nil && d.registerDirectSelectorsFromGenericType(t, origin) {
+					progressed = true
+				}
+			}
+		}
+	}
+}
+
+// substitutionOriginOfInterface returns the interface type (with type
+// parameters) which an unnamed interface type is produced from by
+// substitution, or nil if it is unknown. The origin is found through the
+// explicit methods, so it is unknown for the ones only with embeddings.
+func (d *CodeAnalyzer) substitutionOriginOfInterface(t *TypeInfo) *TypeInfo {
+	itt := t.TT.(*types.Interface)
+	for i := 0; i < itt.NumExplicitMethods(); i++ {
+		m := itt.ExplicitMethod(i)
+		origin := m.Origin()
+		if origin == m {
+			continue
+		}
+		// The receiver is the named type for the methods
+		// of named interfaces, or the interface type itself.
+		recv := origin.Type().(*types.Signature).Recv()
+		if recv == nil {
+			continue
+		}
+		if _, ok := recv.Type().Underlying().(*types.Interface); ok {
+			return d.RegisterType(recv.Type().Underlying())
+		}
+	}
+	return nil
+}
+
+// registerDirectSelectorsFromGenericType builds the direct selectors of an
+// unnamed struct or interface type which is produced by substituting the
+// type parameters in the corresponding type of a generic type.
+// It reports whether or not the selectors are built in this call.
+func (d *CodeAnalyzer) registerDirectSelectorsFromGenericType(ut, originUT *TypeInfo) bool {
+	if ut == originUT || (ut.attributes&directSelectorsCollected) != 0 {
+		return false
+	}
+	if (originUT.attributes & directSelectorsCollected) == 0 {
+		// The underlying type of the generic type is an instantiated one
+		// whose selectors are not built yet. Tried again later.
+		return false
+	}
+
+	switch utt := ut.TT.(type) {
+	case *types.Struct:
+		if _, ok := originUT.TT.(*types.Struct); !ok {
+			return false
+		}
+		ut.attributes |= directSelectorsCollected
+
+		fields := make(map[string]*types.Var, utt.NumFields())
+		for i := 0; i < utt.NumFields(); i++ {
+			v := utt.Field(i)
+			fields[v.Name()] = v
+		}
+
+		ut.DirectSelectors = make([]*Selector, 0, len(originUT.DirectSelectors))
+		for _, sel := range originUT.DirectSelectors {
+			v := fields[sel.Field.Name]
+			if v == nil {
+				continue
+			}
+			field := *sel.Field
+			field.Type = d.RegisterType(v.Type())
+			// Unnamed field types might be also produced by substitution.
+			d.registerDirectSelectorsFromGenericType(field.Type, sel.Field.Type)
+			ut.DirectSelectors = append(ut.DirectSelectors, &Selector{
+				Id:    sel.Id,
+				Field: &field,
+			})
+		}
+		ut.EmbeddingFields = originUT.EmbeddingFields
+	case *types.Interface:
+		if _, ok := originUT.TT.(*types.Interface); !ok {
+			return false
+		}
+		ut.attributes |= directSelectorsCollected
+
+		methods := make(map[string]*types.Func, utt.NumExplicitMethods())
+		for i := 0; i < utt.NumExplicitMethods(); i++ {
+			m := utt.ExplicitMethod(i)
+			methods[m.Name()] = m
+		}
+
+		ut.DirectSelectors = make([]*Selector, 0, len(originUT.DirectSelectors))
+		for _, sel := range originUT.DirectSelectors {
+			var newSel = &Selector{Id: sel.Id}
+			if sel.Method != nil {
+				m := methods[sel.Method.Name]
+				if m == nil {
+					continue
+				}
+				method := *sel.Method
+				method.Type = d.registerInstantiatedMethodType(m)
+				newSel.Method = &method
+			} else {
+				k := embeddedElementIndex(sel.Field.AstInterface, sel.Field.AstField)
+				if k < 0 || k >= utt.NumEmbeddeds() {
+					continue
+				}
+				field := *sel.Field
+				field.Type = d.RegisterType(utt.EmbeddedType(k))
+				newSel.Field = &field
+			}
+			ut.DirectSelectors = append(ut.DirectSelectors, newSel)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// registerInstantiatedMethodType registers the type of a method of an
+// instantiated type, together with its parameter and result types.
+// The latter ones are registered from AST for generic types.
+func (d *CodeAnalyzer) registerInstantiatedMethodType(m *types.Func) *TypeInfo {
+	sig := m.Type().(*types.Signature)
+	for i := 0; i < sig.Params().Len(); i++ {
+		d.RegisterType(sig.Params().At(i).Type())
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		d.RegisterType(sig.Results().At(i).Type())
+	}
+	return d.RegisterType(sig)
+}
+
+// embeddedElementIndex returns the index of an embedded element
+// among all the embedded elements in an interface type.
+func embeddedElementIndex(astInterface *ast.InterfaceType, astField *ast.Field) int {
+	k := 0
+	for _, field := range astInterface.Methods.List {
+		if field == astField {
+			return k
+		}
+		if len(field.Names) == 0 {
+			k++
+		}
+	}
+	return -1
+}
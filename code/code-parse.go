@@ -1,14 +1,21 @@
 package code
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/build"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -54,13 +61,14 @@ func collectPPackages(ppkgs []*packages.Package) map[string]*packages.Package {
 	return allPPkgs
 }
 
-func collectStdPackages() ([]string, error) {
+func collectStdPackages(options *ParseOptions) ([]string, error) {
 	//log.Println("[collect std packages ...]")
 	//defer log.Println("[collect std packages done]")
 
 	var configForCollectStdPkgs = &packages.Config{
 		Tests: false,
 	}
+	options.configure(configForCollectStdPkgs, options.primaryPlatform())
 
 	ppkgs, err := packages.Load(configForCollectStdPkgs, "std")
 	if err != nil {
@@ -76,7 +84,33 @@ func collectStdPackages() ([]string, error) {
 	return pkgs, nil
 }
 
-func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), args ...string) bool {
+// ParseOptions controls how packages are parsed.
+type ParseOptions struct {
+	// Also parse the _test.go files and the external xxx_test packages.
+	Tests bool
+
+	// The target platform. Blank means the one specified by
+	// the GOOS/GOARCH environment variables or the host one.
+	GOOS, GOARCH string
+
+	// Build tags.
+	Tags []string
+
+	// The other platforms to check in the multi-platform mode.
+	// See CodeAnalyzer.collectPlatformInfo for details.
+	Platforms []Platform
+
+	// The directory in which the package arguments are resolved.
+	// Blank means the current directory.
+	Dir string
+
+	// The directory to cache the statistics of packages in, so that a
+	// later run with the same arguments may skip collecting them for the
+	// unchanged packages. Blank means no caching. See analysisCache.
+	CacheDir string
+}
+
+func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), options ParseOptions, args ...string) bool {
 
 	var stopWatch = util.NewStopWatch()
 	if onSubTaskDone == nil {
@@ -100,52 +134,24 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 Start:
 	//log.Println("[parse packages ...], args:", args)
 
-	// ToDo: check cache to avoid parsing again.
-
 	var numParsedPackages int32
+	var parseFile = func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+		if num := atomic.AddInt32(&numParsedPackages, 1); num == 1 {
+			logProgress(true, SubTask_PreparationDone)
+		} else if num&(num-1) == 0 {
+			logProgress(false, SubTask_NFilesParsed, num)
+		}
 
-	var configForParsing = &packages.Config{
-		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
-			packages.NeedTypes | packages.NeedExportsFile | packages.NeedFiles |
-			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
-			packages.NeedSyntax | packages.NeedTypesInfo,
-		Tests: false, // ToDo: parse tests
-		// It looks, if Tests is set to true, then run "GOOS=windows gold std" will fail with
-		//		panic: TypeName for runtime.LFNode not found
-
-		//Logf: func(format string, args ...interface{}) {
-		//	log.Println("================================================\n", args)
-		//},
-
-		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
-			if num := atomic.AddInt32(&numParsedPackages, 1); num == 1 {
-				logProgress(true, SubTask_PreparationDone)
-			} else if num&(num-1) == 0 {
-				logProgress(false, SubTask_NFilesParsed, num)
-			}
-
-			//defer log.Println("parsed", filename)
-			const mode = parser.AllErrors | parser.ParseComments
-			return parser.ParseFile(fset, filename, src, mode)
-		},
-
-		// Reasons to disable this:
-		// 1. to surpress "imported but not used" errors
-		// 2. to implemente "voew code" and "jump to definition" features.
-		// It looks the memory comsumed will be doubled.
-		//ParseFile: avoidCheckFuncBody,
-
-		//       NeedTypes: NeedTypes adds Types, Fset, and IllTyped.
-		//       Why can't only Fset be got?
-		// ToDo: modify go/packages code to not use go/types.
-		//       use ast only and build type info tailored for docs and code reading.
-		//       But it looks NeedTypes doesn't consume much more memory, so ...
-		//       And, go/types can be used to verify the correctness of the custom implementaion.
+		//defer log.Println("parsed", filename)
+		const mode = parser.AllErrors | parser.ParseComments
+		return parser.ParseFile(fset, filename, src, mode)
 	}
 
-	ppkgs, err := packages.Load(configForParsing, args...)
-	if err != nil {
-		log.Println("packages.Load (parse packages):", err)
+	d.targetPlatform = options.primaryPlatform()
+	d.cache = newAnalysisCache(&options, args)
+
+	allPPkgs, tests, stdPkgs, ok := loadPackages(&options, d.targetPlatform, parseFile, args)
+	if !ok {
 		return false
 	}
 
@@ -155,34 +161,10 @@ Start:
 		logProgress(true, SubTask_ParsePackagesDone, -1)
 	}
 
-	stdPkgs, err := collectStdPackages()
-	if err != nil {
-		log.Fatal("failed to collect std packages: ", err)
-	}
-
 	defer func() {
 		logProgress(true, SubTask_CollectPackages, int32(len(d.packageList)))
 	}()
 
-	var hasErrors bool
-	for _, ppkg := range ppkgs {
-		switch ppkg.PkgPath {
-		case "builtin":
-			// skip "illegal cycle in declaration of int" alike errors.
-		//case "unsafe":
-		default:
-			// ToDo: how to judge "imported but not used" errors?
-
-			if packages.PrintErrors([]*packages.Package{ppkg}) > 0 {
-				hasErrors = true
-			}
-		}
-	}
-	if hasErrors {
-		log.Fatal("exit for above errors")
-	}
-
-	var allPPkgs = collectPPackages(ppkgs)
 	d.packageList = make([]*Package, 0, len(allPPkgs))
 	d.packageTable = make(map[string]*Package, len(allPPkgs))
 
@@ -201,6 +183,10 @@ Start:
 		fillUnsafePackage(unsafePPkg, builtinPPkg)
 	}
 
+	if tests != nil {
+		loadTestPackages(allPPkgs, tests)
+	}
+
 	//var packageListChanged = false
 	for path, ppkg := range allPPkgs {
 		pkg := d.packageTable[ppkg.PkgPath]
@@ -217,6 +203,10 @@ Start:
 
 			log.Println("     [parsed]", path, "(duplicated?)")
 		}
+		// Skip "illegal cycle in declaration of int" alike errors of builtin.
+		if path != "builtin" {
+			d.packageTable[path].Errors = collectPackageErrors(ppkg, options.Dir)
+		}
 		//if len(ppkg.Errors) > 0 {
 		//	for _, err := range ppkg.Errors {
 		//		log.Printf("          error: %#v", err)
@@ -225,6 +215,22 @@ Start:
 	}
 	d.builtinPkg = d.packageTable["builtin"]
 
+	// ToDo: how to judge "imported but not used" errors?
+	var numErrors int
+	for _, pkg := range d.IllTypedPackages() {
+		for _, e := range pkg.Errors {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		numErrors += len(pkg.Errors)
+	}
+	if numErrors > 0 {
+		log.Printf("%d errors are found. The packages having errors are analyzed as much as possible.", numErrors)
+	}
+
+	if len(options.Platforms) > 0 {
+		d.collectPlatformInfo(&options, args)
+	}
+
 	var pkgNumDepedBys = make(map[*Package]uint32, len(allPPkgs))
 	for _, pkg := range d.packageList {
 		pkg.Deps = make([]*Package, 0, len(pkg.PPkg.Imports))
@@ -251,14 +257,17 @@ Start:
 	//log.Println("[parse packages done]")
 
 	// Confirm std packages.
+	// Other modules are confirmed in confirmPackageModules.
 	d.stdModule = &Module{
-		Dir:     "",
-		Root:    "",
-		Version: "", // ToDo
+		Dir:     filepath.Join(build.Default.GOROOT, "src"),
+		Root:    "std",
+		Version: goVersion(build.Default.GOROOT),
 	}
 	estimatedNumMods := 1 + len(d.packageList)/3
-	d.allModules = make([]*Module, estimatedNumMods)
+	d.allModules = make([]*Module, 0, estimatedNumMods)
 	d.allModules = append(d.allModules, d.stdModule)
+	d.moduleTable = make(map[string]*Module, estimatedNumMods)
+	d.moduleTable[d.stdModule.Root] = d.stdModule
 
 	for _, path := range stdPkgs {
 		pkg := d.packageTable[path]
@@ -272,6 +281,180 @@ Start:
 	return true
 }
 
+// loadPackages lists, parses and type-checks the packages specified by args
+// and their dependencies. The test files and the std packages are also listed.
+func loadPackages(options *ParseOptions, platform Platform, parseFile func(*token.FileSet, string, []byte) (*ast.File, error), args []string) (allPPkgs map[string]*packages.Package, tests map[string]*testPackageFiles, stdPkgs []string, ok bool) {
+	tests, args, err := listTests(options, args)
+	if err != nil {
+		log.Println("packages.Load (collect tests):", err)
+		return nil, nil, nil, false
+	}
+
+	var configForParsing = &packages.Config{
+		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
+			packages.NeedTypes | packages.NeedExportsFile | packages.NeedFiles |
+			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
+			packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule |
+			packages.NeedEmbedFiles,
+		Tests: false, // test files are parsed in loadTestPackages if needed.
+		// It looks, if Tests is set to true, then run "GOOS=windows gold std" will fail with
+		//		panic: TypeName for runtime.LFNode not found
+		// The reason is test variant packages are not deduplicated.
+
+		//Logf: func(format string, args ...interface{}) {
+		//	log.Println("================================================\n", args)
+		//},
+
+		ParseFile: parseFile,
+
+		// Reasons to disable this:
+		// 1. to surpress "imported but not used" errors
+		// 2. to implemente "voew code" and "jump to definition" features.
+		// It looks the memory comsumed will be doubled.
+		//ParseFile: avoidCheckFuncBody,
+
+		//       NeedTypes: NeedTypes adds Types, Fset, and IllTyped.
+		//       Why can't only Fset be got?
+		// ToDo: modify go/packages code to not use go/types.
+		//       use ast only and build type info tailored for docs and code reading.
+		//       But it looks NeedTypes doesn't consume much more memory, so ...
+		//       And, go/types can be used to verify the correctness of the custom implementaion.
+	}
+
+	options.configure(configForParsing, platform)
+
+	ppkgs, err := packages.Load(configForParsing, args...)
+	if err != nil {
+		log.Println("packages.Load (parse packages):", err)
+		return nil, nil, nil, false
+	}
+
+	stdPkgs, err = collectStdPackages(options)
+	if err != nil {
+		log.Fatal("failed to collect std packages: ", err)
+	}
+
+	return collectPPackages(ppkgs), tests, stdPkgs, true
+}
+
+// listTests collects the test files if options.Tests is true.
+//
+// Test variants are not loaded by go/packages directly,
+// for the types in them are not identical to the types
+// in the corresponding non-test packages, which would
+// make the analyzing results messy. Instead, test files
+// are type-checked later against the non-test packages.
+// Here, the packages imported by test files are added
+// to the arguments, so that they will be also loaded.
+func listTests(options *ParseOptions, args []string) (map[string]*testPackageFiles, []string, error) {
+	if !options.Tests {
+		return nil, args, nil
+	}
+	tests, testImports, err := collectTestPackageFiles(options, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tests, append(args, testImports...), nil
+}
+
+// collectPackageErrors converts and sorts the errors of a package.
+// dir is the directory in which "go list" runs.
+func collectPackageErrors(ppkg *packages.Package, dir string) []PackageError {
+	if len(ppkg.Errors) == 0 {
+		return nil
+	}
+
+	var hasOthers bool
+	for _, e := range ppkg.Errors {
+		if e.Kind != packages.ListError {
+			hasOthers = true
+			break
+		}
+	}
+
+	errs := make([]PackageError, 0, len(ppkg.Errors))
+	for _, e := range ppkg.Errors {
+		// The outputs of the compiler (invoked by "go list -export")
+		// repeat the parse and type-check errors found by go/packages.
+		if hasOthers && e.Kind == packages.ListError && strings.HasPrefix(e.Msg, "# ") {
+			continue
+		}
+		errs = append(errs, PackageError{
+			Position: parseErrorPosition(e.Pos, dir),
+			Msg:      e.Msg,
+			Kind:     e.Kind,
+		})
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].Position, errs[j].Position
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return errs
+}
+
+// parseErrorPosition parses the positions of packages.Error,
+// which are in the forms "file:line:col", "file:line", "file" and "-".
+// Relative file paths are joined with dir (blank means the current directory).
+func parseErrorPosition(pos, dir string) token.Position {
+	var p token.Position
+	if pos == "" || pos == "-" {
+		return p
+	}
+
+	// File paths might contain colons (on Windows, for example),
+	// so the numbers are parsed from the end.
+	var nums [2]int
+	var n int
+	for n < len(nums) {
+		i := strings.LastIndexByte(pos, ':')
+		if i < 0 {
+			break
+		}
+		v, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		nums[n] = v
+		n++
+		pos = pos[:i]
+	}
+
+	// The positions of some list errors are relative to
+	// the directory in which "go list" runs.
+	p.Filename = pos
+	if !filepath.IsAbs(pos) {
+		if abs, err := filepath.Abs(filepath.Join(dir, pos)); err == nil {
+			p.Filename = abs
+		}
+	}
+	switch n {
+	case 1:
+		p.Line = nums[0]
+	case 2:
+		p.Line, p.Column = nums[1], nums[0]
+	}
+	return p
+}
+
+// goVersion returns the version of the Go toolchain installed at goroot.
+func goVersion(goroot string) string {
+	data, err := ioutil.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		// Might be a toolchain built from source.
+		return runtime.Version()
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
+	}
+	return string(bytes.TrimSpace(data))
+}
+
 func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Package) {
 	intType := builtinPPkg.Types.Scope().Lookup("int").Type()
 
@@ -307,7 +490,7 @@ func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Packa
 	unsafePPkg.TypesInfo.Types = make(map[ast.Expr]types.TypeAndValue)
 	unsafePPkg.Fset = fset
 
-	var artitraryExpr, intExpr ast.Expr
+	var artitraryExpr, intExpr, integerExpr ast.Expr
 	var artitraryType types.Type
 
 	for filename, astFile := range astPkg.Files {
@@ -374,6 +557,14 @@ func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Packa
 					typeObj = types.NewTypeName(typeSpec.Pos(), types.Unsafe, typeSpec.Name.Name, nil)
 					unsafePPkg.Types.Scope().Insert(typeObj)
 					artitraryType = types.NewNamed(typeObj, intType.Underlying(), nil)
+				case "IntegerType": // since Go 1.17
+					integerExpr = typeSpec.Type
+
+					// Like ArbitraryType, IntegerType is just a placeholder
+					// for documentation purpose. Create one manually.
+					typeObj = types.NewTypeName(typeSpec.Pos(), types.Unsafe, typeSpec.Name.Name, nil)
+					unsafePPkg.Types.Scope().Insert(typeObj)
+					types.NewNamed(typeObj, intType.Underlying(), nil)
 				}
 
 				// new declared type (source type will be set below)
@@ -399,4 +590,355 @@ func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Packa
 	// source types
 	unsafePPkg.TypesInfo.Types[intExpr] = types.TypeAndValue{Type: intType}
 	unsafePPkg.TypesInfo.Types[artitraryExpr] = types.TypeAndValue{Type: artitraryType}
+	if integerExpr != nil {
+		unsafePPkg.TypesInfo.Types[integerExpr] = types.TypeAndValue{Type: intType}
+	}
+}
+
+// testPackageFiles records the test files of a package.
+type testPackageFiles struct {
+	// The files in the tested package itself.
+	inPkgFiles []string
+
+	// The files in the external xxx_test package.
+	xPkgName  string
+	xPkgFiles []string
+
+	// import path -> package path
+	imports map[string]string
+}
+
+// collectTestPackageFiles finds the test files of the packages specified by args.
+// The paths of the packages imported by the test files are also returned,
+// so that they can be loaded together with the non-test packages.
+func collectTestPackageFiles(options *ParseOptions, args []string) (map[string]*testPackageFiles, []string, error) {
+	var configForCollectTests = &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports,
+		Tests: true,
+	}
+	options.configure(configForCollectTests, options.primaryPlatform())
+
+	ppkgs, err := packages.Load(configForCollectTests, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The ID of a test variant package is in the form "path [testedpath.test]".
+	// The form of the ID of a non-test package is the same as its package path.
+	var variantPkgPath = func(id string) (pkgPath, testedPath string) {
+		i := strings.Index(id, " [")
+		if i < 0 {
+			return id, ""
+		}
+		return id[:i], strings.TrimSuffix(id[i+2:], ".test]")
+	}
+
+	var tests = make(map[string]*testPackageFiles, len(ppkgs)/2)
+	var testImports = make(map[string]struct{}, 128)
+	for _, ppkg := range ppkgs {
+		pkgPath, testedPath := variantPkgPath(ppkg.ID)
+		if testedPath == "" {
+			// non-test packages and the generated "xxx.test" main packages.
+			continue
+		}
+
+		var files []string
+		for _, filename := range ppkg.GoFiles {
+			if strings.HasSuffix(filename, "_test.go") {
+				files = append(files, filename)
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		tpf := tests[testedPath]
+		if tpf == nil {
+			tpf = &testPackageFiles{imports: make(map[string]string, len(ppkg.Imports))}
+			tests[testedPath] = tpf
+		}
+
+		switch pkgPath {
+		default:
+			// Other packages recompiled for the test. They contain no test files.
+			continue
+		case testedPath:
+			tpf.inPkgFiles = files
+		case testedPath + "_test":
+			tpf.xPkgName = ppkg.Name
+			tpf.xPkgFiles = files
+		}
+
+		for importPath, p := range ppkg.Imports {
+			path, _ := variantPkgPath(p.ID)
+			tpf.imports[importPath] = path
+			testImports[path] = struct{}{}
+		}
+	}
+
+	var paths = make([]string, 0, len(testImports))
+	for path := range testImports {
+		paths = append(paths, path)
+	}
+
+	return tests, paths, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// loadTestPackages parses the test files collected by collectTestPackageFiles
+// and type-checks them against the loaded non-test packages.
+//
+// The in-package test files are merged into the packages they test,
+// so that no duplicated packages are produced. The declared objects
+// in them are inserted into the scopes of the tested packages.
+// The external xxx_test packages are put into allPPkgs as new packages.
+//
+// The test files in a package will be discarded if they contain errors.
+// Before being merged, the in-package test files are type-checked together
+// with the non-test files in a scratch package, so that a failed check
+// leaves nothing in the tested package. The methods declared for non-test
+// types in the in-package test files are removed from the files, for
+// go/types doesn't support checking such declarations incrementally.
+// The errors and the removed methods are reported as package errors.
+func loadTestPackages(allPPkgs map[string]*packages.Package, tests map[string]*testPackageFiles) {
+	var parseFiles = func(ppkg *packages.Package, filenames []string) ([]*ast.File, bool) {
+		astFiles := make([]*ast.File, 0, len(filenames))
+		for _, filename := range filenames {
+			const mode = parser.AllErrors | parser.ParseComments
+			astFile, err := parser.ParseFile(ppkg.Fset, filename, nil, mode)
+			if err != nil {
+				if list, ok := err.(scanner.ErrorList); ok {
+					for _, e := range list {
+						ppkg.Errors = append(ppkg.Errors, packages.Error{Pos: e.Pos.String(), Msg: e.Msg, Kind: packages.ParseError})
+					}
+				} else {
+					ppkg.Errors = append(ppkg.Errors, packages.Error{Pos: filename, Msg: err.Error(), Kind: packages.ParseError})
+				}
+				return nil, false
+			}
+			astFiles = append(astFiles, astFile)
+		}
+		return astFiles, true
+	}
+
+	var newTypesInfo = func() *types.Info {
+		return &types.Info{
+			Types:        make(map[ast.Expr]types.TypeAndValue),
+			Instances:    make(map[*ast.Ident]types.Instance),
+			Defs:         make(map[*ast.Ident]types.Object),
+			Uses:         make(map[*ast.Ident]types.Object),
+			Implicits:    make(map[ast.Node]types.Object),
+			Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:       make(map[ast.Node]*types.Scope),
+			FileVersions: make(map[*ast.File]string),
+		}
+	}
+
+	// typeCheck checks astFiles as a part of pkg and returns the errors
+	// in the files which names are in testFiles. The errors in the other
+	// files (the non-test files checked in a scratch package) are the ones
+	// already reported by packages.Load.
+	var typeCheck = func(ppkg *packages.Package, pkg *types.Package, info *types.Info, astFiles []*ast.File, imports map[string]string, testFiles []string) []packages.Error {
+		var errs []packages.Error
+		conf := &types.Config{
+			Importer: importerFunc(func(importPath string) (*types.Package, error) {
+				path, ok := imports[importPath]
+				if !ok {
+					path = importPath
+				}
+				if p := allPPkgs[path]; p != nil && p.Types != nil {
+					return p.Types, nil
+				}
+				if path == "unsafe" {
+					return types.Unsafe, nil
+				}
+				return nil, fmt.Errorf("package %s is not loaded", path)
+			}),
+			Sizes: ppkg.TypesSizes,
+			Error: func(err error) {
+				e, ok := err.(types.Error)
+				if !ok {
+					errs = append(errs, packages.Error{Pos: "-", Msg: err.Error(), Kind: packages.TypeError})
+					return
+				}
+				pos := e.Fset.Position(e.Pos)
+				for _, f := range testFiles {
+					if f == pos.Filename {
+						errs = append(errs, packages.Error{Pos: pos.String(), Msg: e.Msg, Kind: packages.TypeError})
+						break
+					}
+				}
+			},
+		}
+		// The same as packages.Load.
+		if ppkg.Module != nil && ppkg.Module.GoVersion != "" {
+			conf.GoVersion = "go" + ppkg.Module.GoVersion
+		}
+		types.NewChecker(conf, ppkg.Fset, pkg, info).Files(astFiles)
+		return errs
+	}
+
+	// package path -> why some declarations in the test files are ignored.
+	// The errors caused by the ignored declarations are not reported one
+	// by one, for they might be many.
+	var ignoredReasons = make(map[string]string)
+	var reportErrors = func(ppkg *packages.Package, errs []packages.Error) {
+		log.Printf("test files of package %s are ignored for %d errors", ppkg.PkgPath, len(errs))
+		if reason := ignoredReasons[ppkg.PkgPath]; reason != "" {
+			ppkg.Errors = append(ppkg.Errors, packages.Error{
+				Pos:  errs[0].Pos,
+				Msg:  fmt.Sprintf("test files are ignored for %d errors (the first one: %s), which are probably caused by %s", len(errs), errs[0].Msg, reason),
+				Kind: packages.TypeError,
+			})
+			return
+		}
+		ppkg.Errors = append(ppkg.Errors, errs...)
+	}
+
+	// The in-package test files must be handled before the external test packages,
+	// for the latters might use the declarations in the formers (export_test.go).
+	for path, tpf := range tests {
+		ppkg := allPPkgs[path]
+		if ppkg == nil || ppkg.Types == nil || len(tpf.inPkgFiles) == 0 {
+			continue
+		}
+
+		astFiles, ok := parseFiles(ppkg, tpf.inPkgFiles)
+		if !ok {
+			continue
+		}
+		for _, fd := range removeMethodsOfTypesDeclaredOutside(ppkg.Types, astFiles) {
+			ppkg.Errors = append(ppkg.Errors, packages.Error{
+				Pos:  ppkg.Fset.Position(fd.Name.Pos()).String(),
+				Msg:  fmt.Sprintf("method %s.%s declared in a test file is ignored, for its receiver type is declared in a non-test file", receiverTypeName(fd.Recv.List[0].Type), fd.Name.Name),
+				Kind: packages.UnknownError,
+			})
+			ignoredReasons[path] = "the ignored methods"
+		}
+
+		scratch := types.NewPackage(ppkg.PkgPath, ppkg.Name)
+		allFiles := append(ppkg.Syntax[:len(ppkg.Syntax):len(ppkg.Syntax)], astFiles...)
+		errs := typeCheck(ppkg, scratch, newTypesInfo(), allFiles, tpf.imports, tpf.inPkgFiles)
+		info := newTypesInfo()
+		if len(errs) == 0 {
+			// Not expected to fail, but the objects might
+			// have been inserted into the package if it does.
+			errs = typeCheck(ppkg, ppkg.Types, info, astFiles, tpf.imports, tpf.inPkgFiles)
+		}
+		if len(errs) > 0 {
+			reportErrors(ppkg, errs)
+			if ignoredReasons[path] == "" {
+				ignoredReasons[path] = "the ignored in-package test files"
+			}
+			continue
+		}
+		mergeTypesInfo(ppkg.TypesInfo, info)
+
+		ppkg.GoFiles = append(ppkg.GoFiles, tpf.inPkgFiles...)
+		ppkg.CompiledGoFiles = append(ppkg.CompiledGoFiles, tpf.inPkgFiles...)
+		ppkg.Syntax = append(ppkg.Syntax, astFiles...)
+	}
+
+	for path, tpf := range tests {
+		ppkg := allPPkgs[path]
+		if ppkg == nil || ppkg.Types == nil || len(tpf.xPkgFiles) == 0 {
+			continue
+		}
+
+		astFiles, ok := parseFiles(ppkg, tpf.xPkgFiles)
+		if !ok {
+			continue
+		}
+
+		xPath := path + "_test"
+		xppkg := &packages.Package{
+			ID:              xPath + " [" + path + ".test]",
+			Name:            tpf.xPkgName,
+			PkgPath:         xPath,
+			GoFiles:         tpf.xPkgFiles,
+			CompiledGoFiles: tpf.xPkgFiles,
+			Imports:         make(map[string]*packages.Package, len(tpf.imports)),
+			Types:           types.NewPackage(xPath, tpf.xPkgName),
+			Fset:            ppkg.Fset,
+			Syntax:          astFiles,
+			TypesInfo:       newTypesInfo(),
+			TypesSizes:      ppkg.TypesSizes,
+			Module:          ppkg.Module,
+		}
+		// The errors are reported in the tested package,
+		// for the external test package is discarded.
+		if errs := typeCheck(xppkg, xppkg.Types, xppkg.TypesInfo, astFiles, tpf.imports, tpf.xPkgFiles); len(errs) > 0 {
+			reportErrors(ppkg, errs)
+			continue
+		}
+
+		for _, astFile := range astFiles {
+			for _, imp := range astFile.Imports {
+				importPath := strings.Trim(imp.Path.Value, "`\"")
+				depPath, ok := tpf.imports[importPath]
+				if !ok {
+					depPath = importPath
+				}
+				if dep := allPPkgs[depPath]; dep != nil {
+					xppkg.Imports[importPath] = dep
+				}
+			}
+		}
+
+		allPPkgs[xPath] = xppkg
+	}
+}
+
+// mergeTypesInfo copies the entries in the maps of from into the maps of to.
+// The maps of to must be all created (as packages.Load does).
+func mergeTypesInfo(to, from *types.Info) {
+	for k, v := range from.Types {
+		to.Types[k] = v
+	}
+	for k, v := range from.Instances {
+		to.Instances[k] = v
+	}
+	for k, v := range from.Defs {
+		to.Defs[k] = v
+	}
+	for k, v := range from.Uses {
+		to.Uses[k] = v
+	}
+	for k, v := range from.Implicits {
+		to.Implicits[k] = v
+	}
+	for k, v := range from.Selections {
+		to.Selections[k] = v
+	}
+	for k, v := range from.Scopes {
+		to.Scopes[k] = v
+	}
+	for k, v := range from.FileVersions {
+		to.FileVersions[k] = v
+	}
+}
+
+// removeMethodsOfTypesDeclaredOutside removes the declarations of the methods
+// whose receiver base types are declared in pkg but outside of astFiles.
+// The removed declarations are returned.
+func removeMethodsOfTypesDeclaredOutside(pkg *types.Package, astFiles []*ast.File) []*ast.FuncDecl {
+	var removed []*ast.FuncDecl
+	for _, astFile := range astFiles {
+		decls := astFile.Decls[:0]
+		for _, decl := range astFile.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil && len(fd.Recv.List) > 0 {
+				baseName := receiverTypeName(fd.Recv.List[0].Type)
+				if _, ok := pkg.Scope().Lookup(baseName).(*types.TypeName); ok {
+					removed = append(removed, fd)
+					continue
+				}
+			}
+			decls = append(decls, decl)
+		}
+		astFile.Decls = decls
+	}
+	return removed
 }
@@ -0,0 +1,186 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// DeprecationNotice returns the paragraph starting with "Deprecated: "
+// in a doc comment text. Blank is returned if there is no such paragraph.
+func DeprecationNotice(doc string) string {
+	for _, para := range strings.Split(doc, "\n\n") {
+		if para = strings.TrimSpace(para); strings.HasPrefix(para, "Deprecated: ") {
+			return para
+		}
+	}
+	return ""
+}
+
+// Deprecated returns the deprecation notice of the type name.
+func (tn *TypeName) Deprecated() string {
+	return DeprecationNotice(tn.Documentation())
+}
+
+// Deprecated returns the deprecation notice of the constant.
+func (c *Constant) Deprecated() string {
+	return DeprecationNotice(c.Documentation())
+}
+
+// Deprecated returns the deprecation notice of the variable.
+func (v *Variable) Deprecated() string {
+	return DeprecationNotice(v.Documentation())
+}
+
+// Deprecated returns the deprecation notice of the function.
+func (f *Function) Deprecated() string {
+	if f.AstDecl == nil {
+		return ""
+	}
+	return DeprecationNotice(f.Documentation())
+}
+
+// Deprecated returns the deprecation notice of the interface method.
+func (im *InterfaceMethod) Deprecated() string {
+	return im.Method.Deprecated()
+}
+
+// Deprecated returns the deprecation notice of the field.
+func (fld *Field) Deprecated() string {
+	if fld.AstField == nil {
+		return ""
+	}
+	return DeprecationNotice(fld.AstField.Doc.Text())
+}
+
+// Deprecated returns the deprecation notice of the method.
+func (mthd *Method) Deprecated() string {
+	switch {
+	case mthd.AstFunc != nil:
+		return DeprecationNotice(mthd.AstFunc.Doc.Text())
+	case mthd.AstField != nil:
+		return DeprecationNotice(mthd.AstField.Doc.Text())
+	}
+	return ""
+}
+
+// Deprecated returns the deprecation notice of the field or method.
+func (s *Selector) Deprecated() string {
+	if s.Field != nil {
+		return s.Field.Deprecated()
+	}
+	return s.Method.Deprecated()
+}
+
+// A deprecation records the deprecation notice of an object.
+type deprecation struct {
+	name   string // "Name" or "Type.Selector"
+	notice string
+}
+
+// collectDeprecatedObjects finds the deprecated package-level identifiers,
+// fields and methods declared in all packages.
+func (d *CodeAnalyzer) collectDeprecatedObjects() {
+	d.deprecatedObjects = make(map[types.Object]deprecation)
+	for _, pkg := range d.packageList {
+		if pkg.PPkg.TypesInfo == nil {
+			continue
+		}
+		var register = func(ident *ast.Ident, name string, doc *ast.CommentGroup) {
+			notice := DeprecationNotice(doc.Text())
+			if notice == "" {
+				return
+			}
+			if obj := pkg.PPkg.TypesInfo.Defs[ident]; obj != nil {
+				d.deprecatedObjects[obj] = deprecation{name, notice}
+			}
+		}
+
+		for _, file := range pkg.PPkg.Syntax {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					register(decl.Name, FuncDeclName(decl), decl.Doc)
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						switch spec := spec.(type) {
+						case *ast.ValueSpec:
+							for _, ident := range spec.Names {
+								register(ident, ident.Name, genDeclSpecDoc(decl, spec.Doc))
+							}
+						case *ast.TypeSpec:
+							register(spec.Name, spec.Name.Name, genDeclSpecDoc(decl, spec.Doc))
+							ast.Inspect(spec.Type, func(n ast.Node) bool {
+								switch n := n.(type) {
+								case *ast.FuncType:
+									return false // parameters and results
+								case *ast.Field:
+									for _, ident := range n.Names {
+										register(ident, spec.Name.Name+"."+ident.Name, n.Doc)
+									}
+								}
+								return true
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// A DeprecatedUse is a use of a deprecated identifier.
+type DeprecatedUse struct {
+	Identifier // the use
+
+	Object   types.Object // the deprecated object
+	Name     string       // "Name" or "Type.Selector"
+	Notice   string
+	Position token.Position // the position of the use
+}
+
+// DeprecatedUses returns the uses of deprecated identifiers in the specified
+// packages, sorted by package paths and positions. The uses in the packages
+// declaring the deprecated identifiers are not reported.
+func (d *CodeAnalyzer) DeprecatedUses(pkgs []*Package) []DeprecatedUse {
+	var pkgSet = make(map[*Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		pkgSet[pkg] = true
+	}
+
+	var uses []DeprecatedUse
+	for obj, ids := range d.objectRefs {
+		origin := originObject(obj)
+		dep, ok := d.deprecatedObjects[origin]
+		if !ok {
+			continue
+		}
+		for _, id := range ids {
+			pkg := id.FileInfo.Pkg
+			if !pkgSet[pkg] || pkg.PPkg.Types == origin.Pkg() {
+				continue
+			}
+			uses = append(uses, DeprecatedUse{
+				Identifier: id,
+				Object:     origin,
+				Name:       dep.name,
+				Notice:     dep.notice,
+				Position:   pkg.PPkg.Fset.PositionFor(id.AstIdent.Pos(), false),
+			})
+		}
+	}
+
+	sort.Slice(uses, func(i, j int) bool {
+		a, b := &uses[i], &uses[j]
+		if a.FileInfo.Pkg != b.FileInfo.Pkg {
+			return a.FileInfo.Pkg.Path() < b.FileInfo.Pkg.Path()
+		}
+		if a.Position.Filename != b.Position.Filename {
+			return a.Position.Filename < b.Position.Filename
+		}
+		return a.Position.Offset < b.Position.Offset
+	})
+	return uses
+}
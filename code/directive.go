@@ -0,0 +1,187 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// A Directive is a comment line like "//go:noinline", "//go:build linux"
+// or "//export F". Line directives are not viewed as Directives.
+type Directive struct {
+	Name string // such as "go:noinline", "export"
+	Args string // blank for directives without arguments
+	Pos  token.Pos
+}
+
+func (d Directive) String() string {
+	if d.Args == "" {
+		return "//" + d.Name
+	}
+	return "//" + d.Name + " " + d.Args
+}
+
+// parseDirective parses a comment as a directive. The rules are the same
+// as the ones go/ast uses to exclude directives from comment texts.
+func parseDirective(c *ast.Comment) (Directive, bool) {
+	if !strings.HasPrefix(c.Text, "//") {
+		return Directive{}, false
+	}
+	text := c.Text[2:]
+	if strings.HasPrefix(text, "line ") {
+		return Directive{}, false
+	}
+	if !strings.HasPrefix(text, "extern ") && !strings.HasPrefix(text, "export ") {
+		colon := strings.IndexByte(text, ':')
+		if colon <= 0 || colon+1 >= len(text) {
+			return Directive{}, false
+		}
+		for i := 0; i <= colon+1; i++ {
+			if b := text[i]; i != colon && !('a' <= b && b <= 'z' || '0' <= b && b <= '9') {
+				return Directive{}, false
+			}
+		}
+	}
+
+	name, args := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		name, args = text[:i], strings.TrimSpace(text[i+1:])
+	}
+	return Directive{Name: name, Args: args, Pos: c.Pos()}, true
+}
+
+// directivesInComments returns the directives in some comment groups.
+func directivesInComments(groups ...*ast.CommentGroup) []Directive {
+	var directives []Directive
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, c := range g.List {
+			if d, ok := parseDirective(c); ok {
+				directives = append(directives, d)
+			}
+		}
+	}
+	return directives
+}
+
+// collectFileDirectives finds the directives in an ast file which are
+// not in the doc comments of package-level declarations, such as the
+// //go:build and //go:generate directives.
+func collectFileDirectives(file *ast.File) []Directive {
+	var declDocs = make(map[*ast.CommentGroup]bool)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			declDocs[decl.Doc] = true
+		case *ast.GenDecl:
+			declDocs[decl.Doc] = true
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					declDocs[spec.Doc] = true
+				case *ast.ValueSpec:
+					declDocs[spec.Doc] = true
+				}
+			}
+		}
+	}
+
+	var directives []Directive
+	for _, g := range file.Comments {
+		if !declDocs[g] {
+			directives = append(directives, directivesInComments(g)...)
+		}
+	}
+	return directives
+}
+
+// genDeclSpecDoc returns the doc comment of a spec in a GenDecl.
+// The doc comment of an unparenthesized GenDecl is for its only spec.
+func genDeclSpecDoc(gd *ast.GenDecl, specDoc *ast.CommentGroup) *ast.CommentGroup {
+	if specDoc == nil && gd != nil && !gd.Lparen.IsValid() {
+		return gd.Doc
+	}
+	return specDoc
+}
+
+// Directives returns the directives in the doc comment of the function.
+func (f *Function) Directives() []Directive {
+	if f.AstDecl == nil {
+		return nil
+	}
+	return directivesInComments(f.AstDecl.Doc)
+}
+
+// Directives returns the directives in the doc comment of the type name.
+func (tn *TypeName) Directives() []Directive {
+	if tn.AstSpec == nil {
+		return nil
+	}
+	return directivesInComments(genDeclSpecDoc(tn.AstDecl, tn.AstSpec.Doc))
+}
+
+// Directives returns the directives in the doc comment of the constant.
+func (c *Constant) Directives() []Directive {
+	return directivesInComments(genDeclSpecDoc(c.AstDecl, c.AstSpec.Doc))
+}
+
+// Directives returns the directives in the doc comment of the variable.
+func (v *Variable) Directives() []Directive {
+	return directivesInComments(genDeclSpecDoc(v.AstDecl, v.AstSpec.Doc))
+}
+
+// A DirectiveUse is a directive used in a package.
+type DirectiveUse struct {
+	Directive
+
+	// The declaration the directive is for.
+	// Nil for the directives not in declaration doc comments.
+	Resource Resource
+}
+
+// DirectiveUses returns all the directives used in the package,
+// sorted by directive names and then by positions.
+func (p *Package) DirectiveUses() []DirectiveUse {
+	// Files generated by cgo from scratch are ignored.
+	var ignoredFiles = make(map[string]bool)
+	for i := range p.SourceFiles {
+		if info := &p.SourceFiles[i]; info.OriginalFile == "" && info.GeneratedFile != "" {
+			ignoredFiles[info.GeneratedFile] = true
+		}
+	}
+
+	var uses []DirectiveUse
+	var add = func(res Resource, directives []Directive) {
+		for _, d := range directives {
+			if !ignoredFiles[p.PPkg.Fset.File(d.Pos).Name()] {
+				uses = append(uses, DirectiveUse{d, res})
+			}
+		}
+	}
+	for i := range p.SourceFiles {
+		add(nil, p.SourceFiles[i].Directives)
+	}
+	for _, tn := range p.AllTypeNames {
+		add(tn, tn.Directives())
+	}
+	for _, c := range p.AllConstants {
+		add(c, c.Directives())
+	}
+	for _, v := range p.AllVariables {
+		add(v, v.Directives())
+	}
+	for _, f := range p.AllFunctions {
+		add(f, f.Directives())
+	}
+
+	sort.SliceStable(uses, func(i, j int) bool {
+		if uses[i].Name != uses[j].Name {
+			return uses[i].Name < uses[j].Name
+		}
+		return uses[i].Pos < uses[j].Pos
+	})
+	return uses
+}
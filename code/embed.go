@@ -0,0 +1,182 @@
+package code
+
+import (
+	"go/ast"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Embedded files larger than this are not viewed as source files.
+const maxEmbedSourceFileSize = 1 << 20
+
+// EmbedPatterns returns the patterns in the //go:embed directives of the variable.
+func (v *Variable) EmbedPatterns() []string {
+	return v.embedPatterns
+}
+
+// EmbedFiles returns the files embedded in the variable. The paths are
+// slash-separated and relative to the package directory.
+func (v *Variable) EmbedFiles() []string {
+	return v.embedFiles
+}
+
+// parseEmbedDirectives returns the patterns in the //go:embed directives
+// in the doc comment of a variable specification.
+func parseEmbedDirectives(gd *ast.GenDecl, spec *ast.ValueSpec) []string {
+	doc := genDeclSpecDoc(gd, spec.Doc)
+	if doc == nil {
+		return nil
+	}
+
+	var patterns []string
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, "//go:embed ") && !strings.HasPrefix(c.Text, "//go:embed\t") {
+			continue
+		}
+		patterns = append(patterns, splitEmbedPatterns(c.Text[len("//go:embed "):])...)
+	}
+	return patterns
+}
+
+// splitEmbedPatterns splits the space-separated patterns, some of which
+// might be quoted as Go string literals.
+func splitEmbedPatterns(text string) []string {
+	var patterns []string
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		var pattern string
+		switch text[0] {
+		case '"', '`':
+			end := 1
+			for ; end < len(text) && text[end] != text[0]; end++ {
+				if text[0] == '"' && text[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(text) {
+				return patterns // invalid
+			}
+			unquoted, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return patterns
+			}
+			pattern, text = unquoted, text[end+1:]
+		default:
+			end := strings.IndexAny(text, " \t")
+			if end < 0 {
+				end = len(text)
+			}
+			pattern, text = text[:end], text[end:]
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// matchEmbedPattern reports whether or not a file (slash-separated and
+// relative to the package directory) is matched by an embed pattern.
+// If the pattern matches a parent directory of the file, the file is
+// excluded if its path under the directory contains elements starting
+// with '.' or '_', unless the pattern has the "all:" prefix.
+func matchEmbedPattern(pattern, file string) bool {
+	all := strings.HasPrefix(pattern, "all:")
+	pattern = strings.TrimPrefix(pattern, "all:")
+	if ok, _ := path.Match(pattern, file); ok {
+		return true
+	}
+	for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+		if ok, _ := path.Match(pattern, dir); !ok {
+			continue
+		}
+		if all {
+			return true
+		}
+		for _, elem := range strings.Split(file[len(dir)+1:], "/") {
+			if strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// collectEmbedFiles finds the files matched by the embed patterns of a variable.
+// PPkg.EmbedFiles is used if it is available. Otherwise, the package directory
+// is searched.
+func collectEmbedFiles(pkg *Package, patterns []string) []string {
+	if len(pkg.PPkg.GoFiles) == 0 {
+		return nil
+	}
+	dir := filepath.Dir(pkg.PPkg.GoFiles[0])
+
+	var candidates = pkg.PPkg.EmbedFiles
+	if candidates == nil {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(pattern, "all:"))))
+			for _, m := range matches {
+				filepath.WalkDir(m, func(path string, entry fs.DirEntry, err error) error {
+					if err == nil && !entry.IsDir() {
+						candidates = append(candidates, path)
+					}
+					return nil
+				})
+			}
+		}
+	}
+
+	var files []string
+	for _, f := range candidates {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range patterns {
+			if matchEmbedPattern(pattern, rel) {
+				files = append(files, rel)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	for i := len(files) - 1; i > 0; i-- {
+		if files[i] == files[i-1] {
+			files = append(files[:i], files[i+1:]...)
+		}
+	}
+	return files
+}
+
+// collectEmbedSourceFiles makes the embedded files source files of the package.
+// The bare filenames of these files are their slash-separated paths relative
+// to the package directory.
+func (d *CodeAnalyzer) collectEmbedSourceFiles(pkg *Package) {
+	if len(pkg.PPkg.GoFiles) == 0 {
+		return
+	}
+	dir := filepath.Dir(pkg.PPkg.GoFiles[0])
+	for _, v := range pkg.AllVariables {
+		for _, rel := range v.embedFiles {
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			if _, ok := d.sourceFile2PackageTable[path]; ok {
+				continue
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() > maxEmbedSourceFileSize {
+				continue
+			}
+			d.sourceFile2PackageTable[path] = pkg
+			pkg.SourceFiles = append(pkg.SourceFiles,
+				SourceFileInfo{
+					Pkg:          pkg,
+					BareFilename: rel,
+					OriginalFile: path,
+				},
+			)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package code
+
+import (
+	"go/types"
+	"sort"
+)
+
+// FieldLayout is the memory layout of a struct field.
+type FieldLayout struct {
+	Name                string // "_" for blank fields, type names for embedded fields
+	Type                types.Type
+	Offset, Size, Align int64
+
+	// The padding bytes between this field and the next one
+	// (or the end of the struct for the last field).
+	Padding int64
+}
+
+// StructLayout is the memory layout of a struct type on the target platform.
+type StructLayout struct {
+	Fields      []FieldLayout
+	Size, Align int64
+
+	// The sum of the padding bytes of all fields.
+	Padding int64
+
+	// The field order minimizing the padding bytes, as indexes of Fields.
+	// Nil if the current order is already the best one.
+	SuggestedOrder []int
+	SuggestedSize  int64
+}
+
+// StructLayout returns nil if the type name doesn't denote a struct
+// type, or the struct type is generic (so its sizes are unknown),
+// or the package declaring the type has errors.
+func (d *CodeAnalyzer) StructLayout(tn *TypeName) *StructLayout {
+	if tn.Alias != nil || tn.Pkg.IllTyped() {
+		return nil
+	}
+	named, ok := tn.TypeName.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return nil
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	sizes := tn.Pkg.PPkg.TypesSizes
+	if sizes == nil {
+		sizes = types.SizesFor("gc", d.targetPlatform.GOARCH)
+		if sizes == nil {
+			return nil
+		}
+	}
+
+	vars := make([]*types.Var, st.NumFields())
+	for i := range vars {
+		vars[i] = st.Field(i)
+	}
+	offsets := sizes.Offsetsof(vars)
+
+	layout := &StructLayout{
+		Fields: make([]FieldLayout, len(vars)),
+		Size:   sizes.Sizeof(st),
+		Align:  sizes.Alignof(st),
+	}
+	for i, v := range vars {
+		layout.Fields[i] = FieldLayout{
+			Name:   v.Name(),
+			Type:   v.Type(),
+			Offset: offsets[i],
+			Size:   sizes.Sizeof(v.Type()),
+			Align:  sizes.Alignof(v.Type()),
+		}
+	}
+	for i := range layout.Fields {
+		f := &layout.Fields[i]
+		end := layout.Size
+		if i+1 < len(layout.Fields) {
+			end = layout.Fields[i+1].Offset
+		}
+		f.Padding = end - f.Offset - f.Size
+		layout.Padding += f.Padding
+	}
+
+	if layout.Padding > 0 {
+		// Zero-size fields go first, for a trailing zero-size field needs
+		// padding. The others are sorted by alignments, from large to small.
+		order := make([]int, len(vars))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := &layout.Fields[order[i]], &layout.Fields[order[j]]
+			if (a.Size == 0) != (b.Size == 0) {
+				return a.Size == 0
+			}
+			return a.Align > b.Align
+		})
+		reordered := make([]*types.Var, len(vars))
+		for i, k := range order {
+			reordered[i] = vars[k]
+		}
+		if size := sizes.Sizeof(types.NewStruct(reordered, nil)); size < layout.Size {
+			layout.SuggestedOrder = order
+			layout.SuggestedSize = size
+		}
+	}
+
+	return layout
+}
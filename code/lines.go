@@ -0,0 +1,113 @@
+package code
+
+import (
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"log"
+)
+
+// LineStats holds the line counts of a Go source file or a package.
+type LineStats struct {
+	Lines        int32 // all lines, including blank and comment ones
+	BlankLines   int32
+	CommentLines int32 // the lines containing only comments
+}
+
+// CodeLines returns the number of the lines containing code.
+func (s LineStats) CodeLines() int32 {
+	return s.Lines - s.BlankLines - s.CommentLines
+}
+
+func (s *LineStats) add(other LineStats) {
+	s.Lines += other.Lines
+	s.BlankLines += other.BlankLines
+	s.CommentLines += other.CommentLines
+}
+
+// The bucket sizes used in the Stats.PackagesByCodeLines
+// and Stats.FilesByCodeLines statistics.
+const (
+	PackageCodeLinesStep = 200
+	FileCodeLinesStep    = 50
+)
+
+// countLines counts the lines of Go source code. A line is viewed as
+// a code line if it is a part of a non-comment token, which might span
+// several lines (raw string literals).
+func countLines(content []byte) (stats LineStats) {
+	if len(content) == 0 {
+		return
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(content))
+	file.SetLinesForContent(content)
+	var s scanner.Scanner
+	s.Init(file, content, nil, scanner.ScanComments)
+
+	// 0: blank, 1: comment, 2: code.
+	var kinds = make([]byte, file.LineCount()+1)
+	var mark = func(from, to token.Pos, kind byte) {
+		for line := file.Line(from); line <= file.Line(to); line++ {
+			if kinds[line] < kind {
+				kinds[line] = kind
+			}
+		}
+	}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		switch {
+		case tok == token.SEMICOLON && lit == "\n": // automatically inserted
+		case tok == token.COMMENT:
+			mark(pos, pos+token.Pos(len(lit)-1), 1)
+		case lit != "":
+			mark(pos, pos+token.Pos(len(lit)-1), 2)
+		default:
+			mark(pos, pos, 2)
+		}
+	}
+
+	stats.Lines = int32(file.LineCount())
+	for _, k := range kinds[1 : stats.Lines+1] {
+		switch k {
+		case 0:
+			stats.BlankLines++
+		case 1:
+			stats.CommentLines++
+		}
+	}
+	return
+}
+
+// collectLineStats counts the lines of the Go source files of a package.
+// It must be called after the source files are cached.
+func (d *CodeAnalyzer) collectLineStats(pkg *Package) {
+	pkg.Lines = LineStats{}
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		if info.AstFile == nil {
+			continue
+		}
+		content := info.Content
+		// For cgo files, the cached content is the one of the generated file.
+		if info.OriginalFile != "" && info.GeneratedFile != info.OriginalFile {
+			var err error
+			if content, err = ioutil.ReadFile(info.OriginalFile); err != nil {
+				log.Printf("ReadFile (%s) error: %s", info.OriginalFile, err)
+				continue
+			}
+		}
+		info.Lines = countLines(content)
+		pkg.Lines.add(info.Lines)
+
+		pkg.stats.CodeLines += info.Lines.Lines
+		pkg.stats.BlankCodeLines += info.Lines.BlankLines
+		pkg.stats.CommentCodeLines += info.Lines.CommentLines
+		incSliceStat(pkg.stats.FilesByCodeLines[:], int(info.Lines.CodeLines()/FileCodeLinesStep))
+	}
+	incSliceStat(pkg.stats.PackagesByCodeLines[:], int(pkg.Lines.CodeLines()/PackageCodeLinesStep))
+}
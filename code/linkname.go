@@ -0,0 +1,100 @@
+package code
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// A linknameDirective is a "//go:linkname localname importpath.name"
+// directive. The one-argument form is ignored.
+type linknameDirective struct {
+	local  string
+	target string // importpath.name
+}
+
+// LinkedTo returns the function which the function is linked to
+// by a //go:linkname directive for the function.
+func (f *Function) LinkedTo() *Function {
+	return f.linkedTo
+}
+
+// LinkedFroms returns the functions which are linked to the function
+// by //go:linkname directives.
+func (f *Function) LinkedFroms() []*Function {
+	return f.linkedFroms
+}
+
+// collectLinknameDirectives finds the //go:linkname directives in an ast file.
+func collectLinknameDirectives(file *ast.File) []linknameDirective {
+	var directives []linknameDirective
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if !strings.HasPrefix(c.Text, "//go:linkname ") {
+				continue
+			}
+			args := strings.Fields(c.Text[len("//go:linkname "):])
+			if len(args) != 2 {
+				continue
+			}
+			directives = append(directives, linknameDirective{args[0], args[1]})
+		}
+	}
+	return directives
+}
+
+// splitLinknameTarget splits "importpath.name" into the import path and
+// the name. The returned name is blank for method targets, such as
+// "runtime.(*T).m", which are not supported now.
+func splitLinknameTarget(target string) (pkgPath, name string) {
+	i := strings.LastIndexByte(target, '.')
+	if i <= 0 || i == len(target)-1 {
+		return "", ""
+	}
+	pkgPath, name = target[:i], target[i+1:]
+	if strings.ContainsAny(pkgPath, "()*") {
+		return "", ""
+	}
+	return
+}
+
+// resolveLinknames links the functions connected by //go:linkname directives.
+// It must be called after the declarations of all packages are collected.
+func (d *CodeAnalyzer) resolveLinknames() {
+	var funcsByPkg = make(map[*Package]map[string]*Function)
+	var lookup = func(pkg *Package, name string) *Function {
+		funcs, ok := funcsByPkg[pkg]
+		if !ok {
+			funcs = make(map[string]*Function, len(pkg.AllFunctions))
+			for _, f := range pkg.AllFunctions {
+				if !f.IsMethod() {
+					funcs[f.Name()] = f
+				}
+			}
+			funcsByPkg[pkg] = funcs
+		}
+		return funcs[name]
+	}
+
+	for _, pkg := range d.packageList {
+		for _, ld := range pkg.linknames {
+			local := lookup(pkg, ld.local)
+			if local == nil || local.linkedTo != nil {
+				continue // variables are not supported now
+			}
+			pkgPath, name := splitLinknameTarget(ld.target)
+			if name == "" {
+				continue
+			}
+			targetPkg := d.PackageByPath(pkgPath)
+			if targetPkg == nil {
+				continue
+			}
+			target := lookup(targetPkg, name)
+			if target == nil || target == local {
+				continue
+			}
+			local.linkedTo = target
+			target.linkedFroms = append(target.linkedFroms, local)
+		}
+	}
+}
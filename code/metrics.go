@@ -0,0 +1,128 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// FunctionMetrics holds the code-health metrics of a function or method.
+type FunctionMetrics struct {
+	Statements int32 // blocks and case clauses are not counted
+	Lines      int32 // from the "func" keyword to the closing brace
+	Complexity int32 // cyclomatic complexity
+	MaxNesting int32 // the max nesting depth of control flow statements
+	Params     int32
+	Results    int32
+	Returns    int32 // not including the ones in function literals
+}
+
+// Metrics returns the metrics of the function. The second result is false
+// if the function has no declaration or no body (for example, builtin
+// functions and the ones implemented in assembly).
+func (f *Function) Metrics() (FunctionMetrics, bool) {
+	return f.metrics, f.AstDecl != nil && f.AstDecl.Body != nil
+}
+
+func (d *CodeAnalyzer) collectFunctionMetrics(pkg *Package) {
+	for _, f := range pkg.AllFunctions {
+		if f.AstDecl == nil || f.AstDecl.Body == nil {
+			continue
+		}
+		f.metrics = computeFunctionMetrics(pkg.PPkg.Fset, f.AstDecl)
+		if sig, ok := f.TType().(*types.Signature); ok {
+			f.metrics.Params = int32(sig.Params().Len())
+			f.metrics.Results = int32(sig.Results().Len())
+		}
+	}
+}
+
+func computeFunctionMetrics(fset *token.FileSet, decl *ast.FuncDecl) FunctionMetrics {
+	var m = FunctionMetrics{Complexity: 1}
+	m.Lines = int32(fset.PositionFor(decl.End(), false).Line - fset.PositionFor(decl.Pos(), false).Line + 1)
+
+	// The stack of the visited nodes and the nesting depths at them.
+	type visited struct {
+		node  ast.Node
+		depth int32
+	}
+	var stack = []visited{{decl, 0}}
+	var funcLits int
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if n == nil {
+			if _, ok := stack[len(stack)-1].node.(*ast.FuncLit); ok {
+				funcLits--
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		parent := stack[len(stack)-1]
+		depth := parent.depth
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			m.Complexity++
+			// "else if" doesn't increase nesting depths.
+			if p, ok := parent.node.(*ast.IfStmt); !ok || p.Else != n {
+				depth++
+			}
+		case *ast.ForStmt, *ast.RangeStmt:
+			m.Complexity++
+			depth++
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			depth++
+		case *ast.CaseClause:
+			if n.List != nil {
+				m.Complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				m.Complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				m.Complexity++
+			}
+		case *ast.ReturnStmt:
+			if funcLits == 0 {
+				m.Returns++
+			}
+		case *ast.FuncLit:
+			funcLits++
+		}
+		if depth > m.MaxNesting {
+			m.MaxNesting = depth
+		}
+
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.LabeledStmt, *ast.EmptyStmt:
+		case ast.Stmt:
+			m.Statements++
+		}
+
+		stack = append(stack, visited{n, depth})
+		return true
+	})
+	return m
+}
+
+// MostComplexFunctions returns at most n functions (including methods)
+// declared in the specified packages, sorted by cyclomatic complexities.
+func (d *CodeAnalyzer) MostComplexFunctions(pkgs []*Package, n int) []*Function {
+	var funcs []*Function
+	for _, pkg := range pkgs {
+		for _, f := range pkg.AllFunctions {
+			if _, ok := f.Metrics(); ok {
+				funcs = append(funcs, f)
+			}
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return funcs[i].metrics.Complexity > funcs[j].metrics.Complexity
+	})
+	if len(funcs) > n {
+		funcs = funcs[:n]
+	}
+	return funcs
+}
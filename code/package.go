@@ -7,14 +7,47 @@ import (
 	"log"
 	"reflect"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/packages"
 )
 
 type Module struct {
-	Dir     string
-	Root    string // root import path
-	Version string
+	Dir       string
+	Root      string // root import path
+	Version   string
+	GoVersion string // the go directive in go.mod
+	IsMain    bool
+
+	// The replacement of the module. Blank if the module is not replaced.
+	Replacement ModuleVersion
+
+	Pkgs []*Package // sorted by paths
+
+	// The requires and replaces directives in go.mod.
+	Requires []ModuleRequire
+	Replaces []ModuleReplace
+
+	RequiredBys []*Module // sorted by paths
+}
+
+type ModuleVersion struct {
+	Path    string
+	Version string // might be blank
+}
+
+type ModuleRequire struct {
+	ModuleVersion
+	Indirect bool
+
+	// Nil if none packages of the required module are involved.
+	Module *Module
+}
+
+type ModuleReplace struct {
+	Old ModuleVersion
+	New ModuleVersion // New.Path might be a local directory
 }
 
 type Package struct {
@@ -26,6 +59,26 @@ type Package struct {
 	DepLevel int // 0 means the level is not determined yet
 	DepedBys []*Package
 
+	// The platforms having the source files (by bare filenames) and the
+	// package-level declarations. Only set in the multi-platform mode.
+	platformFiles     map[string]PlatformSet
+	platformDecls     map[string]PlatformSet
+	platformDeclKinds map[string]token.Token
+
+	// The errors encountered when loading, parsing and type-checking
+	// the package, sorted by positions. Ill-typed packages are still
+	// analyzed, with the partial type information.
+	Errors []PackageError
+
+	// The statistics of the package.
+	stats Stats
+
+	// The functions implemented in the assembly files of the package.
+	asmFuncs []*AsmFunction
+
+	// The //go:linkname directives in the package.
+	linknames []linknameDirective
+
 	// This field might be shared with PackageForDisplay
 	// for concurrent reads.
 	*PackageAnalyzeResult
@@ -35,6 +88,32 @@ func (p *Package) Path() string {
 	return p.PPkg.PkgPath // might be prefixed with "vendor/", which is different from import path.
 }
 
+// Statistics returns the statistics of the package.
+func (p *Package) Statistics() Stats {
+	return p.stats
+}
+
+// IllTyped reports whether or not the package has errors.
+func (p *Package) IllTyped() bool {
+	return len(p.Errors) > 0
+}
+
+// PackageError is an error of a package.
+type PackageError struct {
+	// Position.Filename is blank if the position is unknown.
+	// Position.Column might be zero.
+	Position token.Position
+	Msg      string
+	Kind     packages.ErrorKind
+}
+
+func (e PackageError) String() string {
+	if e.Position.Filename == "" {
+		return e.Msg
+	}
+	return e.Position.String() + ": " + e.Msg
+}
+
 type PackageAnalyzeResult struct {
 	AllTypeNames []*TypeName
 	AllFunctions []*Function
@@ -43,6 +122,9 @@ type PackageAnalyzeResult struct {
 	AllImports   []*Import
 	SourceFiles  []SourceFileInfo
 	Directory    string
+
+	// The line counts of the Go source files.
+	Lines LineStats
 }
 
 func NewPackageAnalyzeResult() *PackageAnalyzeResult {
@@ -105,6 +187,7 @@ type Resource interface {
 	//IndexString() string
 	Documentation() string
 	Comment() string
+	Deprecated() string // the deprecation notice
 	Position() token.Position
 	Package() *Package
 }
@@ -118,7 +201,7 @@ type ValueResource interface {
 type FunctionResource interface {
 	ValueResource
 	IsMethod() bool
-	ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar bool)
+	ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar, ok bool)
 	AstFuncType() *ast.FuncType
 
 	// For *Function, the result is the same as ValueResource.Package().
@@ -346,6 +429,13 @@ type TypeInfo struct {
 	// For named and basic types.
 	TypeName *TypeName
 
+	// For instantiated types only. The generic type they are instantiated from.
+	Origin *TypeInfo
+
+	// For generic types only. The instantiated types seen in code,
+	// excluding the ones whose type arguments involve type parameters.
+	Instantiations []*TypeInfo
+
 	// For unnamed types.
 	UsePositions []token.Position
 
@@ -554,6 +644,9 @@ type Variable struct {
 	Pkg     *Package // some duplicated with types.Var.Pkg()
 	AstDecl *ast.GenDecl
 	AstSpec *ast.ValueSpec
+
+	embedPatterns []string
+	embedFiles    []string
 }
 
 func (v *Variable) Position() token.Position {
@@ -631,6 +724,13 @@ type Function struct {
 	Type    *TypeInfo
 	Pkg     *Package // some duplicated with types.Func.Pkg(), except builtin functions
 	AstDecl *ast.FuncDecl
+
+	metrics FunctionMetrics
+
+	asmImpls []*AsmFunction
+
+	linkedTo    *Function
+	linkedFroms []*Function
 }
 
 func (f *Function) Name() string {
@@ -701,30 +801,37 @@ func (f *Function) String() string {
 //}
 
 // Please make sure the Funciton is a method when calling this method.
-func (f *Function) ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar bool) {
+// ok is false if the receiver is invalid (only possible in ill-typed
+// packages), such as **T and []int.
+func (f *Function) ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar, ok bool) {
 	if f.AstDecl.Recv == nil {
 		panic("should not")
 	}
 	if len(f.AstDecl.Recv.List) != 1 {
-		panic("should not")
+		return nil, nil, false, false
 	}
 
 	paramField = f.AstDecl.Recv.List[0]
-	switch expr := paramField.Type.(type) {
-	default:
-		panic("should not")
-	case *ast.Ident:
-		typeIdent = expr
-		isStar = false
-		return
-	case *ast.StarExpr:
-		tid, ok := expr.X.(*ast.Ident)
-		if !ok {
-			panic("should not")
+	for typeExpr := paramField.Type; ; {
+		switch expr := typeExpr.(type) {
+		default:
+			return nil, nil, false, false
+		case *ast.Ident:
+			typeIdent = expr
+			return paramField, typeIdent, isStar, true
+		case *ast.ParenExpr:
+			typeExpr = expr.X
+		case *ast.StarExpr:
+			if isStar {
+				return nil, nil, false, false
+			}
+			typeExpr = expr.X
+			isStar = true
+		case *ast.IndexExpr: // T[P]
+			typeExpr = expr.X
+		case *ast.IndexListExpr: // T[P1, P2]
+			typeExpr = expr.X
 		}
-		typeIdent = tid
-		isStar = true
-		return
 	}
 }
 
@@ -736,6 +843,62 @@ func (f *Function) AstPackage() *Package {
 	return f.Package()
 }
 
+const (
+	TestFunc_None = iota
+	TestFunc_Test
+	TestFunc_Benchmark
+	TestFunc_Fuzz
+	TestFunc_Example
+)
+
+// TestFuncKind reports whether or not f is a function run by "go test".
+// It must be a package-level function declared in a _test.go file.
+func (f *Function) TestFuncKind() int {
+	if f.Func == nil || f.IsMethod() || !strings.HasSuffix(f.Position().Filename, "_test.go") {
+		return TestFunc_None
+	}
+
+	var isTest = func(name, prefix string) bool {
+		if !strings.HasPrefix(name, prefix) {
+			return false
+		}
+		if len(name) == len(prefix) {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+		return !unicode.IsLower(r)
+	}
+
+	var hasTestingParam = func(sig *types.Signature, typeName string) bool {
+		if sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+			return false
+		}
+		ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
+		if !ok {
+			return false
+		}
+		named, ok := ptr.Elem().(*types.Named)
+		if !ok {
+			return false
+		}
+		obj := named.Obj()
+		return obj.Pkg() != nil && obj.Pkg().Path() == "testing" && obj.Name() == typeName
+	}
+
+	name, sig := f.Name(), f.Func.Type().(*types.Signature)
+	switch {
+	case isTest(name, "Test") && hasTestingParam(sig, "T"):
+		return TestFunc_Test
+	case isTest(name, "Benchmark") && hasTestingParam(sig, "B"):
+		return TestFunc_Benchmark
+	case isTest(name, "Fuzz") && hasTestingParam(sig, "F"):
+		return TestFunc_Fuzz
+	case isTest(name, "Example") && sig.Params().Len() == 0 && sig.Results().Len() == 0:
+		return TestFunc_Example
+	}
+	return TestFunc_None
+}
+
 // As Function.
 type InterfaceMethod struct {
 	InterfaceTypeName *TypeName
@@ -795,8 +958,8 @@ func (im *InterfaceMethod) String() string {
 //}
 
 // Please make sure the Funciton is a method when calling this method.
-func (im *InterfaceMethod) ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar bool) {
-	return nil, im.InterfaceTypeName.AstSpec.Name, false
+func (im *InterfaceMethod) ReceiverTypeName() (paramField *ast.Field, typeIdent *ast.Ident, isStar, ok bool) {
+	return nil, im.InterfaceTypeName.AstSpec.Name, false, true
 }
 
 func (im *InterfaceMethod) AstFuncType() *ast.FuncType {
@@ -0,0 +1,316 @@
+package code
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Platform is a target GOOS/GOARCH pair.
+type Platform struct {
+	GOOS, GOARCH string
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// ParsePlatforms parses a comma-separated platform list,
+// such as "linux/amd64,windows/amd64,darwin/arm64".
+func ParsePlatforms(list string) ([]Platform, error) {
+	var platforms []Platform
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		i := strings.IndexByte(s, '/')
+		if i <= 0 || i == len(s)-1 || strings.IndexByte(s[i+1:], '/') >= 0 {
+			return nil, fmt.Errorf("invalid platform %q, the form should be GOOS/GOARCH", s)
+		}
+		platforms = append(platforms, Platform{GOOS: s[:i], GOARCH: s[i+1:]})
+	}
+	return platforms, nil
+}
+
+// PlatformSet is a bit set of the indexes of the analyzed platforms.
+type PlatformSet uint64
+
+// MaxPlatforms is the maximum number of platforms in the multi-platform mode.
+const MaxPlatforms = 64
+
+// primaryPlatform returns the target platform of the full analysis.
+func (o *ParseOptions) primaryPlatform() Platform {
+	p := Platform{GOOS: o.GOOS, GOARCH: o.GOARCH}
+	if p.GOOS == "" {
+		p.GOOS = os.Getenv("GOOS")
+		if p.GOOS == "" {
+			p.GOOS = runtime.GOOS
+		}
+	}
+	if p.GOARCH == "" {
+		p.GOARCH = os.Getenv("GOARCH")
+		if p.GOARCH == "" {
+			p.GOARCH = runtime.GOARCH
+		}
+	}
+	return p
+}
+
+// configure sets the environment and build flags of a packages.Config
+// for the specified platform.
+func (o *ParseOptions) configure(config *packages.Config, platform Platform) {
+	config.Env = append(os.Environ(), "GOOS="+platform.GOOS, "GOARCH="+platform.GOARCH)
+	config.Dir = o.Dir
+	if len(o.Tags) > 0 {
+		config.BuildFlags = []string{"-tags=" + strings.Join(o.Tags, ",")}
+	}
+}
+
+// TargetPlatform returns the platform on which packages are fully analyzed.
+func (d *CodeAnalyzer) TargetPlatform() Platform {
+	return d.targetPlatform
+}
+
+// Platforms returns the analyzed platforms. The first one is the platform
+// on which packages are fully analyzed. For the others, only which source
+// files and which package-level declarations they have are collected.
+// Nil is returned if not in the multi-platform mode.
+func (d *CodeAnalyzer) Platforms() []Platform {
+	return d.platforms
+}
+
+// PlatformsOf returns the platforms in the specified set.
+func (d *CodeAnalyzer) PlatformsOf(set PlatformSet) []Platform {
+	var platforms []Platform
+	for i, p := range d.platforms {
+		if set&(1<<i) != 0 {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms
+}
+
+// FilePlatforms returns the platforms having the specified source file
+// of a package. A zero set is returned if the file is on all platforms.
+func (d *CodeAnalyzer) FilePlatforms(pkg *Package, bareFilename string) PlatformSet {
+	return d.partialPlatformSet(pkg.platformFiles[bareFilename])
+}
+
+// DeclarationPlatforms returns the platforms having the specified
+// package-level declaration, in the form "Name" or "Type.Method".
+// A zero set is returned if the declaration is on all platforms.
+func (d *CodeAnalyzer) DeclarationPlatforms(pkg *Package, name string) PlatformSet {
+	return d.partialPlatformSet(pkg.platformDecls[name])
+}
+
+// PlatformDeclaration is a package-level declaration which is only
+// on some of the non-primary platforms, so it is not analyzed.
+type PlatformDeclaration struct {
+	Kind      token.Token // CONST, VAR, TYPE or FUNC
+	Name      string      // in the form "Type.Method" for methods
+	Platforms PlatformSet
+}
+
+// OtherPlatformDeclarations returns the package-level declarations
+// of a package which are not on the primary platform, sorted by names.
+func (d *CodeAnalyzer) OtherPlatformDeclarations(pkg *Package) []PlatformDeclaration {
+	var decls []PlatformDeclaration
+	for name, set := range pkg.platformDecls {
+		if set&1 == 0 {
+			decls = append(decls, PlatformDeclaration{
+				Kind:      pkg.platformDeclKinds[name],
+				Name:      name,
+				Platforms: set,
+			})
+		}
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].Name < decls[j].Name
+	})
+	return decls
+}
+
+// OtherPlatformFiles returns the bare names of the source files
+// of a package which are not on the primary platform, sorted.
+func (d *CodeAnalyzer) OtherPlatformFiles(pkg *Package) []string {
+	var files []string
+	for name, set := range pkg.platformFiles {
+		if set&1 == 0 {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (d *CodeAnalyzer) partialPlatformSet(set PlatformSet) PlatformSet {
+	if all := PlatformSet(1)<<len(d.platforms) - 1; set == all {
+		return 0
+	}
+	return set
+}
+
+// collectPlatformInfo loads the packages for the other platforms and
+// records which source files and declarations every platform has.
+//
+// Fully analyzing packages for every platform and merging the results
+// would multiply the memory use, and types from different platforms can't
+// be compared. So the other platforms are only used to annotate the
+// declarations and files from the fully analyzed one.
+func (d *CodeAnalyzer) collectPlatformInfo(options *ParseOptions, args []string) {
+	platforms := []Platform{options.primaryPlatform()}
+	for _, p := range options.Platforms {
+		if p != platforms[0] {
+			platforms = append(platforms, p)
+		}
+	}
+	if len(platforms) > MaxPlatforms {
+		log.Printf("too many platforms, only the first %d ones are analyzed", MaxPlatforms)
+		platforms = platforms[:MaxPlatforms]
+	}
+
+	fset := token.NewFileSet()
+	fileDecls := make(map[string][]declarationName, 1024)
+	declsOfFile := func(filename string) []declarationName {
+		if decls, ok := fileDecls[filename]; ok {
+			return decls
+		}
+		astFile, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		var decls []declarationName
+		if astFile != nil {
+			decls = declarationNames(astFile)
+		} else {
+			log.Println("parse file for platform info:", err)
+		}
+		fileDecls[filename] = decls
+		return decls
+	}
+
+	register := func(pkg *Package, files []string, bit PlatformSet) {
+		if pkg.platformFiles == nil {
+			pkg.platformFiles = make(map[string]PlatformSet, len(files))
+			pkg.platformDecls = make(map[string]PlatformSet, 64)
+			pkg.platformDeclKinds = make(map[string]token.Token, 64)
+		}
+		for _, filename := range files {
+			// Test files are only collected for the primary platform.
+			if strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			pkg.platformFiles[filepath.Base(filename)] |= bit
+			for _, decl := range declsOfFile(filename) {
+				pkg.platformDecls[decl.name] |= bit
+				pkg.platformDeclKinds[decl.name] = decl.kind
+			}
+		}
+	}
+
+	for _, pkg := range d.packageList {
+		register(pkg, pkg.PPkg.GoFiles, 1)
+	}
+
+	d.platforms = platforms[:1]
+	for _, platform := range platforms[1:] {
+		config := &packages.Config{
+			Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps,
+		}
+		options.configure(config, platform)
+		ppkgs, err := packages.Load(config, args...)
+		if err != nil {
+			log.Printf("packages.Load (platform %s): %s", platform, err)
+			continue
+		}
+
+		bit := PlatformSet(1) << len(d.platforms)
+		d.platforms = append(d.platforms, platform)
+		for path, ppkg := range collectPPackages(ppkgs) {
+			if pkg := d.packageTable[path]; pkg != nil {
+				register(pkg, ppkg.GoFiles, bit)
+			}
+		}
+	}
+}
+
+type declarationName struct {
+	name string
+	kind token.Token
+}
+
+// declarationNames returns the names of the package-level
+// declarations in a file. Methods are named as "Type.Method".
+func declarationNames(astFile *ast.File) []declarationName {
+	var names []declarationName
+	var add = func(ident *ast.Ident, kind token.Token) {
+		if ident.Name != "_" {
+			names = append(names, declarationName{ident.Name, kind})
+		}
+	}
+	for _, decl := range astFile.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name, token.TYPE)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						add(name, decl.Tok)
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				if decl.Name.Name != "init" {
+					add(decl.Name, token.FUNC)
+				}
+				continue
+			}
+			if recv := receiverTypeName(decl.Recv.List[0].Type); recv != "" {
+				names = append(names, declarationName{recv + "." + decl.Name.Name, token.FUNC})
+			}
+		}
+	}
+	return names
+}
+
+// receiverTypeName returns the base type name of a receiver type expression,
+// such as T, *T, T[K, V] and *T[K, V].
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e.Name
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		default:
+			return ""
+		}
+	}
+}
+
+// MethodPlatforms returns the platforms having the specified declared method.
+// A zero set is returned if the method is on all platforms or it is not
+// declared with a function declaration (an interface method, for example).
+func (d *CodeAnalyzer) MethodPlatforms(m *Method) PlatformSet {
+	if m.AstFunc == nil || m.AstFunc.Recv == nil || len(m.AstFunc.Recv.List) == 0 {
+		return 0
+	}
+	recv := receiverTypeName(m.AstFunc.Recv.List[0].Type)
+	return d.DeclarationPlatforms(m.Pkg, recv+"."+m.Name)
+}
@@ -1,13 +1,15 @@
 package code
 
 import (
-	"bytes"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -45,7 +47,7 @@ func (d *CodeAnalyzer) CollectSourceFiles() {
 
 		for _, path := range pkg.PPkg.OtherFiles {
 			d.sourceFile2PackageTable[path] = pkg
-			d.stats.FilesWithoutGenerateds++
+			pkg.stats.FilesWithoutGenerateds++
 		}
 
 		for _, path := range pkg.PPkg.CompiledGoFiles {
@@ -57,12 +59,14 @@ func (d *CodeAnalyzer) CollectSourceFiles() {
 				//log.Println("! in GoFiles but not CompiledGoFiles:", path)
 				d.sourceFile2PackageTable[path] = pkg
 			}
-			d.stats.FilesWithoutGenerateds++
+			pkg.stats.FilesWithoutGenerateds++
 		}
 
 		d.BuildCgoFileMappings(pkg)
+		d.collectAssemblyFiles(pkg)
+		d.collectEmbedSourceFiles(pkg)
 
-		//d.stats.Files += int32(len(pkg.SourceFiles))
+		//pkg.stats.Files += int32(len(pkg.SourceFiles))
 	}
 }
 
@@ -85,6 +89,13 @@ type SourceFileInfo struct {
 
 	// The followings are blank for most files.
 	GeneratedFile string
+
+	// The //line directives in the file, in source order.
+	LineDirectives []LineDirective
+
+	// The directives not in the doc comments of declarations,
+	// such as //go:build and //go:generate directives.
+	Directives []Directive
 	//GoFileContentOffset int32
 	//GoFileLineOffset    int32
 
@@ -95,6 +106,9 @@ type SourceFileInfo struct {
 
 	// ...
 	Content []byte
+
+	// Only set for Go files.
+	Lines LineStats
 }
 
 func (info *SourceFileInfo) AstBareFileName() string {
@@ -104,80 +118,118 @@ func (info *SourceFileInfo) AstBareFileName() string {
 	return info.BareFilename
 }
 
-// ToDo: find a better way to detect generated files.
-var cgoGenIdent = []byte(`// Code generated by cmd/cgo; DO NOT EDIT.`)
-var reposIdent = []byte(`//line `)
-
-// https://github.com/golang/go/issues/24183
-// https://github.com/golang/go/issues/26207
-// https://github.com/golang/go/issues/36072
-// The fucntion is not robust enough to handle all kinds of special cases.
-// 1. It doesn't consider /*line file:m:n*/ form.
-// 2. It doesn't handle multiple "//line ..." occurences.
-// 3. It should ignore the general content enclosed in other comments.
-//    /*
-//    //line file:m:n
-//    */
-// Maybe it is best to check the comments nodes in the already provided ast.File.
-func cgoFileInfo(pkg *Package, filename string, astFile *ast.File) *SourceFileInfo {
-	fileContent, err := ioutil.ReadFile(filename)
-	if err != nil {
-		log.Println("cgoFileInfo ReadFile error:", err)
-		return nil
+// A LineDirective is a "//line file:m[:n]" or "/*line file:m[:n]*/"
+// directive in a Go source file.
+type LineDirective struct {
+	// The position from which the directive takes effect. For the
+	// "//line" form, it is the start of the next line. For the
+	// "/*line*/" form, it is the position just after the comment.
+	Pos token.Pos
+
+	// The original position which Pos is mapped to.
+	Original token.Position
+}
+
+// OriginalPosition returns the position (in the original file) which the
+// specified position in the file is generated from. The argument position
+// is returned if it is not affected by any //line directives.
+func (info *SourceFileInfo) OriginalPosition(p token.Position) token.Position {
+	if len(info.LineDirectives) == 0 || info.AstFile == nil || !p.IsValid() {
+		return p
+	}
+	file := info.Pkg.PPkg.Fset.File(info.AstFile.Pos())
+	if file == nil || p.Offset < 0 || p.Offset > file.Size() {
+		return p
 	}
+	return file.PositionFor(file.Pos(p.Offset), true)
+}
 
-	// ToDo: the current implement strongly depends on the cgo file genenration implementation.
-	//       Here it is assumed that the "// Code generated by cmd/cgo; DO NOT EDIT." is the 4th line.
-	if !bytes.HasPrefix(fileContent, cgoGenIdent) {
-		return &SourceFileInfo{
-			Pkg:                   pkg,
-			BareGeneratedFilename: filepath.Base(filename),
-			OriginalFile:          "",
-			GeneratedFile:         filename,
-			AstFile:               astFile,
-		}
+// collectLineDirectives finds the line directives in an ast file.
+// The parser has already recorded the directives in the token.File,
+// so here the comment nodes are only used to locate the directives
+// (which makes the directive-like texts in other comments ignored),
+// and the original positions are got from the token.File. This makes
+// the results consistent with the ones got by the compiler.
+// Invalid directives (which are ignored by the parser) are ignored.
+func collectLineDirectives(fset *token.FileSet, astFile *ast.File) []LineDirective {
+	file := fset.File(astFile.Pos())
+	if file == nil {
+		return nil
 	}
 
-	for lineOffset, data := 1, fileContent; len(data) > 0; lineOffset++ {
-		i := bytes.IndexByte(data, '\n')
-		k := i
-		if k < 0 {
-			k = len(data)
-		}
-		if k > 0 && data[k-1] == '\r' {
-			k--
-		}
-		for bytes.HasPrefix(data[:k], reposIdent) {
-			line := bytes.TrimSpace(data[len(reposIdent):k])
-			indexB := bytes.LastIndexByte(line, ':')
-			if indexB < 0 {
-				break
+	var directives []LineDirective
+	for _, cg := range astFile.Comments {
+		for _, c := range cg.List {
+			var pos token.Pos
+			var text string
+			switch {
+			case strings.HasPrefix(c.Text, "//line "):
+				// Only the ones starting at line starts are recognized.
+				cp := file.PositionFor(c.Slash, false)
+				if cp.Column != 1 || cp.Line >= file.LineCount() {
+					continue
+				}
+				pos = file.LineStart(cp.Line + 1)
+				text = c.Text[len("//line "):]
+			case strings.HasPrefix(c.Text, "/*line "):
+				pos = c.End()
+				text = c.Text[len("/*line ") : len(c.Text)-len("*/")]
+			default:
+				continue
 			}
-
-			// Assume the colume offset is 1.
-			var goFilename string
-			if indexA := bytes.LastIndexByte(line[:indexB], ':'); indexA >= 0 {
-				goFilename = string(line[:indexA])
-			} else {
-				goFilename = string(line[:indexB])
+			original := file.PositionFor(pos, true)
+			if original.Line != lineDirectiveLine(text) {
+				continue // ignored by the parser
 			}
+			directives = append(directives, LineDirective{Pos: pos, Original: original})
+		}
+	}
+	return directives
+}
 
-			return &SourceFileInfo{
-				Pkg:           pkg,
-				BareFilename:  filepath.Base(goFilename),
-				OriginalFile:  goFilename,
-				GeneratedFile: filename,
-				AstFile:       astFile,
-				//: int32(len(fileContent) - len(data[i+1:])),
-				//GoFileLineOffset:    int32(lineOffset),
-			}
+// lineDirectiveLine returns the line number in the "file:line[:column]"
+// text of a line directive. It returns 0 for an invalid text.
+func lineDirectiveLine(text string) int {
+	var trailingNumber = func(s string) (rest string, n int) {
+		i := strings.LastIndexByte(s, ':')
+		if i < 0 {
+			return s, 0
 		}
-		if i >= 0 {
-			data = data[i+1:]
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil || n <= 0 {
+			return s, 0
 		}
+		return s[:i], n
+	}
+	text = strings.TrimRight(text, " \t\r")
+	rest, n := trailingNumber(text)
+	if n == 0 {
+		return 0
 	}
+	if _, line := trailingNumber(rest); line > 0 {
+		return line // the "file:line:column" form
+	}
+	return n
+}
 
-	return nil
+// generatedFileInfo returns the info of a Go file generated by cgo.
+// The original file is the one the first line directive maps to.
+// It might be blank, which means the file is generated from scratch.
+func generatedFileInfo(pkg *Package, filename string, astFile *ast.File) *SourceFileInfo {
+	info := &SourceFileInfo{
+		Pkg:                   pkg,
+		BareGeneratedFilename: filepath.Base(filename),
+		GeneratedFile:         filename,
+		AstFile:               astFile,
+		LineDirectives:        collectLineDirectives(pkg.PPkg.Fset, astFile),
+		Directives:            collectFileDirectives(astFile),
+	}
+	if len(info.LineDirectives) > 0 {
+		info.OriginalFile = info.LineDirectives[0].Original.Filename
+		info.BareFilename = filepath.Base(info.OriginalFile)
+		info.BareGeneratedFilename = ""
+	}
+	return info
 }
 
 func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
@@ -192,21 +244,18 @@ func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
 			// ToDo: verify compiledFile must be also in  pkg.PPkg.GoFiles
 			pkg.SourceFiles = append(pkg.SourceFiles,
 				SourceFileInfo{
-					Pkg:           pkg,
-					BareFilename:  filepath.Base(compiledFile),
-					OriginalFile:  compiledFile,
-					GeneratedFile: compiledFile,
-					AstFile:       pkg.PPkg.Syntax[i],
+					Pkg:            pkg,
+					BareFilename:   filepath.Base(compiledFile),
+					OriginalFile:   compiledFile,
+					GeneratedFile:  compiledFile,
+					AstFile:        pkg.PPkg.Syntax[i],
+					LineDirectives: collectLineDirectives(pkg.PPkg.Fset, pkg.PPkg.Syntax[i]),
+					Directives:     collectFileDirectives(pkg.PPkg.Syntax[i]),
 				},
 			)
 			continue
 		}
-		info := cgoFileInfo(pkg, compiledFile, pkg.PPkg.Syntax[i])
-		if info == nil {
-			log.Println(compiledFile, "!has no original file:", compiledFile)
-			continue
-		}
-
+		info := generatedFileInfo(pkg, compiledFile, pkg.PPkg.Syntax[i])
 		if info.OriginalFile != "" && info.GeneratedFile != info.OriginalFile {
 			d.generatedFile2OriginalFileTable[info.GeneratedFile] = info.OriginalFile
 		}
@@ -228,6 +277,43 @@ func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
 			},
 		)
 	}
+
+	d.collectLineDirectiveTargets(pkg)
+}
+
+// collectLineDirectiveTargets makes the non-Go files which Go files
+// are generated from (by goyacc, ragel, templ, etc.) also viewed as
+// source files of the package, so that positions in the generated
+// files could be mapped to them. Only the ones in the directories of
+// the generated files are collected.
+func (d *CodeAnalyzer) collectLineDirectiveTargets(pkg *Package) {
+	var targets []string
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		for _, ld := range info.LineDirectives {
+			target := ld.Original.Filename
+			if strings.HasSuffix(target, ".go") || filepath.Dir(target) != filepath.Dir(info.GeneratedFile) {
+				continue
+			}
+			if _, ok := d.sourceFile2PackageTable[target]; ok {
+				continue
+			}
+			if _, err := os.Stat(target); err != nil {
+				continue
+			}
+			d.sourceFile2PackageTable[target] = pkg
+			targets = append(targets, target)
+		}
+	}
+	for _, path := range targets {
+		pkg.SourceFiles = append(pkg.SourceFiles,
+			SourceFileInfo{
+				Pkg:          pkg,
+				BareFilename: filepath.Base(path),
+				OriginalFile: path,
+			},
+		)
+	}
 }
 
 func (d *CodeAnalyzer) CollectObjectReferences() {
@@ -0,0 +1,110 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+)
+
+// TopN is the max length of the ranked lists.
+const TopN = 16
+
+// A RankedItem is an item in a ranked list. Name is blank for packages.
+type RankedItem struct {
+	Pkg   *Package
+	Name  string
+	Value int32
+}
+
+// TopNLists holds the ranked lists of packages and exported package-level
+// identifiers. Items with larger values are listed before others.
+type TopNLists struct {
+	MostImportedPackages      []RankedItem // by numbers of importing packages
+	MostDependingPackages     []RankedItem // by numbers of imported packages
+	PackagesWithMostFiles     []RankedItem
+	PackagesWithMostCodeLines []RankedItem
+	StructsWithMostFields     []RankedItem // including promoteds and non-exporteds
+	InterfacesWithMostMethods []RankedItem
+	MostImplementedInterfaces []RankedItem
+	MostReferencedIdentifiers []RankedItem // not including methods and fields
+}
+
+func (d *CodeAnalyzer) TopNLists() *TopNLists {
+	return &d.topNLists
+}
+
+// pushRankedItem inserts an item into a ranked list if the item ranks
+// in the top N. Items with the same values keep their pushing order.
+func pushRankedItem(items []RankedItem, item RankedItem) []RankedItem {
+	if item.Value <= 0 {
+		return items
+	}
+	i := len(items)
+	for i > 0 && items[i-1].Value < item.Value {
+		i--
+	}
+	if i >= TopN {
+		return items
+	}
+	if len(items) < TopN {
+		items = append(items, RankedItem{})
+	}
+	copy(items[i+1:], items[i:])
+	items[i] = item
+	return items
+}
+
+// collectTopNLists must be called after line stats are collected.
+func (d *CodeAnalyzer) collectTopNLists() {
+	var lists = &d.topNLists
+	*lists = TopNLists{}
+
+	var pushReferenced = func(pkg *Package, obj types.Object) {
+		lists.MostReferencedIdentifiers = pushRankedItem(lists.MostReferencedIdentifiers,
+			RankedItem{pkg, obj.Name(), int32(len(d.objectRefs[obj]))})
+	}
+
+	for _, pkg := range d.packageList {
+		lists.MostImportedPackages = pushRankedItem(lists.MostImportedPackages, RankedItem{pkg, "", int32(len(pkg.DepedBys))})
+		lists.MostDependingPackages = pushRankedItem(lists.MostDependingPackages, RankedItem{pkg, "", int32(len(pkg.Deps))})
+		lists.PackagesWithMostFiles = pushRankedItem(lists.PackagesWithMostFiles, RankedItem{pkg, "", int32(len(pkg.SourceFiles))})
+		lists.PackagesWithMostCodeLines = pushRankedItem(lists.PackagesWithMostCodeLines, RankedItem{pkg, "", pkg.Lines.CodeLines()})
+
+		var isBuiltinPkg = pkg.Path() == "builtin"
+		var isUnsafePkg = pkg.Path() == "unsafe"
+		for _, tn := range pkg.AllTypeNames {
+			if isBuiltinPkg == token.IsExported(tn.Name()) || tn.Alias != nil {
+				continue
+			}
+			if !isBuiltinPkg && !isUnsafePkg {
+				pushReferenced(pkg, tn.TypeName)
+			}
+			t := tn.Denoting()
+			switch t.TT.Underlying().(type) {
+			case *types.Struct:
+				lists.StructsWithMostFields = pushRankedItem(lists.StructsWithMostFields, RankedItem{pkg, tn.Name(), int32(len(t.AllFields))})
+			case *types.Interface:
+				lists.InterfacesWithMostMethods = pushRankedItem(lists.InterfacesWithMostMethods, RankedItem{pkg, tn.Name(), int32(len(t.AllMethods))})
+				lists.MostImplementedInterfaces = pushRankedItem(lists.MostImplementedInterfaces, RankedItem{pkg, tn.Name(), int32(len(t.ImplementedBys))})
+			}
+		}
+
+		if isBuiltinPkg || isUnsafePkg {
+			continue
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Func != nil && f.Exported() && !f.IsMethod() {
+				pushReferenced(pkg, f.Func)
+			}
+		}
+		for _, v := range pkg.AllVariables {
+			if v.Exported() {
+				pushReferenced(pkg, v.Var)
+			}
+		}
+		for _, c := range pkg.AllConstants {
+			if c.Exported() {
+				pushReferenced(pkg, c.Const)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// UnusedExported is an exported identifier which is not referenced in
+// any other analyzed packages than the one declaring it.
+//
+// Only the references recorded in CollectObjectReferences are counted.
+// A method might be still called through interface methods, which is
+// what SatisfiesInterface is for. Interface methods are not reported.
+type UnusedExported struct {
+	Pkg      *Package
+	Name     string // "Name" or "Type.Selector"
+	Kind     string // "type", "func", "method", "field", "var" or "const"
+	Position token.Position
+
+	// For methods only. Whether or not the method contributes to
+	// implementing some interface types.
+	SatisfiesInterface bool
+}
+
+// UnusedExporteds returns the unused exported identifiers declared in the
+// specified packages, sorted by package paths and positions. The fields
+// and methods of unexported types and the identifiers declared in _test.go
+// files are not reported.
+func (d *CodeAnalyzer) UnusedExporteds(pkgs []*Package) []UnusedExported {
+	var pkgSet = make(map[*types.Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		pkgSet[pkg.PPkg.Types] = true
+	}
+
+	// Uses of the instances of generic types and functions
+	// are counted for their origins.
+	var usedOutside = make(map[types.Object]bool, 1024)
+	for obj, ids := range d.objectRefs {
+		if obj.Pkg() == nil || !pkgSet[obj.Pkg()] {
+			continue
+		}
+		for _, id := range ids {
+			if id.FileInfo.Pkg.PPkg.Types != obj.Pkg() {
+				usedOutside[originObject(obj)] = true
+				break
+			}
+		}
+	}
+
+	var unuseds []UnusedExported
+	for _, pkg := range pkgs {
+		var fset = pkg.PPkg.Fset
+		var register = func(obj types.Object, name, kind string) {
+			if usedOutside[obj] {
+				return
+			}
+			pos := fset.PositionFor(obj.Pos(), false)
+			if strings.HasSuffix(pos.Filename, "_test.go") {
+				return
+			}
+			unuseds = append(unuseds, UnusedExported{
+				Pkg:      pkg,
+				Name:     name,
+				Kind:     kind,
+				Position: pos,
+			})
+		}
+
+		for _, tn := range pkg.AllTypeNames {
+			if !tn.Exported() {
+				continue
+			}
+			register(tn.TypeName, tn.Name(), "type")
+			if tn.Alias != nil {
+				continue
+			}
+			if st, ok := tn.TypeName.Type().Underlying().(*types.Struct); ok {
+				for i := 0; i < st.NumFields(); i++ {
+					if f := st.Field(i); f.Exported() {
+						register(f, tn.Name()+"."+f.Name(), "field")
+					}
+				}
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Func == nil || !f.Func.Exported() || f.AstDecl == nil {
+				continue
+			}
+			if !f.IsMethod() {
+				register(f.Func, f.Name(), "func")
+				continue
+			}
+			_, recvIdent, _, ok := f.ReceiverTypeName()
+			if !ok || !token.IsExported(recvIdent.Name) {
+				continue
+			}
+			n := len(unuseds)
+			register(f.Func, recvIdent.Name+"."+f.Name(), "method")
+			if len(unuseds) > n {
+				unuseds[n].SatisfiesInterface = d.CheckTypeMethodContributingToTypeImplementations(pkg.Path(), recvIdent.Name, "", f.Name())
+			}
+		}
+		for _, v := range pkg.AllVariables {
+			if v.Exported() {
+				register(v.Var, v.Name(), "var")
+			}
+		}
+		for _, c := range pkg.AllConstants {
+			if c.Exported() {
+				register(c.Const, c.Name(), "const")
+			}
+		}
+	}
+
+	sort.SliceStable(unuseds, func(i, j int) bool {
+		a, b := &unuseds[i], &unuseds[j]
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		if a.Position.Filename != b.Position.Filename {
+			return a.Position.Filename < b.Position.Filename
+		}
+		return a.Position.Offset < b.Position.Offset
+	})
+	return unuseds
+}
+
+// originObject returns the generic origin of an instantiated
+// function, method or field. Other objects are returned as is.
+func originObject(obj types.Object) types.Object {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
@@ -1,8 +1,21 @@
 module go101.org/gold
 
-go 1.13
+go 1.26.0
 
 require (
-	golang.org/x/text v0.3.3
-	golang.org/x/tools v0.0.0-20200804011535-6c149bb5ef0d
+	golang.org/x/mod v0.41.0
+	golang.org/x/text v0.42.0
+	golang.org/x/tools v0.50.0
+)
+
+require (
+	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/yuin/goldmark v1.4.13 // indirect
+	golang.org/x/crypto v0.57.0 // indirect
+	golang.org/x/net v0.59.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/telemetry v0.0.0-20260908163034-4bcc4b2ee518 // indirect
+	golang.org/x/term v0.46.0 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"go101.org/gold/code"
 	"go101.org/gold/internal/server"
 	"go101.org/gold/internal/util"
 )
@@ -88,6 +89,31 @@ func Run() {
 	}
 
 	silentMode := *silentFlag || *sFlag
+	parseOptions := code.ParseOptions{
+		Tests:  *testsFlag,
+		GOOS:   *goosFlag,
+		GOARCH: *goarchFlag,
+	}
+	for _, tag := range strings.Split(*tagsFlag, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			parseOptions.Tags = append(parseOptions.Tags, tag)
+		}
+	}
+	if *platformsFlag != "" {
+		platforms, err := code.ParsePlatforms(*platformsFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		parseOptions.Platforms = platforms
+	}
+
+	if *cacheFlag {
+		if dir, err := os.UserCacheDir(); err != nil {
+			log.Println("on-disk cache is disabled:", err)
+		} else {
+			parseOptions.CacheDir = filepath.Join(dir, "go101.org", "gold", Version)
+		}
+	}
 
 	if gen := *genFlag; gen {
 		viewDocsCommand := func(docsDir string) string {
@@ -95,10 +121,17 @@ func Run() {
 		}
 		options := server.DocsGenerationOptions{
 			NoIdentifierUsesPages: *nouses,
+			NoCallGraphPages:      *nocalls,
 			PlainSourceCodePages:  *plainsrc,
 			SilentMode:            silentMode,
 			IncreaseGCFrequency:   *moregcFlag,
 			EmphasizeWdPkgs:       *emphasizeWorkingDirectoryPackages,
+			ParseOptions:          parseOptions,
+
+			UnusedIncludeMainPackages:     *unusedMainsFlag,
+			UnusedIncludeInterfaceMethods: *unusedIfaceMethodsFlag,
+
+			APIDiffRefs: *diffFlag,
 		}
 		server.Gen(*genIntentFlag, validateDiir(*dirFlag), *langFlag, flag.Args(), options, Version, printUsage, viewDocsCommand)
 		return
@@ -124,7 +157,7 @@ is expected, please run the following command instead:
 		return
 	}
 
-	server.Run(*portFlag, *langFlag, flag.Args(), silentMode, Version, printUsage, getRoughBuildTime)
+	server.Run(*portFlag, *langFlag, flag.Args(), parseOptions, *watchFlag, *diffFlag, silentMode, Version, printUsage, getRoughBuildTime)
 }
 
 var hFlag = flag.Bool("h", false, "show help")
@@ -134,7 +167,9 @@ var helpFlag = flag.Bool("help", false, "show help")
 //var updateFlag = flag.Bool("update", false, "update self")
 var versionFlag = flag.Bool("version", false, "show version info")
 var genFlag = flag.Bool("gen", false, "HTML generation mode")
-var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata")
+var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata | unused")
+var unusedMainsFlag = flag.Bool("unused-mains", false, "also report main packages (for the unused report)")
+var unusedIfaceMethodsFlag = flag.Bool("unused-ifacemethods", false, "also report methods satisfying interfaces (for the unused report)")
 var langFlag = flag.String("lang", "", "docs generation language tag")
 var dirFlag = flag.String("dir", "", "directory for file serving or HTML generation")
 var portFlag = flag.String("port", "", "preferred server port [1024, 65536]. Default: 56789")
@@ -142,8 +177,17 @@ var sFlag = flag.Bool("s", false, "not open a browser automatically")
 var silentFlag = flag.Bool("silent", false, "not open a browser automatically")
 var moregcFlag = flag.Bool("moregc", false, "increase garbage collection frequency")
 var nouses = flag.Bool("nouses", false, "disable the identifier uses feature")
+var nocalls = flag.Bool("nocalls", false, "disable the call graph feature")
 var plainsrc = flag.Bool("plainsrc", false, "disable the source navigation feature")
 var emphasizeWorkingDirectoryPackages = flag.Bool("emphasize-wdpkgs", false, "disable the source navigation feature")
+var testsFlag = flag.Bool("tests", false, "also analyze test files and test packages")
+var cacheFlag = flag.Bool("cache", false, "cache some analysis results on disk")
+var watchFlag = flag.Bool("watch", false, "re-analyze packages when source files change")
+var goosFlag = flag.String("goos", "", "target GOOS")
+var goarchFlag = flag.String("goarch", "", "target GOARCH")
+var tagsFlag = flag.String("tags", "", "comma-separated build tags")
+var platformsFlag = flag.String("platforms", "", "other GOOS/GOARCH platforms to check, comma-separated")
+var diffFlag = flag.String("diff", "", "show the API changes between two git revisions, in the form OLD..NEW")
 
 func printVersion(out io.Writer) {
 	fmt.Fprintf(out, "Gold %s\n", Version)
@@ -179,6 +223,17 @@ Options:
 		logs in docs generation mode.
 	-gen
 		Static HTML docs generation mode.
+	-gen-intent=unused
+		Instead of HTML docs, generate a report
+		listing the exported identifiers which
+		are declared in the packages under the
+		current directory but not used by the
+		other analyzed packages.
+	-unused-mains
+	-unused-ifacemethods
+		Also report the identifiers in main
+		packages and the methods satisfying
+		interfaces. For the unused report only.
 	-dir=ContentDirectory
 		Specifiy the docs generation or file
 		serving diretory. Current directory
@@ -190,10 +245,47 @@ Options:
 	-plainsrc
 		Disable the source navigation feature.
 		For HTML docs generation mode only.
+	-nocalls
+		Disable the call graph feature.
+		For HTML docs generation mode only.
 	-emphasize-wdpkgs
 		List the packages under the current
 		directory before other pacakges.
 		For HTML docs generation mode only.
+	-tests
+		Also analyze the _test.go files and
+		the external test packages, and list
+		the tests, benchmarks, fuzz tests and
+		examples on package pages.
+	-cache
+		Cache the package, file and function
+		statistics on disk. A later run with
+		the same arguments doesn't collect
+		them again for the packages whose
+		source files (and dependencies) are
+		unchanged.
+	-watch
+		Watch the source files and re-analyze
+		packages when they change. Pages open
+		in browsers will be reloaded then.
+	-goos=GOOS
+	-goarch=GOARCH
+		The target platform to analyze.
+		The defaults are the ones specified
+		by the GOOS and GOARCH environment
+		variables, or the host ones.
+	-tags=tag1,tag2,...
+		The build tags to use.
+	-platforms=GOOS/GOARCH,...
+		Also check the specified platforms,
+		such as linux/amd64,windows/amd64.
+		Package pages will note which source
+		files and declarations are only for
+		some of the platforms.
+	-diff=OLD..NEW
+		Show the API changes of the packages
+		between two git revisions. NEW defaults
+		to HEAD. Breaking changes are flagged.
 
 Examples:
 	%[1]v std
@@ -211,6 +303,14 @@ Examples:
 		specified by the -dir flag for the
 		packages under the current directory
 		and their dependency packages.
+	%[1]v -gen -gen-intent=unused -dir=. ./...
+		Report the unused exported identifiers
+		in the packages under the current
+		directory.
+	%[1]v -diff=v1.2.0..HEAD ./...
+		Show docs of the packages under the
+		current directory, and their API
+		changes since the v1.2.0 tag.
 	%[1]v -dir=. -s
 		Serve the files in working directory
 		without opening a browser window.
@@ -2,11 +2,20 @@ package server
 
 import (
 	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
+	"go101.org/gold/code"
 	"go101.org/gold/internal/util"
 )
 
@@ -148,6 +157,194 @@ func TestPreviousVersion(t *testing.T) {
 	}
 }
 
+func TestConversionRuntimeFunc(t *testing.T) {
+	const src = `package p
+
+type S string
+
+type (
+	N struct{ a, b int64 }
+	P struct {
+		p *int
+		n int
+	}
+	W struct{ s []int }
+)
+
+func f(bs []byte, rs []rune, r rune, s S, p *int, x any, i16 int16, i64 int64, b bool, u8 uint8) {
+	_ = string(bs)             // slicebytetostring
+	_ = S(rs)                  // slicerunetostring
+	_ = string(r)              // intstring
+	_ = []byte(s)              // stringtoslicebyte
+	_ = []rune("a")            // stringtoslicerune
+	_ = any(s)                 // convTstring
+	_ = any(bs)                // convTslice
+	_ = any(W{})               // convTslice
+	_ = any(r)                 // convT32
+	_ = any(i16)               // convT16
+	_ = any(i64)               // convT64
+	_ = any(N{})               // convTnoptr
+	_ = any(P{})               // convT
+	_ = any(b)                 //
+	_ = any(u8)                //
+	_ = any(struct{ p *int }{}) //
+	_ = any(struct{}{})        //
+	_ = any(p)                 //
+	_ = any(1)                 //
+	_ = any(x)                 //
+	_ = string("a")            //
+	_ = int64(r)               //
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	if _, err := (&types.Config{Importer: importer.Default()}).Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = make(map[int]string)
+	for _, cg := range file.Comments {
+		expected[fset.Position(cg.Pos()).Line] = strings.TrimSpace(strings.TrimPrefix(cg.Text(), "//"))
+	}
+	v := &astVisitor{info: info, sizes: types.SizesFor("gc", "amd64")}
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		line := fset.Position(call.Pos()).Line
+		if f := v.conversionRuntimeFunc(call); f != expected[line] {
+			t.Errorf("line %d: runtime function not match: %q vs. %q", line, f, expected[line])
+		}
+		return false
+	})
+}
+
+func TestImplicitConversionRuntimeFunc(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+func f(n int64, s string, p *int, x any) (any, error) {
+	fmt.Println(n)    // convT64
+	fmt.Println(p, s) // convTstring
+	fmt.Println(x)    //
+	var y any = s     // convTstring
+	var z int64 = n   //
+	x = n             // convT64
+	x, z = p, n       //
+	_, _ = y, z       //
+	g := func() any {
+		return s // convTstring
+	}
+	_ = g             //
+	return n, nil     // convT64
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	if _, err := (&types.Config{Importer: importer.Default()}).Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = make(map[int]string)
+	for _, cg := range file.Comments {
+		expected[fset.Position(cg.Pos()).Line] = strings.TrimSpace(strings.TrimPrefix(cg.Text(), "//"))
+	}
+	v := &astVisitor{info: info, sizes: types.SizesFor("gc", "amd64")}
+	var numChecked int
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			switch stack[len(stack)-1].(type) {
+			case *ast.FuncDecl, *ast.FuncLit:
+				v.enclosingFuncs = v.enclosingFuncs[:len(v.enclosingFuncs)-1]
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		var node = n
+		switch n := n.(type) {
+		default:
+			return true
+		case *ast.FuncDecl:
+			v.enclosingFuncs = append(v.enclosingFuncs, enclosingFunc{sig: info.Defs[n.Name].Type().(*types.Signature)})
+			return true
+		case *ast.FuncLit:
+			v.enclosingFuncs = append(v.enclosingFuncs, enclosingFunc{sig: info.TypeOf(n).(*types.Signature)})
+			return true
+		case *ast.ExprStmt:
+			node = n.X
+		case *ast.DeclStmt:
+			node = n.Decl.(*ast.GenDecl).Specs[0]
+		case *ast.AssignStmt, *ast.ReturnStmt:
+		}
+		line := fset.Position(n.Pos()).Line
+		if f := v.implicitConversionRuntimeFunc(node); f != expected[line] {
+			t.Errorf("line %d: runtime function not match: %q vs. %q", line, f, expected[line])
+		}
+		numChecked++
+		return true
+	})
+	if numChecked != 12 {
+		t.Errorf("%d statements are checked, expected 12", numChecked)
+	}
+}
+
+func TestLookupDocLinkSymbol(t *testing.T) {
+	const src = `package p
+
+type T struct{ X int }
+
+func (T) M() {}
+
+type E struct{ T }
+
+func F() {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpkg, err := (&types.Config{}).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		recv, name string
+		expected   bool
+	}{
+		{"", "F", true},
+		{"", "T", true},
+		{"", "G", false},
+		{"T", "X", true},
+		{"T", "M", true},
+		{"E", "M", true},
+		{"T", "Y", false},
+		{"F", "X", false},
+	} {
+		if found := lookupDocLinkSymbol(tpkg, c.recv, c.name); found != c.expected {
+			t.Errorf("lookupDocLinkSymbol(%q, %q) = %v, expected %v", c.recv, c.name, found, c.expected)
+		}
+	}
+}
+
 func TestDocsForStandardPackages(t *testing.T) {
 	// ...
 	data, err := ioutil.ReadFile(filepath.Join("..", "testing", "data", "testdata.json.tar.gz"))
@@ -227,3 +424,466 @@ func TestGenerateDocsOfStandardPackages(t *testing.T) {
 	}
 	GenDocs("", []string{"std"}, "en-US", opts, "v0.0.0", nil, nil)
 }
+
+func TestDropCachedPagesForPackages(t *testing.T) {
+	ds := &docServer{
+		cachedPages: map[pageCacheKey][]byte{
+			{resType: ResTypeNone, res: ""}:                                  nil,
+			{resType: ResTypeCSS, res: "light"}:                              nil,
+			{resType: ResTypePackage, res: ""}:                               nil,
+			{resType: ResTypePackage, res: "a"}:                              nil,
+			{resType: ResTypePackage, res: "b"}:                              nil,
+			{resType: ResTypeDependency, res: "b"}:                           nil,
+			{resType: ResTypeSource, res: [...]string{"a", "a.go"}}:          nil,
+			{resType: ResTypeSource, res: [...]string{"b", "b.go"}}:          nil,
+			{resType: ResTypeReference, res: [...]string{"b", "B"}}:          nil,
+			{resType: ResTypeImplementation, res: [...]string{"b", "Iface"}}: nil,
+		},
+	}
+	ds.dropCachedPagesForPackages(map[string]bool{"a": true})
+
+	var kept []pageCacheKey
+	for key := range ds.cachedPages {
+		kept = append(kept, key)
+	}
+	if len(kept) != 4 {
+		t.Fatalf("4 pages should be kept, but %v are kept", kept)
+	}
+	for _, key := range []pageCacheKey{
+		{resType: ResTypeCSS, res: "light"},
+		{resType: ResTypePackage, res: "b"},
+		{resType: ResTypeDependency, res: "b"},
+		{resType: ResTypeSource, res: [...]string{"b", "b.go"}},
+	} {
+		if _, ok := ds.cachedPages[key]; !ok {
+			t.Errorf("page %v should be kept", key)
+		}
+	}
+}
+
+func TestAffectedPackages(t *testing.T) {
+	oldPkgs := map[string]*watchedPackage{
+		"a":      {files: []string{"/w/a/a.go"}},
+		"b":      {files: []string{"/w/b/b.go"}, imports: []string{"a", "fmt"}},
+		"c":      {files: []string{"/w/c/c.go"}},
+		"c_test": {files: []string{"/w/c/c_test.go"}, imports: []string{"c", "testing"}},
+		"d":      {files: []string{"/w/d/d.go"}},
+		"fmt":    {files: []string{"/goroot/src/fmt/print.go"}},
+	}
+	newPkgs := map[string]*watchedPackage{
+		"a":      {files: []string{"/w/a/a.go"}},
+		"b":      {files: []string{"/w/b/b.go"}, imports: []string{"a", "fmt"}},
+		"c":      {files: []string{"/w/c/c.go"}},
+		"c_test": {files: []string{"/w/c/c_test.go"}, imports: []string{"c", "testing"}},
+		"e":      {files: []string{"/w/e/e.go"}, imports: []string{"fmt"}},
+		"fmt":    {files: []string{"/goroot/src/fmt/print.go"}},
+	}
+	affected := affectedPackages(oldPkgs, newPkgs, map[string]bool{"/w/a": true})
+	for _, path := range []string{"a", "b", "d", "e", "fmt"} {
+		if !affected[path] {
+			t.Errorf("package %s should be affected", path)
+		}
+	}
+	for _, path := range []string{"c", "c_test", "testing"} {
+		if affected[path] {
+			t.Errorf("package %s should not be affected", path)
+		}
+	}
+}
+
+func TestSignDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sig := util.SignDirectory(dir)
+	if sig == "" || util.SignDirectory(dir) != sig {
+		t.Fatalf("the signature of an unchanged directory should be stable")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a // modified"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if util.SignDirectory(dir) == sig {
+		t.Errorf("the signature should change after a file is modified")
+	}
+	if util.SignDirectory(filepath.Join(dir, "nonexistent")) != "" {
+		t.Errorf("the signature of a nonexistent directory should be blank")
+	}
+}
+
+func TestParseAPIDiffRefs(t *testing.T) {
+	for _, c := range []struct {
+		refs, oldRef, newRef string
+		ok                   bool
+	}{
+		{"v1.0.0", "v1.0.0", "HEAD", true},
+		{"v1.0.0..", "v1.0.0", "HEAD", true},
+		{"v1.0.0..main", "v1.0.0", "main", true},
+		{"..main", "", "", false},
+		{"", "", "", false},
+	} {
+		oldRef, newRef, err := parseAPIDiffRefs(c.refs)
+		if (err == nil) != c.ok || oldRef != c.oldRef || newRef != c.newRef {
+			t.Errorf("parseAPIDiffRefs(%q) = %q, %q, %v", c.refs, oldRef, newRef, err)
+		}
+	}
+}
+
+func TestTypeHierarchyOf(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/hierarchy\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+import "io"
+
+type I interface{ M() }
+
+type Base struct{}
+
+func (Base) M() {}
+
+type Inner struct{ Base }
+
+type Outer struct {
+	*Inner
+	io.Reader
+}
+
+type Plain struct{ x int }
+`,
+	})
+	ds := &docServer{analyzer: analyzer}
+
+	var texts = func(nodes []typeHierarchyNode) map[string]int {
+		m := make(map[string]int, len(nodes))
+		for _, n := range nodes {
+			m[n.text] = n.depth
+		}
+		return m
+	}
+
+	tn, self, implements, embeddings, _ := ds.typeHierarchyOf("example.com/hierarchy/p", "Outer")
+	if tn == nil {
+		t.Fatal("type Outer is not found")
+	}
+	if self.text != "Outer" {
+		t.Errorf("the self node should be Outer, but it is %s", self.text)
+	}
+	impls := texts(implements)
+	for _, name := range []string{"I", "io.Reader"} {
+		if _, ok := impls[name]; !ok {
+			t.Errorf("Outer should implement %s, but the implemented ones are %v", name, impls)
+		}
+	}
+	if _, ok := impls["Outer"]; ok {
+		t.Errorf("Outer should not implement itself")
+	}
+	embeds := texts(embeddings)
+	for name, depth := range map[string]int{"*Inner": 1, "io.Reader": 1, "Base": 2} {
+		if d, ok := embeds[name]; !ok || d != depth {
+			t.Errorf("%s should be embedded at depth %d, but the embedded ones are %v", name, depth, embeds)
+		}
+	}
+
+	tn, _, implements, embeddings, implementers := ds.typeHierarchyOf("example.com/hierarchy/p", "I")
+	if tn == nil {
+		t.Fatal("type I is not found")
+	}
+	if len(implements) != 0 || len(embeddings) != 0 {
+		t.Errorf("I should neither implement nor embed other types, but got %v and %v", implements, embeddings)
+	}
+	implers := texts(implementers)
+	for _, name := range []string{"Base", "Inner", "Outer"} {
+		if _, ok := implers[name]; !ok {
+			t.Errorf("I should be implemented by %s, but the implementers are %v", name, implers)
+		}
+	}
+
+	if tn, _, _, _, _ := ds.typeHierarchyOf("example.com/hierarchy/p", "Nonexistent"); tn != nil {
+		t.Errorf("type Nonexistent should not be found")
+	}
+
+	pkg := analyzer.PackageByPath("example.com/hierarchy/p")
+	for _, tn := range pkg.AllTypeNames {
+		has := ds.hasTypeHierarchy(tn)
+		if expected := tn.Name() != "Plain"; has != expected {
+			t.Errorf("hasTypeHierarchy(%s) should be %v", tn.Name(), expected)
+		}
+		if it := tn.Denoting(); analyzer.HasCleanImplements(it) != (len(analyzer.CleanImplements(it)) > 0) {
+			t.Errorf("HasCleanImplements and CleanImplements don't match for %s", tn.Name())
+		}
+	}
+}
+
+// analyzeTestModule analyzes all the packages in a module
+// consisting of the specified files (keyed by slash paths).
+func analyzeTestModule(t *testing.T, files map[string]string) *code.CodeAnalyzer {
+	dir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// GO111MODULE might be turned off by the tests analyzing std packages.
+	t.Setenv("GO111MODULE", "on")
+
+	var analyzer code.CodeAnalyzer
+	if !analyzer.ParsePackages(nil, code.ParseOptions{Dir: dir}, "./...") {
+		t.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+	return &analyzer
+}
+
+func TestPackagesUnderDirectory(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod":    "module example.com/dirs\n\ngo 1.22\n",
+		"a/a.go":    "package a\n",
+		"a/b/b.go":  "package b\n",
+		"ab/ab.go":  "package ab\n",
+		"c/a/ca.go": "package a\n",
+	})
+	a := analyzer.PackageByPath("example.com/dirs/a")
+	if a == nil {
+		t.Fatal("package example.com/dirs/a is not found")
+	}
+
+	for _, dir := range []string{a.Directory, a.Directory + string(filepath.Separator)} {
+		var paths []string
+		for _, pkg := range packagesUnderDirectory(analyzer, dir) {
+			paths = append(paths, pkg.Path())
+		}
+		sort.Strings(paths)
+		if expected := "example.com/dirs/a example.com/dirs/a/b"; strings.Join(paths, " ") != expected {
+			t.Errorf("packages under %s: %v, expected: %s", dir, paths, expected)
+		}
+	}
+}
+
+func TestBuildMethodSetsData(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/methodsets\n\ngo 1.22\n",
+		"p/p.go": `package p
+
+type I interface {
+	V()
+	P()
+}
+
+type J interface{ V() }
+
+type Base struct{}
+
+func (Base) BV()  {}
+func (*Base) BP() {}
+
+type Mid struct{ *Base }
+
+func (Mid) MV() {}
+
+type Other struct{}
+
+func (*Other) OP() {}
+
+type T struct {
+	Mid
+	Other
+}
+
+func (T) V()  {}
+func (*T) P() {}
+`,
+	})
+	ds := &docServer{analyzer: analyzer}
+
+	result, err := ds.buildMethodSetsData("example.com/methodsets/p", "T")
+	if err != nil {
+		t.Fatalf("build method sets data error: %s", err)
+	}
+
+	var names = func(sels []*code.Selector) string {
+		var ns []string
+		for _, sel := range sels {
+			ns = append(ns, sel.Name())
+		}
+		return strings.Join(ns, ",")
+	}
+	// BP is declared for *Base, but Base is embedded as a pointer.
+	if ns := names(result.ValueMethods); ns != "BP,BV,MV,V" {
+		t.Errorf("the value methods should be BP,BV,MV,V, but are %s", ns)
+	}
+	if ns := names(result.PointerOnlyMethods); ns != "OP,P" {
+		t.Errorf("the pointer-only methods should be OP,P, but are %s", ns)
+	}
+
+	paths := map[string]string{}
+	for _, sel := range append(result.ValueMethods, result.PointerOnlyMethods...) {
+		if sel.EmbeddingChain != nil {
+			paths[sel.Name()] = embeddingPath(sel.EmbeddingChain)
+		}
+	}
+	expectedPaths := map[string]string{"BP": "Mid.*Base", "BV": "Mid.*Base", "MV": "Mid", "OP": "Other"}
+	if len(paths) != len(expectedPaths) {
+		t.Errorf("the embedding paths should be %v, but are %v", expectedPaths, paths)
+	}
+	for name, path := range expectedPaths {
+		if paths[name] != path {
+			t.Errorf("method %s should be promoted through %s, but through %q", name, path, paths[name])
+		}
+	}
+
+	implements := map[string]string{}
+	for _, impl := range result.Implements {
+		if impl.Interface.TypeName != nil {
+			implements[impl.Interface.TypeName.Name()] = names(impl.PointerOnlyMethods)
+		}
+	}
+	if ns, ok := implements["I"]; !ok || ns != "P" {
+		t.Errorf("only *T should implement I (for method P), but got %q", ns)
+	}
+	if ns, ok := implements["J"]; !ok || ns != "" {
+		t.Errorf("both T and *T should implement J, but got %q", ns)
+	}
+
+	if _, err := ds.buildMethodSetsData("example.com/methodsets/p", "I"); err == nil {
+		t.Errorf("interface types should have no method sets pages")
+	}
+	if _, err := ds.buildMethodSetsData("example.com/methodsets/p", "Nonexistent"); err == nil {
+		t.Errorf("nonexistent types should have no method sets pages")
+	}
+}
+
+func TestCgoSourcePositions(t *testing.T) {
+	if os.Getenv("CGO_ENABLED") == "0" {
+		t.Skip("cgo is disabled")
+	}
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/cgo\n\ngo 1.22\n",
+		"a.go": `package cgo
+
+/*
+static int twice(int x) { return 2*x; }
+*/
+import "C"
+
+// Twice doubles x.
+func Twice(x int) int {
+	return int(C.twice(C.int(x)))
+}
+`,
+	})
+	ds := &docServer{analyzer: analyzer}
+
+	pkg := analyzer.PackageByPath("example.com/cgo")
+	if pkg == nil {
+		t.Fatal("package example.com/cgo is not found")
+	}
+	var twice *code.Function
+	for _, f := range pkg.AllFunctions {
+		if f.Name() == "Twice" {
+			twice = f
+		}
+	}
+	if twice == nil {
+		t.Fatal("function Twice is not found")
+	}
+
+	pos := twice.Position()
+	fileInfo := pkg.SourceFileInfoByFilePath(pos.Filename)
+	if fileInfo == nil {
+		t.Fatalf("file info for %s is not found", pos.Filename)
+	}
+	if !isCgoGeneratedFile(fileInfo) {
+		t.Skipf("%s is not generated by cgo", pos.Filename)
+	}
+	fileInfo, pos = originalSourcePosition(pkg, fileInfo, pos)
+	if fileInfo.AstBareFileName() != "a.go" || pos.Line != 9 {
+		t.Errorf("Twice should be declared at a.go:9, but at %s:%d", fileInfo.AstBareFileName(), pos.Line)
+	}
+
+	result, err := ds.analyzeSoureCode("example.com/cgo", "a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Lines) != 11 {
+		t.Fatalf("the source page of a.go should have 11 lines, but has %d", len(result.Lines))
+	}
+	if line := result.Lines[8]; !strings.Contains(line, ">Twice<") {
+		t.Errorf("line 9 of the source page of a.go should declare Twice, but it is %q", line)
+	}
+	if result.DocStartLine != 0 {
+		t.Errorf("a.go has no package doc, but the doc starts at line %d", result.DocStartLine)
+	}
+}
+
+func TestSourceImplicitConversionLinks(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/conv\n\ngo 1.22\n",
+		"a.go": `package conv
+
+import "fmt"
+
+func F(n int64, s string) any {
+	fmt.Println(n)
+	var x any = s
+	x = n
+	_ = x
+	return n
+}
+`,
+	})
+	ds := &docServer{analyzer: analyzer}
+
+	result, err := ds.analyzeSoureCode("example.com/conv", "a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var convT64 = "#line-" + strconv.Itoa(analyzer.RuntimeFunctionCodePosition("convT64").Line) + `"`
+	var convTstring = "#line-" + strconv.Itoa(analyzer.RuntimeFunctionCodePosition("convTstring").Line) + `"`
+	for _, c := range []struct {
+		line int
+		link string
+	}{
+		{6, convT64},
+		{7, convTstring},
+		{8, convT64},
+		{10, convT64},
+	} {
+		if line := result.Lines[c.line-1]; !strings.Contains(line, "/runtime/") || !strings.Contains(line, c.link) {
+			t.Errorf("line %d of the source page of a.go should link to the runtime function, but it is %q", c.line, line)
+		}
+	}
+	if line := result.Lines[8]; strings.Contains(line, "/runtime/") {
+		t.Errorf("line 9 of the source page of a.go should not link to runtime functions, but it is %q", line)
+	}
+}
+
+func TestPackageDetailsFileLines(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/lines\n\ngo 1.22\n",
+		"a.go":   "// Package lines is a test package.\npackage lines\n\nfunc A() {}\n",
+		"b.go":   "package lines\n\n// B is blank.\nvar B = 1\n",
+	})
+
+	details := buildPackageDetailsData(analyzer, "example.com/lines", packagePageOptions{})
+	if details == nil {
+		t.Fatal("package example.com/lines is not found")
+	}
+	expected := map[string]code.LineStats{
+		"a.go": {Lines: 4, BlankLines: 1, CommentLines: 1},
+		"b.go": {Lines: 4, BlankLines: 1, CommentLines: 1},
+	}
+	if len(details.Files) != len(expected) {
+		t.Fatalf("the package should have %d files, but has %d", len(expected), len(details.Files))
+	}
+	for _, info := range details.Files {
+		if info.Lines != expected[info.Filename] {
+			t.Errorf("the line stats of %s should be %+v, but are %+v", info.Filename, expected[info.Filename], info.Lines)
+		}
+	}
+}
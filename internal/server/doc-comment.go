@@ -0,0 +1,213 @@
+package server
+
+import (
+	"go/doc/comment"
+	"go/types"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// writeDocComment renders a doc comment with the Go doc comment syntax:
+// headings, lists, code blocks, URLs and doc links. Doc links are resolved
+// against the analyzed packages, with pkg as the current package.
+// Like writePageText, each line is prefixed with indent, blank lines are
+// not indented and no newline is written at the end.
+func (ds *docServer) writeDocComment(page *htmlPage, pkg *code.Package, indent, doc string) {
+	var parser = comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			return lookupDocLinkPackage(pkg, name)
+		},
+		LookupSym: func(recv, name string) bool {
+			return pkg.PPkg.Types != nil && lookupDocLinkSymbol(pkg.PPkg.Types, recv, name)
+		},
+	}
+	for i, block := range parser.Parse(doc).Content {
+		if i > 0 {
+			if list, ok := block.(*comment.List); ok && !list.BlankBefore() {
+				page.WriteByte('\n')
+			} else {
+				page.WriteString("\n\n")
+			}
+		}
+		ds.writeDocBlock(page, pkg, indent, indent, block)
+	}
+}
+
+// The first line of a block is prefixed with firstIndent,
+// the others are prefixed with indent.
+func (ds *docServer) writeDocBlock(page *htmlPage, pkg *code.Package, firstIndent, indent string, block comment.Block) {
+	switch block := block.(type) {
+	case *comment.Paragraph:
+		page.WriteString(firstIndent)
+		ds.writeDocText(page, pkg, indent, block.Text)
+	case *comment.Heading:
+		page.WriteString(firstIndent)
+		page.WriteString(`<span class="doc-heading">`)
+		ds.writeDocText(page, pkg, indent, block.Text)
+		page.WriteString(`</span>`)
+	case *comment.Code:
+		page.WriteString(`<span class="doc-code">`)
+		for i, line := range strings.Split(strings.TrimSuffix(block.Text, "\n"), "\n") {
+			if i > 0 {
+				page.WriteByte('\n')
+			}
+			if line != "" {
+				if i == 0 {
+					page.WriteString(firstIndent)
+				} else {
+					page.WriteString(indent)
+				}
+				page.WriteByte('\t')
+				WriteHtmlEscapedBytes(page, []byte(line))
+			}
+		}
+		page.WriteString(`</span>`)
+	case *comment.List:
+		for i, item := range block.Items {
+			if i > 0 {
+				if block.BlankBetween() {
+					page.WriteString("\n\n")
+				} else {
+					page.WriteByte('\n')
+				}
+			}
+			marker := "-"
+			if item.Number != "" {
+				marker = item.Number + "."
+			}
+			itemFirstIndent := indent + "  " + marker + " "
+			if i == 0 {
+				itemFirstIndent = firstIndent + "  " + marker + " "
+			}
+			itemIndent := indent + "    "
+			for k, b := range item.Content {
+				if k > 0 {
+					page.WriteString("\n\n")
+					itemFirstIndent = itemIndent
+				}
+				ds.writeDocBlock(page, pkg, itemFirstIndent, itemIndent, b)
+			}
+		}
+	}
+}
+
+func (ds *docServer) writeDocText(page *htmlPage, pkg *code.Package, indent string, texts []comment.Text) {
+	for _, t := range texts {
+		switch t := t.(type) {
+		case comment.Plain:
+			writeDocPlainText(page, indent, string(t))
+		case comment.Italic:
+			page.WriteString("<i>")
+			writeDocPlainText(page, indent, string(t))
+			page.WriteString("</i>")
+		case *comment.Link:
+			page.WriteString(`<a href="`)
+			WriteHtmlEscapedBytes(page, []byte(strings.ReplaceAll(t.URL, `"`, "%22")))
+			page.WriteString(`">`)
+			ds.writeDocText(page, pkg, indent, t.Text)
+			page.WriteString("</a>")
+		case *comment.DocLink:
+			href := ds.docLinkHref(page.PathInfo, pkg, t)
+			if href != "" {
+				page.WriteString(`<a href="`)
+				page.WriteString(href)
+				page.WriteString(`">`)
+			}
+			ds.writeDocText(page, pkg, indent, t.Text)
+			if href != "" {
+				page.WriteString("</a>")
+			}
+		}
+	}
+}
+
+func writeDocPlainText(page *htmlPage, indent, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			page.WriteByte('\n')
+			page.WriteString(indent)
+		}
+		WriteHtmlEscapedBytes(page, []byte(line))
+	}
+}
+
+// docLinkHref returns the href of a doc link. Exported package-level
+// identifiers are linked to their anchors in package pages. Fields, methods
+// and unexported identifiers are linked to their declarations in source pages.
+// Blank is returned if the linked package or identifier is not found.
+func (ds *docServer) docLinkHref(currentPathInfo pagePathInfo, pkg *code.Package, link *comment.DocLink) string {
+	importPath := link.ImportPath
+	if importPath == "" {
+		importPath = pkg.Path()
+	}
+	target := ds.analyzer.PackageByPath(importPath)
+	if target == nil {
+		return ""
+	}
+	if link.Name == "" {
+		return buildPageHref(currentPathInfo, pagePathInfo{ResTypePackage, importPath}, nil, "")
+	}
+	if target.PPkg.Types == nil {
+		return ""
+	}
+
+	var obj types.Object
+	if link.Recv == "" {
+		obj = target.PPkg.Types.Scope().Lookup(link.Name)
+		if obj == nil {
+			return ""
+		}
+		if obj.Exported() || importPath == "builtin" {
+			return buildPageHref(currentPathInfo, pagePathInfo{ResTypePackage, importPath}, nil, "") + "#name-" + obj.Name()
+		}
+	} else {
+		tn, ok := target.PPkg.Types.Scope().Lookup(link.Recv).(*types.TypeName)
+		if !ok {
+			return ""
+		}
+		obj, _, _ = types.LookupFieldOrMethod(tn.Type(), true, tn.Pkg(), link.Name)
+		if obj == nil || obj.Pkg() == nil {
+			return ""
+		}
+	}
+
+	// The selector might be promoted from an embedded type declared in another package.
+	declPkg := ds.analyzer.PackageByPath(obj.Pkg().Path())
+	if declPkg == nil || !obj.Pos().IsValid() {
+		return ""
+	}
+	return buildSrouceCodeLineLink(currentPathInfo, ds.analyzer, declPkg, declPkg.PPkg.Fset.PositionFor(obj.Pos(), false))
+}
+
+// lookupDocLinkPackage finds the import path of the package with the
+// specified name among the package itself and its imports. Like go/doc,
+// ambiguous names are not resolved here.
+func lookupDocLinkPackage(pkg *code.Package, name string) (importPath string, ok bool) {
+	if name == pkg.PPkg.Name {
+		return pkg.Path(), true
+	}
+	for path, imp := range pkg.PPkg.Imports {
+		if imp.Name == name {
+			if ok {
+				return "", false
+			}
+			importPath, ok = path, true
+		}
+	}
+	return
+}
+
+// lookupDocLinkSymbol reports whether a doc link "[name]" or "[recv.name]"
+// denotes a package-level identifier, or a field or method, in a package.
+func lookupDocLinkSymbol(tpkg *types.Package, recv, name string) bool {
+	if recv == "" {
+		return tpkg.Scope().Lookup(name) != nil
+	}
+	tn, ok := tpkg.Scope().Lookup(recv).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, tpkg, name)
+	return obj != nil
+}
@@ -23,6 +23,8 @@ const (
 	ResTypeImplementation pageResType = "imp"
 	ResTypeSource         pageResType = "src"
 	ResTypeReference      pageResType = "use"
+	ResTypeCallGraph      pageResType = "cal"
+	ResTypeMethodSets     pageResType = "mts"
 	ResTypeCSS            pageResType = "css"
 	ResTypeJS             pageResType = "jvs"
 	ResTypeSVG            pageResType = "svg"
@@ -42,6 +44,8 @@ func isHTMLPage(res pageResType) bool {
 	case ResTypeImplementation:
 	case ResTypeSource:
 	case ResTypeReference:
+	case ResTypeCallGraph:
+	case ResTypeMethodSets:
 	}
 	return true
 }
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go101.org/gold/code"
+	"go101.org/gold/internal/util"
+)
+
+// The API diff compares the exported APIs of the packages specified by
+// the program arguments at two git revisions. Each of the revisions is
+// checked out into a temporary worktree and analyzed separately, so the
+// diff is independent of the uncommitted changes in the working tree.
+
+type apiDiffResult struct {
+	oldRef, newRef string
+
+	done  bool
+	diffs []code.PackageAPIDiff
+	err   error
+}
+
+// parseAPIDiffRefs parses the form OLD..NEW. NEW defaults to HEAD.
+func parseAPIDiffRefs(refs string) (oldRef, newRef string, err error) {
+	oldRef, newRef = refs, "HEAD"
+	if i := strings.Index(refs, ".."); i >= 0 {
+		oldRef, newRef = refs[:i], refs[i+2:]
+		if newRef == "" {
+			newRef = "HEAD"
+		}
+	}
+	if oldRef == "" {
+		return "", "", fmt.Errorf("invalid git revision range %q, the form should be OLD..NEW", refs)
+	}
+	return oldRef, newRef, nil
+}
+
+// computeAPIDiff is called in a separate goroutine in the server mode.
+func (ds *docServer) computeAPIDiff(args []string, parseOptions code.ParseOptions) {
+	ds.mutex.Lock()
+	result := ds.apiDiff
+	ds.mutex.Unlock()
+
+	diffs, err := diffGitRevisions(result.oldRef, result.newRef, args, parseOptions)
+	if err != nil {
+		log.Printf("API diff (%s..%s) error: %s", result.oldRef, result.newRef, err)
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	result.diffs, result.err = diffs, err
+	result.done = true
+}
+
+func diffGitRevisions(oldRef, newRef string, args []string, parseOptions code.ParseOptions) ([]code.PackageAPIDiff, error) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	oldAnalyzer, oldWorktree, err := analyzeGitRevision(oldRef, args, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	newAnalyzer, newWorktree, err := analyzeGitRevision(newRef, args, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	return code.DiffAPIs(oldAnalyzer, newAnalyzer, packagesUnderDirectory(oldAnalyzer, oldWorktree), packagesUnderDirectory(newAnalyzer, newWorktree)), nil
+}
+
+// analyzeGitRevision analyzes the packages at a git revision in a temporary
+// worktree. The package arguments are resolved in the directory in the
+// worktree corresponding to the current directory. The worktree directory
+// is also returned, though it has been removed when the function returns.
+func analyzeGitRevision(ref string, args []string, parseOptions code.ParseOptions) (*code.CodeAnalyzer, string, error) {
+	output, err := util.RunShellCommand(time.Second*10, "", nil, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, "", fmt.Errorf("not in a git repository: %s", strings.TrimSpace(string(output)))
+	}
+	topDir, err := filepath.EvalSymlinks(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	if wd, err = filepath.EvalSymlinks(wd); err != nil {
+		return nil, "", err
+	}
+	relDir, err := filepath.Rel(topDir, wd)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tempDir, err := os.MkdirTemp("", "gold-apidiff-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(tempDir)
+	// The file paths of the packages are compared with it later.
+	if tempDir, err = filepath.EvalSymlinks(tempDir); err != nil {
+		return nil, "", err
+	}
+
+	worktree := filepath.Join(tempDir, "worktree")
+	output, err = util.RunShellCommand(time.Minute, topDir, nil, "git", "worktree", "add", "--detach", worktree, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to check out %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+	defer util.RunShellCommand(time.Minute, topDir, nil, "git", "worktree", "remove", "--force", worktree)
+
+	parseOptions.Dir = filepath.Join(worktree, relDir)
+	parseOptions.CacheDir = "" // the worktree is temporary
+	analyzer := &code.CodeAnalyzer{}
+	if !analyzer.ParsePackages(nil, parseOptions, args...) {
+		return nil, "", fmt.Errorf("failed to parse packages at %s", ref)
+	}
+	analyzer.AnalyzePackages(nil)
+	return analyzer, worktree, nil
+}
+
+func (ds *docServer) apiDiffPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.apiDiff == nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "The API diff is not enabled. Please specify the git revisions with the -diff option.")
+		return
+	}
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	if !ds.apiDiff.done {
+		w.WriteHeader(http.StatusTooEarly)
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="3">
+</head>
+<body><pre>%s</pre></body>
+</html>`,
+			ds.currentTranslation.Text_APIDiffBeingComputed(),
+		)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "apidiff",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildAPIDiffPage(w, ds.apiDiff)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildAPIDiffPage(w http.ResponseWriter, result *apiDiffResult) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_APIChanges(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "apidiff"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span>
+%s..%s</code></pre>
+`,
+		ds.currentTranslation.Text_APIChanges(),
+		result.oldRef,
+		result.newRef,
+	)
+
+	if result.err != nil {
+		page.WriteString(`<pre><code><span class="package-error">`)
+		WriteHtmlEscapedBytes(page, []byte(result.err.Error()))
+		page.WriteString("</span></code></pre>")
+		return page.Done(w)
+	}
+
+	var numBreakings int
+	for i := range result.diffs {
+		numBreakings += result.diffs[i].NumBreakingChanges()
+	}
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_APIDiffStat(len(result.diffs), numBreakings))
+	for i := range result.diffs {
+		pd := &result.diffs[i]
+		page.WriteString("\n\n\t")
+		if ds.analyzer.PackageByPath(pd.Path) != nil {
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pd.Path}, page, pd.Path)
+		} else {
+			page.WriteString(pd.Path)
+		}
+		for _, c := range pd.Changes {
+			ds.writeAPIChange(page, &c)
+		}
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(w)
+}
+
+func (ds *docServer) writeAPIChange(page *htmlPage, c *code.APIChange) {
+	var class, sign = "api-changed", "~"
+	switch {
+	case c.Added():
+		class, sign = "api-added", "+"
+	case c.Removed():
+		class, sign = "api-removed", "-"
+	}
+	fmt.Fprintf(page, "\n\t\t"+`<span class="%s">%s</span> %s `, class, sign, ds.currentTranslation.Text_APIChangeKind(c.Kind))
+	if c.Kind != "package" {
+		WriteHtmlEscapedBytes(page, []byte(c.Name))
+		page.WriteString(": ")
+	}
+	switch {
+	case c.Added():
+		WriteHtmlEscapedBytes(page, []byte(c.New))
+	case c.Removed():
+		WriteHtmlEscapedBytes(page, []byte(c.Old))
+	default:
+		WriteHtmlEscapedBytes(page, []byte(c.Old))
+		page.WriteString(" → ")
+		WriteHtmlEscapedBytes(page, []byte(c.New))
+	}
+	if c.Breaking {
+		page.WriteString(` <span class="api-breaking">`)
+		page.WriteString(ds.currentTranslation.Text_BreakingChange())
+		page.WriteString(`</span>`)
+	}
+}
+
+// genAPIDiff computes the API diff synchronously in the docs generation mode.
+func (ds *docServer) genAPIDiff(refs string, args []string, parseOptions code.ParseOptions) {
+	oldRef, newRef, err := parseAPIDiffRefs(refs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ds.apiDiff = &apiDiffResult{oldRef: oldRef, newRef: newRef}
+	ds.computeAPIDiff(args, parseOptions)
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// writeAsmImplementationLinks writes the links to the assembly
+// implementations of a body-less function, one for each architecture.
+func (ds *docServer) writeAsmImplementationLinks(page *htmlPage, f *code.Function) {
+	impls := f.AsmImplementations()
+	if len(impls) == 0 {
+		return
+	}
+	fmt.Fprintf(page, ` <i class="asm-impls">%s:`, ds.currentTranslation.Text_AssemblyImplementations())
+	for _, af := range impls {
+		bareFilename := filepath.Base(af.File)
+		text := af.Arch
+		if text == "" || strings.ContainsAny(text, " !&|()") {
+			text = bareFilename
+		}
+		page.WriteByte(' ')
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeSource, af.Pkg.Path() + "/" + bareFilename}, page, text, "asm-", af.Name)
+	}
+	page.WriteString(`</i>`)
+}
+
+var asmPseudoOps = map[string]bool{
+	"TEXT": true, "DATA": true, "GLOBL": true,
+	"FUNCDATA": true, "PCDATA": true, "NO_LOCAL_POINTERS": true,
+}
+
+// buildAssemblyLines renders the lines of an assembly file with simple
+// highlighting. The symbols in TEXT directives are rendered as anchors
+// (with ids like "asm-Sqrt") and linked to their Go declarations.
+func (ds *docServer) buildAssemblyLines(currentPathInfo pagePathInfo, fileInfo *code.SourceFileInfo, content []byte) []string {
+	var textLines = make(map[int]*code.AsmFunction)
+	for _, af := range fileInfo.Pkg.AsmFunctions() {
+		if af.File == fileInfo.OriginalFile {
+			textLines[af.Line] = af
+		}
+	}
+
+	var lines []string
+	var buf bytes.Buffer
+	var inComment bool
+	for lineNumber, data := 1, content; len(data) > 0; lineNumber++ {
+		var line []byte
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			line, data = data, nil
+		}
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+
+		// Comments in the line.
+		var comment []byte
+		if inComment {
+			i := bytes.Index(line, []byte("*/"))
+			if i < 0 {
+				i = len(line)
+			} else {
+				i += 2
+				inComment = false
+			}
+			buf.WriteString(`<span class="comment">`)
+			WriteHtmlEscapedBytes(&buf, line[:i])
+			buf.WriteString(`</span>`)
+			line = line[i:]
+		}
+		if i := bytes.Index(line, []byte("//")); i >= 0 {
+			line, comment = line[:i], line[i:]
+		}
+		if i := bytes.Index(line, []byte("/*")); i >= 0 {
+			if !bytes.Contains(line[i:], []byte("*/")) {
+				inComment = true
+			}
+			line, comment = line[:i], append(line[i:len(line):len(line)], comment...)
+		}
+
+		af := textLines[lineNumber]
+		ds.writeAssemblyCode(&buf, currentPathInfo, line, af)
+		if len(comment) > 0 {
+			buf.WriteString(`<span class="comment">`)
+			WriteHtmlEscapedBytes(&buf, comment)
+			buf.WriteString(`</span>`)
+		}
+
+		lines = append(lines, buf.String())
+		buf.Reset()
+	}
+	return lines
+}
+
+// writeAssemblyCode writes the code (without comments) part of a line.
+func (ds *docServer) writeAssemblyCode(buf *bytes.Buffer, currentPathInfo pagePathInfo, text []byte, af *code.AsmFunction) {
+	trimmed := bytes.TrimLeft(text, " \t")
+	buf.Write(text[:len(text)-len(trimmed)])
+	text = trimmed
+
+	if len(text) > 0 && text[0] == '#' { // preprocessor directives
+		end := bytes.IndexAny(text, " \t")
+		if end < 0 {
+			end = len(text)
+		}
+		buf.WriteString(`<span class="keyword">`)
+		WriteHtmlEscapedBytes(buf, text[:end])
+		buf.WriteString(`</span>`)
+		text = text[end:]
+		if i := bytes.IndexAny(text, `"<`); i >= 0 {
+			WriteHtmlEscapedBytes(buf, text[:i])
+			buf.WriteString(`<span class="lit-string">`)
+			WriteHtmlEscapedBytes(buf, text[i:])
+			buf.WriteString(`</span>`)
+		} else {
+			WriteHtmlEscapedBytes(buf, text)
+		}
+		return
+	}
+
+	end := bytes.IndexAny(text, " \t")
+	if end < 0 {
+		end = len(text)
+	}
+	if !asmPseudoOps[string(text[:end])] {
+		WriteHtmlEscapedBytes(buf, text)
+		return
+	}
+	buf.WriteString(`<span class="keyword">`)
+	buf.Write(text[:end])
+	buf.WriteString(`</span>`)
+	text = text[end:]
+
+	if af == nil {
+		WriteHtmlEscapedBytes(buf, text)
+		return
+	}
+
+	// The symbol of a TEXT directive.
+	i := bytes.Index(text, []byte("·"+af.Name))
+	if i < 0 {
+		WriteHtmlEscapedBytes(buf, text)
+		return
+	}
+	i += len("·")
+	WriteHtmlEscapedBytes(buf, text[:i])
+	fmt.Fprintf(buf, `<span class="anchor" id="asm-%s">`, af.Name)
+	if af.Func != nil {
+		pos := af.Func.Position()
+		buf.WriteString(`<a href="`)
+		buf.WriteString(buildSrouceCodeLineLink(currentPathInfo, ds.analyzer, af.Func.Pkg, pos))
+		buf.WriteString(`">`)
+		buf.WriteString(af.Name)
+		buf.WriteString(`</a>`)
+	} else {
+		buf.WriteString(af.Name)
+	}
+	buf.WriteString(`</span>`)
+	WriteHtmlEscapedBytes(buf, text[i+len(af.Name):])
+}
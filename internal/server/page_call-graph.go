@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// name is in the form "Func" or "Type.Method".
+func (ds *docServer) callGraphPage(w http.ResponseWriter, r *http.Request, pkgPath, name string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeCallGraph,
+		res:     [...]string{pkgPath, name},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := ds.buildCallGraphData(pkgPath, name)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "error: ", err)
+			return
+		}
+
+		data = ds.buildCallGraphPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type CallGraphResult struct {
+	Function *code.Function
+	Name     string // "Func" or "Type.Method"
+	Callers  []*code.CallSite
+	Callees  []*code.CallSite
+}
+
+func (ds *docServer) buildCallGraphData(pkgPath, name string) (*CallGraphResult, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, fmt.Errorf("package %s is not found", pkgPath)
+	}
+	f := ds.analyzer.FunctionByName(pkg, name)
+	if f == nil {
+		return nil, fmt.Errorf("function %s is not found in package %s", name, pkgPath)
+	}
+
+	callers := ds.analyzer.Callers(f)
+	sort.Slice(callers, func(i, j int) bool {
+		a, b := callers[i], callers[j]
+		if pa, pb := a.Caller.Pkg.Path(), b.Caller.Pkg.Path(); pa != pb {
+			return pa < pb
+		}
+		pa, pb := a.Position(), b.Position()
+		if pa.Filename != pb.Filename {
+			return pa.Filename < pb.Filename
+		}
+		return pa.Offset < pb.Offset
+	})
+
+	return &CallGraphResult{
+		Function: f,
+		Name:     name,
+		Callers:  callers,
+		Callees:  ds.analyzer.Callees(f), // already in the source order
+	}, nil
+}
+
+func (ds *docServer) buildCallGraphPage(w http.ResponseWriter, result *CallGraphResult) []byte {
+	pkg := result.Function.Pkg
+	qualifiedName := pkg.Path() + "." + result.Name
+	title := ds.currentTranslation.Text_CallGraph() + ds.currentTranslation.Text_Colon(true) + qualifiedName
+	page := NewHtmlPage(ds.goldVersion, title, ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeCallGraph, pkg.Path() + ".." + result.Name})
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:x-large;">func <b><a href="%s">%s</a>.`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, nil, ""),
+		pkg.Path(),
+	)
+	writeSrouceCodeLineLink(page, pkg, result.Function.Position(), result.Name, "")
+	page.WriteString("</b></span>\n")
+
+	fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_Callers(len(result.Callers)), `</span>`)
+	var lastPkg *code.Package
+	for _, site := range result.Callers {
+		if callerPkg := site.Caller.Pkg; callerPkg != lastPkg {
+			lastPkg = callerPkg
+			page.WriteString("\n\t")
+			if callerPkg == pkg {
+				page.WriteString(callerPkg.Path())
+				page.WriteString(" ")
+				page.WriteString(ds.currentTranslation.Text_CurrentPackage())
+			} else {
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, callerPkg.Path()}, page, callerPkg.Path())
+			}
+		}
+		page.WriteString("\n\t\t")
+		ds.writeCallSiteLink(page, site)
+		page.WriteByte(' ')
+		ds.writeFunctionCallGraphLink(page, site.Caller, false)
+		if site.Dynamic() {
+			page.WriteByte(' ')
+			page.WriteString(ds.currentTranslation.Text_CallThroughInterfaceMethod(interfaceMethodName(site.Interface)))
+		}
+	}
+	page.WriteString("\n")
+
+	fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_Callees(len(result.Callees)), `</span>`)
+	for i, site := range result.Callees {
+		page.WriteString("\n\t")
+		ds.writeCallSiteLink(page, site)
+		page.WriteByte(' ')
+		if !site.Dynamic() {
+			ds.writeFunctionCallGraphLink(page, site.Callee, site.Callee.Pkg != pkg)
+			continue
+		}
+
+		page.WriteString(interfaceMethodName(site.Interface))
+		page.WriteByte(' ')
+		possibleCallees := ds.analyzer.PossibleCallees(site)
+		if len(possibleCallees) == 0 {
+			page.WriteString(ds.currentTranslation.Text_PossibleCallees(0))
+			continue
+		}
+		sort.Slice(possibleCallees, func(i, j int) bool {
+			a, b := possibleCallees[i], possibleCallees[j]
+			if pa, pb := a.Pkg.Path(), b.Pkg.Path(); pa != pb {
+				return pa < pb
+			}
+			return code.FuncDeclName(a.AstDecl) < code.FuncDeclName(b.AstDecl)
+		})
+		writeNamedStatTitle(page, fmt.Sprint("call-", i), "callees",
+			ds.currentTranslation.Text_PossibleCallees(len(possibleCallees)),
+			func() {
+				for _, f := range possibleCallees {
+					page.WriteString("\n\t\t")
+					ds.writeFunctionCallGraphLink(page, f, true)
+				}
+			}, false)
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+func (ds *docServer) writeCallSiteLink(page *htmlPage, site *code.CallSite) {
+	pos := site.Position()
+	writeSrouceCodeLineLink(page, site.Caller.Pkg, pos, fmt.Sprintf("%s#L%d", site.FileInfo.AstBareFileName(), pos.Line), "")
+}
+
+// writeFunctionCallGraphLink writes the name of a function
+// which links to the call graph page of the function.
+func (ds *docServer) writeFunctionCallGraphLink(page *htmlPage, f *code.Function, qualified bool) {
+	name := code.FuncDeclName(f.AstDecl)
+	if qualified {
+		page.WriteString(f.Pkg.PPkg.Name)
+		page.Write(period)
+	}
+	if !hasCallGraphPage(name) {
+		page.WriteString(name)
+		return
+	}
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeCallGraph, f.Pkg.Path() + ".." + name}, page, name)
+}
+
+// hasCallGraphPage reports whether or not there is a call graph page
+// for a function. Init and blank functions can't be looked up by names.
+func hasCallGraphPage(funcDeclName string) bool {
+	return buildCallGraphPages && funcDeclName != "init" &&
+		funcDeclName != "_" && !strings.HasSuffix(funcDeclName, "._")
+}
+
+// writeCallGraphLink writes a small link to the call graph page of
+// a function declared in a package.
+func (ds *docServer) writeCallGraphLink(page *htmlPage, pkg *code.Package, name string) {
+	if !hasCallGraphPage(name) {
+		return
+	}
+	page.WriteString(` <i class="call-graph">`)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeCallGraph, pkg.Path() + ".." + name}, page, ds.currentTranslation.Text_CallGraph())
+	page.WriteString(`</i>`)
+}
+
+// interfaceMethodName returns a name like "io.Reader.Read".
+func interfaceMethodName(m *types.Func) string {
+	recv := m.Type().(*types.Signature).Recv().Type()
+	switch t := types.Unalias(recv).(type) {
+	case *types.Named:
+		if t.Obj().Pkg() == nil { // error.Error
+			return t.Obj().Name() + "." + m.Name()
+		}
+		return t.Obj().Pkg().Name() + "." + t.Obj().Name() + "." + m.Name()
+	}
+	return "interface{...}." + m.Name()
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"go101.org/gold/code"
+)
+
+// The max number of the functions listed on the most complex functions page.
+const maxComplexFunctions = 100
+
+func (ds *docServer) complexFunctionsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "complexity",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		pkgs := packagesUnderDirectory(ds.analyzer, ds.workingDirectory)
+		funcs := ds.analyzer.MostComplexFunctions(pkgs, maxComplexFunctions)
+		data = ds.buildComplexFunctionsPage(w, funcs)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildComplexFunctionsPage(w http.ResponseWriter, funcs []*code.Function) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_MostComplexFunctions(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "complexity"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		ds.currentTranslation.Text_MostComplexFunctions(),
+	)
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code></pre>`, ds.currentTranslation.Text_MostComplexFunctionsStat(len(funcs), maxComplexFunctions))
+	page.WriteString(`<table class="function-metrics"><tr>`)
+	for _, column := range functionMetricsColumns {
+		fmt.Fprintf(page, "<th>%s</th>", ds.currentTranslation.Text_FunctionMetricsColumn(column))
+	}
+	page.WriteString("</tr>")
+	for _, f := range funcs {
+		m, _ := f.Metrics()
+		page.WriteString("\n<tr><td>")
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, f.Package().Path()}, page, f.Package().Path())
+		page.WriteString(".")
+		writeSrouceCodeLineLink(page, f.Package(), f.Position(), functionMetricsName(f), "")
+		fmt.Fprintf(page, "</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>",
+			m.Statements, m.Lines, m.Complexity, m.MaxNesting, m.Params, m.Results, m.Returns)
+	}
+	page.WriteString("\n</table>\n")
+
+	return page.Done(w)
+}
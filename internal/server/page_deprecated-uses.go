@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) deprecatedUsesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "deprecated",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		uses := ds.analyzer.DeprecatedUses(packagesUnderDirectory(ds.analyzer, ds.workingDirectory))
+		data = ds.buildDeprecatedUsesPage(w, uses)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildDeprecatedUsesPage(w http.ResponseWriter, uses []code.DeprecatedUse) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_DeprecatedUses(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "deprecated"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		ds.currentTranslation.Text_DeprecatedUses(),
+	)
+
+	var numPkgs int
+	for i, u := range uses {
+		if i == 0 || u.FileInfo.Pkg != uses[i-1].FileInfo.Pkg {
+			numPkgs++
+		}
+	}
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_DeprecatedUsesStat(len(uses), numPkgs))
+	for i, u := range uses {
+		pkg := u.FileInfo.Pkg
+		if i == 0 || pkg != uses[i-1].FileInfo.Pkg {
+			page.WriteString("\n\n\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, page, pkg.Path())
+		}
+		page.WriteString("\n\t\t")
+		writeSrouceCodeLineLink(page, pkg, u.Position, fmt.Sprintf("%s:%d", u.FileInfo.AstBareFileName(), u.Position.Line), "")
+		page.WriteByte(' ')
+
+		declPkgPath := u.Object.Pkg().Path()
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, declPkgPath}, page, declPkgPath)
+		page.WriteByte('.')
+		if declPkg := ds.analyzer.PackageByPath(declPkgPath); declPkg != nil {
+			writeSrouceCodeLineLink(page, declPkg, declPkg.PPkg.Fset.PositionFor(u.Object.Pos(), false), u.Name, "")
+		} else {
+			page.WriteString(u.Name)
+		}
+
+		page.WriteString(` <span class="deprecation-notice">`)
+		WriteHtmlEscapedBytes(page, []byte(strings.Join(strings.Fields(u.Notice), " ")))
+		page.WriteString(`</span>`)
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(w)
+}
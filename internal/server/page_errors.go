@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"go/token"
+	"net/http"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) errorsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "errors",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildErrorsPage(w, ds.analyzer.IllTypedPackages())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildErrorsPage(w http.ResponseWriter, pkgs []*code.Package) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Errors(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "errors"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		ds.currentTranslation.Text_Errors(),
+	)
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_IllTypedPackages(len(pkgs)))
+	for _, pkg := range pkgs {
+		fmt.Fprintf(page, `<div class="anchor" id="pkg-%s">`, pkg.Path())
+		page.WriteString("<code>\t")
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, page, pkg.Path())
+		for _, e := range pkg.Errors {
+			page.WriteString("\n\t\t")
+			ds.writePackageErrorPosition(page, pkg, e.Position)
+			page.WriteString(`<span class="package-error">`)
+			WriteHtmlEscapedBytes(page, []byte(e.Msg))
+			page.WriteString(`</span>`)
+		}
+		page.WriteString("</code></div>")
+	}
+	page.WriteString("</pre>")
+
+	return page.Done(w)
+}
+
+// writePackageErrorPosition writes nothing if the position is unknown.
+// The position links to the source line if the file is in the package.
+func (ds *docServer) writePackageErrorPosition(page *htmlPage, pkg *code.Package, pos token.Position) {
+	if pos.Filename == "" {
+		return
+	}
+
+	fileInfo := pkg.SourceFileInfoByFilePath(pos.Filename)
+	if fileInfo == nil || pos.Line <= 0 {
+		WriteHtmlEscapedBytes(page, []byte(pos.String()))
+	} else {
+		text := fmt.Sprintf("%s:%d", fileInfo.AstBareFileName(), pos.Line)
+		if pos.Column > 0 {
+			text += fmt.Sprintf(":%d", pos.Column)
+		}
+		writeSrouceCodeLineLink(page, pkg, pos, text, "") // bare filenames need no escaping
+	}
+	page.WriteString(": ")
+}
+
+// writeIllTypedMark writes a mark which links to the errors of a package.
+func (ds *docServer) writeIllTypedMark(page *htmlPage, pkg *code.Package) {
+	if !pkg.IllTyped() {
+		return
+	}
+	page.WriteString(` <span class="ill-typed">`)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "errors"}, page, ds.currentTranslation.Text_IllTyped(), "pkg-", pkg.Path())
+	page.WriteString(`</span>`)
+}
@@ -205,55 +205,66 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 		stack = stack[:0]
 	}
 
-	for _, refGroup := range result.References {
-		page.WriteString("\n\t")
-		if refGroup.Pkg.Path() == result.Package.Path() {
-			page.WriteString(refGroup.Pkg.Path())
-			page.WriteString(" <i>(current package)</i>")
-		} else {
-			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, refGroup.Pkg.Path()}, page, refGroup.Pkg.Path())
-		}
-		page.WriteByte('\n')
-
-		var fileInfo *code.SourceFileInfo
-		var lineNumber int
-		stack = stack[:0]
-		for i := range refGroup.Identifiers {
-			id := &refGroup.Identifiers[i]
-			if fileInfo != id.FileInfo {
-				if fileInfo != nil {
-					excerptCode(fileInfo)
-				}
-				lineNumber = 0
-				fileInfo = id.FileInfo
-				//page.WriteString("\t\t")
-				//writeSrouceCodeFileLink(page, refGroup.Pkg, fileInfo.AstBareFileName())
-				//page.WriteByte('\n')
+	writeReferences := func(references []*ObjectReferences) {
+		for _, refGroup := range references {
+			page.WriteString("\n\t")
+			if refGroup.Pkg.Path() == result.Package.Path() {
+				page.WriteString(refGroup.Pkg.Path())
+				page.WriteString(" <i>(current package)</i>")
+			} else {
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, refGroup.Pkg.Path()}, page, refGroup.Pkg.Path())
 			}
-
-			pos := refGroup.Pkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)
-			if lineNumber != pos.Line {
-				if lineNumber > 0 {
-					// ExcerptNearbyCode(page, id.FileInfo, id.AstIdent, pos)
-					excerptCode(fileInfo)
+			page.WriteByte('\n')
+
+			var fileInfo *code.SourceFileInfo
+			var lineNumber int
+			stack = stack[:0]
+			for i := range refGroup.Identifiers {
+				id := &refGroup.Identifiers[i]
+				if fileInfo != id.FileInfo {
+					if fileInfo != nil {
+						excerptCode(fileInfo)
+					}
+					lineNumber = 0
+					fileInfo = id.FileInfo
+					//page.WriteString("\t\t")
+					//writeSrouceCodeFileLink(page, refGroup.Pkg, fileInfo.AstBareFileName())
+					//page.WriteByte('\n')
 				}
-				//page.WriteString("\t\t\t")
-				page.WriteString("\t\t")
-				if lineNumber > 0 {
-					linkText := fmt.Sprintf("%s", fileInfo.AstBareFileName())
-					writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "path-duplicate")
-					linkText = fmt.Sprintf("#L%d", pos.Line)
-					writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "")
-				} else {
-					linkText := fmt.Sprintf("%s#L%d", fileInfo.AstBareFileName(), pos.Line)
-					writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "")
+
+				pos := refGroup.Pkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)
+				if lineNumber != pos.Line {
+					if lineNumber > 0 {
+						// ExcerptNearbyCode(page, id.FileInfo, id.AstIdent, pos)
+						excerptCode(fileInfo)
+					}
+					//page.WriteString("\t\t\t")
+					page.WriteString("\t\t")
+					if lineNumber > 0 {
+						linkText := fmt.Sprintf("%s", fileInfo.AstBareFileName())
+						writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "path-duplicate")
+						linkText = fmt.Sprintf("#L%d", pos.Line)
+						writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "")
+					} else {
+						linkText := fmt.Sprintf("%s#L%d", fileInfo.AstBareFileName(), pos.Line)
+						writeSrouceCodeLineLink(page, refGroup.Pkg, pos, linkText, "")
+					}
+					page.WriteString(": ")
+					lineNumber = pos.Line
 				}
-				page.WriteString(": ")
-				lineNumber = pos.Line
+				stack = append(stack, idpos{id: id.AstIdent, pos: pos})
 			}
-			stack = append(stack, idpos{id: id.AstIdent, pos: pos})
+			excerptCode(fileInfo)
 		}
-		excerptCode(fileInfo)
+	}
+	writeReferences(result.References)
+
+	for _, linked := range result.LinkedReferences {
+		f := linked.Function
+		page.WriteString("\n\n")
+		writeSrouceCodeLineLink(page, f.Pkg, f.Position(), f.Pkg.Path()+"."+f.Name(), "")
+		fmt.Fprintf(page, " %s:\n", ds.currentTranslation.Text_LinkedFunctionUses(linked.UsesCount))
+		writeReferences(linked.References)
 	}
 
 	page.WriteString("</code></pre>")
@@ -285,6 +296,15 @@ type ReferencesResult struct {
 	Selector   *code.Selector // non-nil for fields and methods
 	References []*ObjectReferences
 	UsesCount  int
+
+	// Only for functions connected with others by //go:linkname directives.
+	LinkedReferences []LinkedReferences
+}
+
+type LinkedReferences struct {
+	Function   *code.Function
+	References []*ObjectReferences
+	UsesCount  int
 }
 
 type ObjectReferences struct {
@@ -380,103 +400,134 @@ ResFound:
 	if obj != nil {
 		ids := ds.analyzer.ObjectReferences(obj)
 		usesCount = len(ids)
+		refs = groupObjectReferences(ids, pkgPath)
+	}
 
-		numPkgs := 0
-		var lastPkg *code.Package
-		for _, id := range ids {
-			if id.FileInfo.Pkg != lastPkg {
-				lastPkg = id.FileInfo.Pkg
-				numPkgs++
-			}
+	// The uses of the functions connected by //go:linkname directives.
+	var linkedRefs []LinkedReferences
+	if f, ok := res.(*code.Function); ok && sel == nil {
+		var linkeds = f.LinkedFroms()
+		if to := f.LinkedTo(); to != nil {
+			linkeds = append([]*code.Function{to}, linkeds...)
 		}
+		for _, linked := range linkeds {
+			ids := ds.analyzer.ObjectReferences(linked.Func)
+			linkedRefs = append(linkedRefs, LinkedReferences{
+				Function:   linked,
+				References: groupObjectReferences(ids, pkgPath),
+				UsesCount:  len(ids),
+			})
+		}
+	}
 
-		allocatedRefs := make([]ObjectReferences, numPkgs)
-		refs = make([]*ObjectReferences, numPkgs)
-		var refIndex = numPkgs - 1
-		var endIndex = len(ids) - 1
-		var register = func(startIndex int) {
-			ref := &allocatedRefs[refIndex]
-			refs[refIndex] = ref
-			ref.Identifiers = ids[startIndex : endIndex+1]
-			ref.Pkg = lastPkg
-			ref.InCurrentPkg = lastPkg.Path() == pkgPath
-			if ref.InCurrentPkg {
-				ref.CommonPath = pkgPath
-			} else {
-				ref.CommonPath = FindPackageCommonPrefixPaths(lastPkg.Path(), pkgPath)
-			}
-			refIndex--
+	return &ReferencesResult{
+		Package:    pkg,
+		Identifier: identifier,
+		Resource:   res,
+		Selector:   sel,
+		References: refs,
+		UsesCount:  usesCount,
+
+		LinkedReferences: linkedRefs,
+	}, nil
+}
+
+// groupObjectReferences groups the references by packages. The groups
+// are sorted by the closeness between their packages and the one
+// at pkgPath.
+func groupObjectReferences(ids []code.Identifier, pkgPath string) []*ObjectReferences {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	numPkgs := 0
+	var lastPkg *code.Package
+	for _, id := range ids {
+		if id.FileInfo.Pkg != lastPkg {
+			lastPkg = id.FileInfo.Pkg
+			numPkgs++
 		}
+	}
 
-		for i := endIndex; i >= 0; i-- {
-			id := &ids[i]
-			if id.FileInfo.Pkg != lastPkg {
-				register(i + 1)
-				lastPkg = id.FileInfo.Pkg
-				endIndex = i
-			}
+	allocatedRefs := make([]ObjectReferences, numPkgs)
+	refs := make([]*ObjectReferences, numPkgs)
+	var refIndex = numPkgs - 1
+	var endIndex = len(ids) - 1
+	var register = func(startIndex int) {
+		ref := &allocatedRefs[refIndex]
+		refs[refIndex] = ref
+		ref.Identifiers = ids[startIndex : endIndex+1]
+		ref.Pkg = lastPkg
+		ref.InCurrentPkg = lastPkg.Path() == pkgPath
+		if ref.InCurrentPkg {
+			ref.CommonPath = pkgPath
+		} else {
+			ref.CommonPath = FindPackageCommonPrefixPaths(lastPkg.Path(), pkgPath)
 		}
-		register(0)
-
-		/*
-			refsByPkg := make(map[*code.Package][]*ast.Ident, numPkgs)
-			for _, id := range ids {
-				dups := refsByPkg[id.FileInfo.Pkg]
-				if dups == nil {
-					dups = make([]*ast.Ident, 0, 4)
-				}
-				dups = append(dups, id.AstIdent)
-				refsByPkg[id.FileInfo.Pkg] = dups
-			}
+		refIndex--
+	}
 
-			allocatedRefs := make([]ObjectReferences, len(refsByPkg))
-			refs = make([]*ObjectReferences, len(refsByPkg))
-			i := 0
-			for pkg, ids := range refsByPkg {
-				refs[i] = &allocatedRefs[i]
-				refs[i].AstIdents = ids
-				refs[i].Pkg = pkg
-				refs[i].InCurrentPkg = pkg.Path() == pkgPath
-				if refs[i].InCurrentPkg {
-					refs[i].CommonPath = pkgPath
-				} else {
-					refs[i].CommonPath = FindPackageCommonPrefixPaths(pkg.Path(), pkgPath)
-				}
-				i++
+	for i := endIndex; i >= 0; i-- {
+		id := &ids[i]
+		if id.FileInfo.Pkg != lastPkg {
+			register(i + 1)
+			lastPkg = id.FileInfo.Pkg
+			endIndex = i
+		}
+	}
+	register(0)
+
+	/*
+		refsByPkg := make(map[*code.Package][]*ast.Ident, numPkgs)
+		for _, id := range ids {
+			dups := refsByPkg[id.FileInfo.Pkg]
+			if dups == nil {
+				dups = make([]*ast.Ident, 0, 4)
 			}
-		*/
+			dups = append(dups, id.AstIdent)
+			refsByPkg[id.FileInfo.Pkg] = dups
+		}
 
-		sort.Slice(refs, func(a, b int) bool {
-			commonA, commonB := refs[a].CommonPath, refs[b].CommonPath
-			if len(commonA) != len(commonB) {
-				if len(commonA) == len(pkgPath) {
-					return true
-				}
-				if len(commonB) == len(pkgPath) {
-					return false
-				}
-				if len(commonA) > 0 || len(commonB) > 0 {
-					return len(commonA) > len(commonB)
-				}
+		allocatedRefs := make([]ObjectReferences, len(refsByPkg))
+		refs = make([]*ObjectReferences, len(refsByPkg))
+		i := 0
+		for pkg, ids := range refsByPkg {
+			refs[i] = &allocatedRefs[i]
+			refs[i].AstIdents = ids
+			refs[i].Pkg = pkg
+			refs[i].InCurrentPkg = pkg.Path() == pkgPath
+			if refs[i].InCurrentPkg {
+				refs[i].CommonPath = pkgPath
+			} else {
+				refs[i].CommonPath = FindPackageCommonPrefixPaths(pkg.Path(), pkgPath)
 			}
-			pathA, pathB := strings.ToLower(refs[a].Pkg.Path()), strings.ToLower(refs[b].Pkg.Path())
-			r := strings.Compare(pathA, pathB)
-			if pathA == "builtin" {
+			i++
+		}
+	*/
+
+	sort.Slice(refs, func(a, b int) bool {
+		commonA, commonB := refs[a].CommonPath, refs[b].CommonPath
+		if len(commonA) != len(commonB) {
+			if len(commonA) == len(pkgPath) {
 				return true
 			}
-			if pathB == "builtin" {
+			if len(commonB) == len(pkgPath) {
 				return false
 			}
-			return r < 0
-		})
-	}
+			if len(commonA) > 0 || len(commonB) > 0 {
+				return len(commonA) > len(commonB)
+			}
+		}
+		pathA, pathB := strings.ToLower(refs[a].Pkg.Path()), strings.ToLower(refs[b].Pkg.Path())
+		r := strings.Compare(pathA, pathB)
+		if pathA == "builtin" {
+			return true
+		}
+		if pathB == "builtin" {
+			return false
+		}
+		return r < 0
+	})
 
-	return &ReferencesResult{
-		Package:    pkg,
-		Identifier: identifier,
-		Resource:   res,
-		Selector:   sel,
-		References: refs,
-		UsesCount:  usesCount,
-	}, nil
+	return refs
 }
@@ -7,6 +7,9 @@ import (
 func (ds *docServer) javascriptFile(w http.ResponseWriter, r *http.Request, themeName string) {
 	w.Header().Set("Content-Type", "application/javascript")
 	w.Write(jsFile)
+	if ds.watching {
+		w.Write(watchJsFile)
+	}
 }
 
 var jsFile = []byte(`
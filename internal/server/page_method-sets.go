@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) methodSetsPage(w http.ResponseWriter, r *http.Request, pkgPath, typeName string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeMethodSets,
+		res:     [...]string{pkgPath, typeName},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := ds.buildMethodSetsData(pkgPath, typeName)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Build method sets for (", typeName, ") in ", pkgPath, " error: ", err)
+			return
+		}
+
+		data = ds.buildMethodSetsPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type MethodSetsResult struct {
+	TypeName *code.TypeName
+
+	// Sorted by names. The method set of *T is ValueMethods+PointerOnlyMethods.
+	ValueMethods       []*code.Selector
+	PointerOnlyMethods []*code.Selector
+
+	Implements []MethodSetsImplementation
+}
+
+type MethodSetsImplementation struct {
+	Interface *code.TypeInfo
+
+	// Nil if both T and *T implement the interface. Otherwise, the methods
+	// making T not implement the interface (but *T does).
+	PointerOnlyMethods []*code.Selector
+}
+
+func (ds *docServer) buildMethodSetsData(pkgPath, typeName string) (*MethodSetsResult, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, errors.New("package not found")
+	}
+
+	var tn *code.TypeName
+	for _, t := range pkg.AllTypeNames {
+		if t.Name() == typeName {
+			tn = t
+			break
+		}
+	}
+	if tn == nil {
+		return nil, errors.New("type not found")
+	}
+	if !hasMethodSetsPage(tn) {
+		return nil, errors.New("not a non-interface named type with methods")
+	}
+
+	result := &MethodSetsResult{TypeName: tn}
+	t := tn.Denoting()
+	var pointerOnlys = make(map[string]*code.Selector)
+	for _, sel := range t.AllMethods {
+		if sel.PointerReceiverOnly() {
+			result.PointerOnlyMethods = append(result.PointerOnlyMethods, sel)
+			pointerOnlys[sel.Name()] = sel
+		} else {
+			result.ValueMethods = append(result.ValueMethods, sel)
+		}
+	}
+	var sortSelectors = func(sels []*code.Selector) {
+		sort.Slice(sels, func(i, j int) bool {
+			return sels[i].Name() < sels[j].Name()
+		})
+	}
+	sortSelectors(result.ValueMethods)
+	sortSelectors(result.PointerOnlyMethods)
+
+	for _, impl := range ds.analyzer.CleanImplements(t) {
+		msi := MethodSetsImplementation{Interface: impl.Interface}
+		if _, ok := impl.Impler.TT.(*types.Pointer); ok {
+			for _, m := range impl.Interface.AllMethods {
+				if sel := pointerOnlys[m.Name()]; sel != nil {
+					msi.PointerOnlyMethods = append(msi.PointerOnlyMethods, sel)
+				}
+			}
+			sortSelectors(msi.PointerOnlyMethods)
+		}
+		result.Implements = append(result.Implements, msi)
+	}
+	sort.Slice(result.Implements, func(i, j int) bool {
+		// Unnamed interface types are listed after named ones.
+		a, b := result.Implements[i].Interface.TypeName, result.Implements[j].Interface.TypeName
+		if a == nil || b == nil {
+			if a != nil || b != nil {
+				return a != nil
+			}
+			return result.Implements[i].Interface.TT.String() < result.Implements[j].Interface.TT.String()
+		}
+		if a.Name() != b.Name() {
+			return a.Name() < b.Name()
+		}
+		return a.Pkg.Path() < b.Pkg.Path()
+	})
+
+	return result, nil
+}
+
+func (ds *docServer) buildMethodSetsPage(w http.ResponseWriter, result *MethodSetsResult) []byte {
+	tn := result.TypeName
+	pkg := tn.Pkg
+	qualifiedName := pkg.Path() + "." + tn.Name()
+	title := ds.currentTranslation.Text_MethodSets() + ds.currentTranslation.Text_Colon(false) + qualifiedName
+	page := NewHtmlPage(ds.goldVersion, title, ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeMethodSets, pkg.Path() + ".." + tn.Name()})
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:x-large;">type <b><a href="%s">%s</a>.`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, nil, ""),
+		pkg.Path(),
+	)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, page, tn.Name(), "name-", tn.Name())
+	page.WriteString("</b></span>\n\n</code></pre>")
+
+	var writeMethods = func(sels []*code.Selector, class string) {
+		for _, sel := range sels {
+			fmt.Fprintf(page, "\n\t"+`<span class="%s">`, class)
+			ds.writeMethodForListing(page, pkg, sel, tn, false, false)
+			page.WriteString("</span>")
+			if sel.EmbeddingChain != nil {
+				page.WriteString(" <i>")
+				page.WriteString(ds.currentTranslation.Text_PromotedThrough(embeddingPath(sel.EmbeddingChain)))
+				page.WriteString("</i>")
+			}
+		}
+	}
+
+	numValueMethods := len(result.ValueMethods)
+	page.WriteString(`<table class="method-sets"><tr><td><pre><code>`)
+	fmt.Fprint(page, `<span class="title">`, ds.currentTranslation.Text_MethodSetOf(false, numValueMethods), `</span>`)
+	writeMethods(result.ValueMethods, "value-method")
+	page.WriteString(`</code></pre></td><td><pre><code>`)
+	fmt.Fprint(page, `<span class="title">`, ds.currentTranslation.Text_MethodSetOf(true, numValueMethods+len(result.PointerOnlyMethods)), `</span>`)
+	writeMethods(result.ValueMethods, "value-method")
+	writeMethods(result.PointerOnlyMethods, "pointer-only-method")
+	page.WriteString(`</code></pre></td></tr></table>`)
+
+	page.WriteString("\n<pre><code>")
+	fmt.Fprint(page, `<span class="title">`, ds.currentTranslation.Text_Implements(len(result.Implements)), `</span>`)
+	for _, impl := range result.Implements {
+		page.WriteString("\n\t")
+		ds.writeValueTType(page, impl.Interface.TT, pkg, true, nil)
+		page.WriteString(": ")
+		if impl.PointerOnlyMethods == nil {
+			page.WriteString(ds.currentTranslation.Text_ImplementedByValueAndPointer())
+			continue
+		}
+		page.WriteString(`<span class="pointer-only-method">`)
+		page.WriteString(ds.currentTranslation.Text_ImplementedByPointerOnly())
+		page.WriteString("</span> <i>(")
+		for i, sel := range impl.PointerOnlyMethods {
+			if i > 0 {
+				page.WriteString(", ")
+			}
+			ds.writeMethodForListing(page, pkg, sel, tn, false, true)
+		}
+		page.WriteString(")</i>")
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(w)
+}
+
+// embeddingPath returns a path like "Mid.*Base".
+func embeddingPath(chain *code.EmbeddedField) string {
+	var names []string
+	for ef := chain; ef != nil; ef = ef.Prev {
+		name := ef.Field.Name
+		if ef.Field.Mode == code.EmbedMode_Indirect {
+			name = "*" + name
+		}
+		names = append(names, name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, ".")
+}
+
+// hasMethodSetsPage reports whether or not there is a method sets page
+// for a type. Only non-interface named types with methods have one.
+func hasMethodSetsPage(tn *code.TypeName) bool {
+	if tn.Alias != nil || tn.Pkg.Path() == "builtin" {
+		return false
+	}
+	t := tn.Denoting()
+	if _, ok := t.TT.Underlying().(*types.Interface); ok {
+		return false
+	}
+	return len(t.AllMethods) > 0
+}
+
+// writeMethodSetsLink writes a small link to the method sets page of a type.
+func (ds *docServer) writeMethodSetsLink(page *htmlPage, tn *code.TypeName) {
+	if !hasMethodSetsPage(tn) {
+		return
+	}
+	page.WriteString(` <i class="method-sets">`)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeMethodSets, tn.Pkg.Path() + ".." + tn.Name()}, page, ds.currentTranslation.Text_MethodSets())
+	page.WriteString(`</i>`)
+}
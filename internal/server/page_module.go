@@ -3,11 +3,192 @@ package server
 import (
 	"fmt"
 	"net/http"
-)
 
-func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, rootVersion string) {
-	// w.WriteHeader(http.StatusTooEarly)
+	"go101.org/gold/code"
+)
 
+func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, modulePath string) {
 	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprint(w, "Module page is not implemented yet")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeModule,
+		res:     modulePath,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		mod := ds.analyzer.ModuleByPath(modulePath)
+		if mod == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Module (%s) not found", modulePath)
+			return
+		}
+
+		data = ds.buildModulePage(w, ds.buildModuleData(mod))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type ModuleDetails struct {
+	*code.Module
+
+	Packages []*PackageForListing
+}
+
+func (ds *docServer) buildModuleData(mod *code.Module) *ModuleDetails {
+	pkgs := make([]PackageForListing, len(mod.Pkgs))
+	result := &ModuleDetails{
+		Module:   mod,
+		Packages: make([]*PackageForListing, len(mod.Pkgs)),
+	}
+	for i, pkg := range mod.Pkgs {
+		result.Packages[i] = &pkgs[i]
+
+		result.Packages[i].Package = pkg
+		result.Packages[i].Mod = mod
+		result.Packages[i].Path = pkg.Path()
+		result.Packages[i].Remaining = pkg.Path()
+		result.Packages[i].Name = pkg.PPkg.Name
+		result.Packages[i].Index = pkg.Index
+		result.Packages[i].DepLevel = int32(pkg.DepLevel)
+		result.Packages[i].NumImportedBys = int32(len(pkg.DepedBys))
+	}
+
+	// mod.Pkgs are already sorted by paths.
+	ImprovePackagesForListing(result.Packages)
+
+	return result
+}
+
+func (ds *docServer) buildModulePage(w http.ResponseWriter, mod *ModuleDetails) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Module(mod.Root), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeModule, mod.Root})
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">module <b>%s</b></span>
+`,
+		mod.Root,
+	)
+
+	if mod.Version != "" || mod.Replacement.Path != "" {
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_ModuleItem("version"), `</span>`)
+		page.WriteString("\n\t")
+		if mod.Version != "" {
+			page.WriteString(mod.Version)
+		} else {
+			page.WriteString(mod.Root)
+		}
+		if mod.Replacement.Path != "" {
+			page.WriteString(" => ")
+			writeModuleVersion(page, mod.Replacement)
+		}
+		page.WriteByte('\n')
+	}
+
+	if mod.GoVersion != "" {
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_ModuleItem("goversion"), `</span>`)
+		page.WriteString("\n\t")
+		page.WriteString(mod.GoVersion)
+		page.WriteByte('\n')
+	}
+
+	if mod.Dir != "" {
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_ModuleItem("directory"), `</span>`)
+		page.WriteString("\n\t")
+		WriteHtmlEscapedBytes(page, []byte(mod.Dir))
+		page.WriteByte('\n')
+	}
+
+	if mod.Module != ds.analyzer.StandardModule() {
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_DependencyRelations(""), `</span>`)
+		page.WriteString("\n\t")
+		page.WriteString(ds.currentTranslation.Text_RequireStat(len(mod.Requires), len(mod.RequiredBys)))
+		page.WriteByte('\n')
+	}
+
+	if len(mod.Packages) > 0 {
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_ModuleItem("packages"), `</span>`)
+		ds.writePackagesForListing(page, mod.Packages, false, "")
+		page.WriteByte('\n')
+	}
+
+	if len(mod.Packages) > 0 && !genDocsMode {
+		scope := "mod:" + mod.Root
+		fmt.Fprint(page, "\n", `<span class="title">`, ds.currentTranslation.Text_Statistics(), `</span>`)
+		fmt.Fprintf(page, "\n\t"+`<a href="%s">%s</a>`,
+			buildStatisticsScopeHref(page.PathInfo, pagePathInfo{ResTypeNone, "statistics"}, scope),
+			ds.currentTranslation.Text_StatisticsScope(scope),
+		)
+		page.WriteByte('\n')
+	}
+
+	if len(mod.Requires) > 0 {
+		fmt.Fprint(page, "\n", `<span class="title" id="requires">`, ds.currentTranslation.Text_ModuleItem("requires"), `</span>`)
+		for _, r := range mod.Requires {
+			page.WriteString("\n\t")
+			if r.Module != nil {
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypeModule, r.Module.Root}, page, r.Path)
+			} else {
+				page.WriteString(r.Path)
+			}
+			page.WriteByte(' ')
+			page.WriteString(r.Version)
+			if r.Indirect {
+				page.WriteString(" <i>// indirect</i>")
+			}
+		}
+		page.WriteByte('\n')
+	}
+
+	if len(mod.Replaces) > 0 {
+		fmt.Fprint(page, "\n", `<span class="title" id="replaces">`, ds.currentTranslation.Text_ModuleItem("replaces"), `</span>`)
+		for _, r := range mod.Replaces {
+			page.WriteString("\n\t")
+			writeModuleVersion(page, r.Old)
+			page.WriteString(" => ")
+			writeModuleVersion(page, r.New)
+		}
+		page.WriteByte('\n')
+	}
+
+	if len(mod.RequiredBys) > 0 {
+		fmt.Fprint(page, "\n", `<span class="title" id="required-by">`, ds.currentTranslation.Text_ModuleItem("requiredbys"), `</span>`)
+		for _, by := range mod.RequiredBys {
+			page.WriteString("\n\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeModule, by.Root}, page, by.Root)
+		}
+		page.WriteByte('\n')
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+func writeModuleVersion(page *htmlPage, mv code.ModuleVersion) {
+	WriteHtmlEscapedBytes(page, []byte(mv.Path))
+	if mv.Version != "" {
+		page.WriteByte(' ')
+		page.WriteString(mv.Version)
+	}
+}
+
+// writeModuleLink writes a link to the page of a module, together with the module version.
+func writeModuleLink(page *htmlPage, mod *code.Module) {
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeModule, mod.Root}, page, mod.Root)
+	if mod.Version != "" {
+		page.WriteByte(' ')
+		page.WriteString(mod.Version)
+	}
+	if mod.Replacement.Path != "" {
+		page.WriteString(" => ")
+		writeModuleVersion(page, mod.Replacement)
+	}
 }
@@ -103,6 +103,34 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 
 	ds.writeSimpleStatsBlock(page, &overview.Stats)
 
+	fmt.Fprint(page, "\n<pre><code>", `<span class="title">`, ds.currentTranslation.Text_Reports(), `</span>`, "\n\t")
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "unused"}, page, ds.currentTranslation.Text_UnusedExporteds())
+	page.WriteString("\n\t")
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "complexity"}, page, ds.currentTranslation.Text_MostComplexFunctions())
+	page.WriteString("\n\t")
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "deprecated"}, page, ds.currentTranslation.Text_DeprecatedUses())
+	if ds.apiDiff != nil {
+		page.WriteString("\n\t")
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "apidiff"}, page, ds.currentTranslation.Text_APIChanges())
+		fmt.Fprintf(page, " (%s..%s)", ds.apiDiff.oldRef, ds.apiDiff.newRef)
+	}
+	page.WriteString("\n</code></pre>\n")
+
+	if overview.NumIllTypedPackages > 0 {
+		fmt.Fprint(page, "\n<pre><code>", `<span class="title">`, ds.currentTranslation.Text_Errors(), `</span>`, "\n\t")
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "errors"}, page, ds.currentTranslation.Text_IllTypedPackages(overview.NumIllTypedPackages))
+		page.WriteString("\n</code></pre>\n")
+	}
+
+	if len(overview.Modules) > 0 {
+		fmt.Fprint(page, "\n<pre><code>", `<span class="title">`, ds.currentTranslation.Text_Modules(), `</span>`)
+		for _, mod := range overview.Modules {
+			page.WriteString("\n\t")
+			writeModuleLink(page, mod)
+		}
+		page.WriteString("\n</code></pre>\n")
+	}
+
 	page.WriteString("<pre>")
 
 	if genDocsMode {
@@ -197,6 +225,7 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 		if sortBy == "importedbys" {
 			fmt.Fprintf(page, ` <i>(%d)</i>`, pkg.NumImportedBys)
 		}
+		ds.writeIllTypedMark(page, pkg.Package)
 		page.WriteString(`</code>`)
 		if writeAnchorTarget {
 			page.WriteString(`</div>`)
@@ -249,8 +278,11 @@ func (ds *docServer) writeSimpleStatsBlock(page *htmlPage, stats *code.Stats) {
 }
 
 type Overview struct {
+	Modules  []*code.Module // the std module is always the first one
 	Packages []*PackageForListing
 
+	NumIllTypedPackages int
+
 	code.Stats
 }
 
@@ -291,7 +323,7 @@ func (ds *docServer) buildOverviewData(sortBy string) *Overview {
 		pkg.DepLevel = int32(p.DepLevel)
 		pkg.NumImportedBys = int32(len(p.DepedBys))
 
-		pkg.InWorkingDirectory = strings.HasPrefix(p.Directory, ds.workingDirectory)
+		pkg.InWorkingDirectory = isUnderDirectory(p.Directory, ds.workingDirectory)
 	}
 
 	switch sortBy {
@@ -327,9 +359,17 @@ func (ds *docServer) buildOverviewData(sortBy string) *Overview {
 		})
 	}
 
+	var mods = make([]*code.Module, ds.analyzer.NumModules())
+	for i := range mods {
+		mods[i] = ds.analyzer.ModuleAt(i)
+	}
+
 	return &Overview{
+		Modules:  mods,
 		Packages: result,
 		Stats:    ds.analyzer.Statistics(),
+
+		NumIllTypedPackages: len(ds.analyzer.IllTypedPackages()),
 	}
 }
 
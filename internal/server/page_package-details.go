@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"go101.org/gold/code"
@@ -30,6 +31,10 @@ var _ = log.Print
 type packagePageOptions struct {
 	sortBy string // "alphabet", "popularity"
 	filter string // "all", "exported"
+
+	metricsSortBy string // one of functionMetricsColumns, blank means "complexity"
+
+	hideDeprecateds bool
 }
 
 func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
@@ -74,9 +79,28 @@ func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
+	var metricsSortBy = r.FormValue("metrics")
+	if !isFunctionMetricsColumn(metricsSortBy) {
+		if ok {
+			metricsSortBy = oldOptions.metricsSortBy
+		} else {
+			metricsSortBy = ""
+		}
+	}
+
+	var hideDeprecateds = oldOptions.hideDeprecateds
+	switch r.FormValue("deprecated") {
+	case "hide":
+		hideDeprecateds = true
+	case "show":
+		hideDeprecateds = false
+	}
+
 	newOptions := packagePageOptions{
-		sortBy: sortBy,
-		filter: filter,
+		sortBy:          sortBy,
+		filter:          filter,
+		metricsSortBy:   metricsSortBy,
+		hideDeprecateds: hideDeprecateds,
 	}
 	if newOptions != oldOptions {
 		ds.cachePageOptions(pageKey, newOptions)
@@ -134,6 +158,23 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		ds.currentTranslation.Text_PackageDocsLinksOnOtherWebsites(pkg.ImportPath, pkg.IsStandard),
 	)
 
+	if mod := pkg.Package.Mod; mod != nil {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_BelongingModule(), `</span>`)
+		page.WriteString("\n\t")
+		writeModuleLink(page, mod)
+	}
+
+	if platforms := ds.analyzer.Platforms(); len(platforms) > 1 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_Platforms(platforms[0].String()), `</span>`)
+		page.WriteString("\n\t")
+		for i, p := range platforms {
+			if i > 0 {
+				page.WriteString(", ")
+			}
+			page.WriteString(p.String())
+		}
+	}
+
 	isBuiltin := pkg.ImportPath == "builtin"
 	if !isBuiltin {
 		fmt.Fprintf(page, `
@@ -146,8 +187,28 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		)
 	}
 
-	if len(pkg.Files) > 0 {
-		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_InvolvedFiles(len(pkg.Files)), `</span>`)
+	if lines := pkg.Package.Lines; lines.Lines > 0 {
+		var ratio float64
+		if lines.CodeLines() > 0 {
+			ratio = float64(lines.CommentLines) / float64(lines.CodeLines())
+		}
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s`,
+			ds.currentTranslation.Text_CodeLines(),
+			ds.currentTranslation.Text_CodeLineStat(int(lines.Lines), int(lines.CodeLines()), int(lines.CommentLines), int(lines.BlankLines), ratio),
+		)
+		if !genDocsMode {
+			fmt.Fprintf(page, ` <i><a href="%s">%s</a></i>`,
+				buildStatisticsScopeHref(page.PathInfo, pagePathInfo{ResTypeNone, "statistics"}, "pkg:"+pkg.Package.Path()),
+				ds.currentTranslation.Text_Statistics(),
+			)
+		}
+	}
+
+	if len(pkg.Files) > 0 || len(pkg.OtherPlatformFiles) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_InvolvedFiles(len(pkg.Files)+len(pkg.OtherPlatformFiles)), `</span>`)
 
 		numArrows := 0
 		for _, info := range pkg.Files {
@@ -178,6 +239,53 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				page.WriteString("    ")
 			}
 			writeSrouceCodeFileLink(page, pkg.Package, info.Filename)
+			if lines := info.Lines; lines.Lines > 0 {
+				fmt.Fprintf(page, ` <i>(%s)</i>`, ds.currentTranslation.Text_FileCodeLineStat(int(lines.Lines), int(lines.CodeLines()), int(lines.CommentLines), int(lines.BlankLines)))
+			}
+			ds.writePlatformsNote(page, ds.analyzer.FilePlatforms(pkg.Package, info.Filename))
+		}
+		// These files are not analyzed, so they are not linked.
+		for _, filename := range pkg.OtherPlatformFiles {
+			page.WriteString("\n\t")
+			if numArrows == 2 {
+				page.WriteString("    ")
+			}
+			page.WriteString("    ")
+			page.WriteString(filename)
+			ds.writePlatformsNote(page, ds.analyzer.FilePlatforms(pkg.Package, filename))
+		}
+	}
+
+	if uses := pkg.Package.DirectiveUses(); len(uses) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_Directives(len(uses)), `</span>`)
+		for i, start := 0, 0; start < len(uses); i++ {
+			end := start + 1
+			for end < len(uses) && uses[end].Name == uses[start].Name {
+				end++
+			}
+			page.WriteString("\n\t")
+			writeNamedStatTitle(page, "directives", strconv.Itoa(i),
+				fmt.Sprintf("//%s (%d)", uses[start].Name, end-start),
+				func() {
+					for _, use := range uses[start:end] {
+						page.WriteString("\n\t\t")
+						ds.writeDirectiveUse(page, pkg.Package, use)
+					}
+				}, false)
+			start = end
+		}
+	}
+
+	if pkg.NumDeprecateds > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_DeprecatedIdentifiers(pkg.NumDeprecateds), `</span>`)
+		page.WriteString("\n\t")
+		page.WriteString(ds.currentTranslation.Text_DeprecatedIdentifiersState(options.hideDeprecateds))
+		if !genDocsMode {
+			var action = "hide"
+			if options.hideDeprecateds {
+				action = "show"
+			}
+			fmt.Fprintf(page, ` (<a href="?deprecated=%s">%s</a>)`, action, ds.currentTranslation.Text_DeprecatedIdentifiersAction(!options.hideDeprecateds))
 		}
 	}
 
@@ -257,9 +365,12 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		fmt.Fprintf(page, `<div class="anchor" id="name-%s" data-popularity="%d">`, et.TypeName.Name(), et.Popularity)
 		page.WriteByte('\t')
 		ds.writeResourceIndexHTML(page, pkg.Package, et.TypeName, false)
+		ds.writePlatformsNote(page, ds.analyzer.DeclarationPlatforms(pkg.Package, et.TypeName.Name()))
+		writeDirectiveBadges(page, et.TypeName.Directives())
+		ds.writeDeprecatedBadge(page, et.TypeName.Deprecated())
 		if doc := et.TypeName.Documentation(); doc != "" {
 			page.WriteString("\n")
-			writePageText(page, "\t\t", doc, true)
+			ds.writeDocComment(page, pkg.Package, "\t\t", doc)
 		}
 
 		// ToDo: for alias, if its denoting type is an exported named type, then stop here.
@@ -278,6 +389,14 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 					}
 				}, false)
 		}
+		if layout := ds.analyzer.StructLayout(et.TypeName); layout != nil && !isBuiltin {
+			page.WriteString("\n\t\t")
+			writeNamedStatTitle(page, et.TypeName.Name(), "layout",
+				ds.currentTranslation.Text_MemoryLayout(layout.Size, layout.Padding),
+				func() {
+					ds.writeStructLayout(page, pkg.Package, layout)
+				}, false)
+		}
 		if count := len(et.Methods); count > 0 {
 			page.WriteString("\n\t\t")
 			writeNamedStatTitle(page, et.TypeName.Name(), "methods",
@@ -343,6 +462,18 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 					}
 				}, false)
 		}
+		if count := len(et.Instantiations); count > 0 {
+			page.WriteString("\n\t\t")
+			writeNamedStatTitle(page, et.TypeName.Name(), "instantiations",
+				ds.currentTranslation.Text_Instantiations(count),
+				func() {
+					insts := sortInstantiationList(et.Instantiations)
+					for _, inst := range insts {
+						page.WriteString("\n\t\t\t")
+						ds.writeValueTType(page, inst.TT, pkg.Package, true, nil)
+					}
+				}, false)
+		}
 		if count := len(et.Values); count > 0 {
 			page.WriteString("\n\t\t")
 			writeNamedStatTitle(page, et.TypeName.Name(), "values",
@@ -361,7 +492,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 WriteValues:
 	if len(pkg.ValueResources) == 0 {
-		goto Done
+		goto WriteTests
 	}
 
 	if needOneMoreLine {
@@ -378,22 +509,262 @@ WriteValues:
 		fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, v.Name())
 		page.WriteByte('\t')
 		ds.writeResourceIndexHTML(page, pkg.Package, v, false)
+		ds.writePlatformsNote(page, ds.analyzer.DeclarationPlatforms(pkg.Package, v.Name()))
+		if d, ok := v.(interface{ Directives() []code.Directive }); ok {
+			writeDirectiveBadges(page, d.Directives())
+		}
+		ds.writeDeprecatedBadge(page, v.Deprecated())
 		if doc := v.Documentation(); doc != "" {
 			page.WriteString("\n")
-			writePageText(page, "\t\t", doc, true)
+			ds.writeDocComment(page, pkg.Package, "\t\t", doc)
+		}
+		if v, ok := v.(*code.Variable); ok && len(v.EmbedPatterns()) > 0 {
+			ds.writeEmbedFiles(page, pkg.Package, v)
+		}
+		if f, ok := v.(*code.Function); ok && (f.LinkedTo() != nil || len(f.LinkedFroms()) > 0) {
+			page.WriteString("\n\t\t")
+			ds.writeLinknameLinks(page, page.PathInfo, f)
 		}
 		page.WriteString("</div>")
 	}
 
-Done:
+	if len(pkg.OtherPlatformDeclarations) > 0 {
+		page.WriteString("\n\n")
+		fmt.Fprint(page, `<span class="title">`, ds.currentTranslation.Text_OtherPlatformDeclarations(len(pkg.OtherPlatformDeclarations)), `</span>`)
+		page.WriteByte('\n')
+		for _, decl := range pkg.OtherPlatformDeclarations {
+			page.WriteString("\n\t")
+			writeOtherPlatformDeclaration(page, decl)
+			ds.writePlatformsNote(page, decl.Platforms)
+		}
+	}
+
+WriteTests:
+	for kind, start := code.TestFunc_Test, 0; start < len(pkg.TestFunctions); kind++ {
+		end := start
+		for end < len(pkg.TestFunctions) && pkg.TestFunctions[end].TestFuncKind() == kind {
+			end++
+		}
+		if end == start {
+			continue
+		}
+
+		page.WriteString("\n\n")
+		fmt.Fprint(page, `<span class="title">`, ds.currentTranslation.Text_TestFunctions(testFuncKindNames[kind], end-start), `</span>`)
+		page.WriteByte('\n')
+		for _, f := range pkg.TestFunctions[start:end] {
+			anchorName := f.Name()
+			if f.Pkg != pkg.Package {
+				// Functions with the same name might be declared
+				// in both the package and its external test package.
+				anchorName = f.Pkg.PPkg.Name + "." + anchorName
+			}
+			page.WriteByte('\n')
+			fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, anchorName)
+			page.WriteByte('\t')
+			ds.writeResourceIndexHTML(page, f.Pkg, f, false)
+			page.WriteString("</div>")
+		}
+		start = end
+	}
+
 	page.WriteString("</code></pre>")
+	ds.writeFunctionMetrics(page, pkg.Package, options)
 	return page.Done(w)
 }
 
+// writeDeprecatedBadge writes a badge for a deprecated identifier.
+// The deprecation notice is shown as the badge title.
+func (ds *docServer) writeDeprecatedBadge(page *htmlPage, notice string) {
+	if notice == "" {
+		return
+	}
+	page.WriteString(` <span class="deprecated" title="`)
+	WriteHtmlEscapedBytes(page, []byte(notice))
+	page.WriteString(`">`)
+	page.WriteString(ds.currentTranslation.Text_Deprecated())
+	page.WriteString(`</span>`)
+}
+
+// writeDirectiveBadges writes the names of the directives of a declaration
+// as badges. The full directive texts are shown as the badge titles.
+func writeDirectiveBadges(page *htmlPage, directives []code.Directive) {
+	for _, d := range directives {
+		page.WriteString(` <span class="directive" title="`)
+		WriteHtmlEscapedBytes(page, []byte(d.String()))
+		page.WriteString(`">`)
+		page.WriteString(d.Name)
+		page.WriteString(`</span>`)
+	}
+}
+
+// writeDirectiveUse writes a link to where a directive is used, followed by
+// the directive arguments. The link text is the declaration name or the file name.
+func (ds *docServer) writeDirectiveUse(page *htmlPage, pkg *code.Package, use code.DirectiveUse) {
+	pos := pkg.PPkg.Fset.PositionFor(use.Pos, false)
+	var text string
+	switch res := use.Resource.(type) {
+	case nil:
+		text = filepath.Base(pos.Filename)
+		if info := pkg.SourceFileInfoByFilePath(pos.Filename); info != nil {
+			text = info.AstBareFileName()
+		}
+	case *code.Function:
+		text = functionMetricsName(res)
+	default:
+		text = res.Name()
+	}
+	writeSrouceCodeLineLink(page, pkg, pos, text, "")
+	if use.Args != "" {
+		page.WriteString(` <span class="comment">`)
+		WriteHtmlEscapedBytes(page, []byte(use.Args))
+		page.WriteString(`</span>`)
+	}
+}
+
+// writeEmbedFiles writes the //go:embed patterns of a variable
+// and the files matched by the patterns.
+func (ds *docServer) writeEmbedFiles(page *htmlPage, pkg *code.Package, v *code.Variable) {
+	page.WriteString("\n\t\t<i class=\"embed-files\">//go:embed")
+	for _, pattern := range v.EmbedPatterns() {
+		page.WriteByte(' ')
+		WriteHtmlEscapedBytes(page, []byte(pattern))
+	}
+	files := v.EmbedFiles()
+	page.WriteString("\n\t\t")
+	page.WriteString(ds.currentTranslation.Text_EmbeddedFiles(len(files)))
+	for _, f := range files {
+		page.WriteByte(' ')
+		if pkg.SourceFileInfoByBareFilename(f) != nil {
+			writeSrouceCodeFileLink(page, pkg, f)
+		} else {
+			WriteHtmlEscapedBytes(page, []byte(f))
+		}
+	}
+	page.WriteString("</i>")
+}
+
+var functionMetricsColumns = [...]string{"name", "statements", "lines", "complexity", "nesting", "params", "results", "returns"}
+
+func isFunctionMetricsColumn(column string) bool {
+	for _, c := range functionMetricsColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// functionMetricsName returns names like "F", "T.M" and "(*T).M".
+// The receiver type is omitted if it is invalid.
+func functionMetricsName(f *code.Function) string {
+	if !f.IsMethod() {
+		return f.Name()
+	}
+	_, typeIdent, isStar, ok := f.ReceiverTypeName()
+	if !ok {
+		return f.Name()
+	}
+	if isStar {
+		return "(*" + typeIdent.Name + ")." + f.Name()
+	}
+	return typeIdent.Name + "." + f.Name()
+}
+
+func (ds *docServer) writeFunctionMetrics(page *htmlPage, pkg *code.Package, options packagePageOptions) {
+	type functionMetrics struct {
+		*code.Function
+		name string
+		code.FunctionMetrics
+	}
+	var funcs []functionMetrics
+	for _, f := range pkg.AllFunctions {
+		m, ok := f.Metrics()
+		if !ok {
+			continue
+		}
+		if f.IsMethod() {
+			_, typeIdent, _, ok := f.ReceiverTypeName()
+			if !ok || options.filter != "all" && !typeIdent.IsExported() {
+				continue
+			}
+		}
+		if options.filter != "all" && !f.Exported() {
+			continue
+		}
+		funcs = append(funcs, functionMetrics{f, functionMetricsName(f), m})
+	}
+	if len(funcs) == 0 {
+		return
+	}
+
+	sortBy := options.metricsSortBy
+	if sortBy == "" {
+		sortBy = "complexity"
+	}
+	var value = func(m *functionMetrics) int32 {
+		switch sortBy {
+		case "statements":
+			return m.Statements
+		case "lines":
+			return m.Lines
+		case "nesting":
+			return m.MaxNesting
+		case "params":
+			return m.Params
+		case "results":
+			return m.Results
+		case "returns":
+			return m.Returns
+		}
+		return m.Complexity
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if sortBy == "name" {
+			return funcs[i].name < funcs[j].name
+		}
+		if vi, vj := value(&funcs[i]), value(&funcs[j]); vi != vj {
+			return vi > vj
+		}
+		return funcs[i].name < funcs[j].name
+	})
+
+	fmt.Fprintf(page, `
+<pre><code><span class="title" id="function-metrics">%s</span></code></pre>
+<table class="function-metrics"><tr>`,
+		ds.currentTranslation.Text_FunctionMetrics(len(funcs)),
+	)
+	for _, column := range functionMetricsColumns {
+		text := ds.currentTranslation.Text_FunctionMetricsColumn(column)
+		if genDocsMode || column == sortBy {
+			fmt.Fprintf(page, "<th>%s</th>", text)
+		} else {
+			fmt.Fprintf(page, `<th><a href="?metrics=%s#function-metrics">%s</a></th>`, column, text)
+		}
+	}
+	page.WriteString("</tr>")
+	for i := range funcs {
+		m := &funcs[i]
+		page.WriteString("\n<tr><td>")
+		writeSrouceCodeLineLink(page, pkg, m.Position(), m.name, "")
+		fmt.Fprintf(page, "</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>",
+			m.Statements, m.Lines, m.Complexity, m.MaxNesting, m.Params, m.Results, m.Returns)
+	}
+	page.WriteString("\n</table>\n")
+}
+
+var testFuncKindNames = [...]string{
+	code.TestFunc_Test:      "test",
+	code.TestFunc_Benchmark: "benchmark",
+	code.TestFunc_Fuzz:      "fuzz",
+	code.TestFunc_Example:   "example",
+}
+
 type FileInfo struct {
 	Filename     string
 	MainPosition *token.Position // for main packages only
 	HasDocs      bool
+	Lines        code.LineStats // only for Go files
 }
 
 type PackageDetails struct {
@@ -413,8 +784,20 @@ type PackageDetails struct {
 	//UnexportedTypeNames []*code.TypeName
 	ExportedTypeNames []*ExportedType // also including unexported ones when "show=all" query parameter is set.
 
+	// Sorted by kinds then names. Including the ones in the external test package.
+	TestFunctions []*code.Function
+
+	// The source files and the package-level declarations which are only
+	// on the non-primary platforms (in the multi-platform mode).
+	OtherPlatformFiles        []string
+	OtherPlatformDeclarations []code.PlatformDeclaration
+
 	HasHiddenTypeNames bool
 
+	// The number of the deprecated identifiers in the lists,
+	// including the hidden ones.
+	NumDeprecateds int
+
 	// Line dismatches exist in some cgo generated files.
 	//FileLineNumberOffsets map[string][]int
 
@@ -458,6 +841,10 @@ type ExportedType struct {
 	AsInputsOf  []ValueForListing
 	AsOutputsOf []ValueForListing
 
+	// For generic types only.
+	// The instantiated types used in code.
+	Instantiations []*code.TypeInfo
+
 	Popularity int
 }
 
@@ -499,6 +886,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			files = append(files, FileInfo{
 				Filename: info.BareFilename,
 				HasDocs:  info.AstFile != nil && info.AstFile.Doc != nil,
+				Lines:    info.Lines,
 			})
 		}
 
@@ -533,23 +921,49 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 		}
 	}
 
+	// Deprecated identifiers are counted even if they are hidden.
+	var numDeprecateds int
+	var isHiddenDeprecated = func(res interface{ Deprecated() string }) bool {
+		if res.Deprecated() == "" {
+			return false
+		}
+		numDeprecateds++
+		return options.hideDeprecateds
+	}
+	var filterSelectors = func(sels []*code.Selector) []*code.Selector {
+		var filtered = sels[:0]
+		for _, sel := range sels {
+			if sel.EmbeddingChain != nil && sel.Deprecated() != "" && options.hideDeprecateds {
+				continue // promoted selectors are not counted
+			}
+			if sel.EmbeddingChain == nil && isHiddenDeprecated(sel) {
+				continue
+			}
+			filtered = append(filtered, sel)
+		}
+		return filtered
+	}
+
 	// ...
 	var valueResources = make([]code.ValueResource, 0,
 		len(pkg.PackageAnalyzeResult.AllConstants)+
 			len(pkg.PackageAnalyzeResult.AllVariables)+
 			len(pkg.PackageAnalyzeResult.AllFunctions))
 	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
-		if c.Exported() {
+		if c.Exported() && !isHiddenDeprecated(c) {
 			valueResources = append(valueResources, c)
 		}
 	}
 	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
-		if v.Exported() {
+		if v.Exported() && !isHiddenDeprecated(v) {
 			valueResources = append(valueResources, v)
 		}
 	}
+	var testFunctions []*code.Function
 	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
-		if f.Exported() && !f.IsMethod() {
+		if f.TestFuncKind() != code.TestFunc_None {
+			testFunctions = append(testFunctions, f)
+		} else if f.Exported() && !f.IsMethod() && !isHiddenDeprecated(f) {
 			valueResources = append(valueResources, f)
 		}
 	}
@@ -558,6 +972,20 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 		return strings.ToLower(valueResources[i].Name()) < strings.ToLower(valueResources[j].Name())
 	})
 
+	if xtestPkg := analyzer.PackageByPath(pkgPath + "_test"); xtestPkg != nil {
+		for _, f := range xtestPkg.PackageAnalyzeResult.AllFunctions {
+			if f.TestFuncKind() != code.TestFunc_None {
+				testFunctions = append(testFunctions, f)
+			}
+		}
+	}
+	sort.Slice(testFunctions, func(i, j int) bool {
+		if ki, kj := testFunctions[i].TestFuncKind(), testFunctions[j].TestFuncKind(); ki != kj {
+			return ki < kj
+		}
+		return testFunctions[i].Name() < testFunctions[j].Name()
+	})
+
 	//asTypesOf := make([]code.ValueResource, 256)
 	//asParamsOf := make([]code.ValueResource, 256)
 	//asResultsOf := make([]code.ValueResource, 256)
@@ -576,7 +1004,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	var exportedTypesResources = make([]*ExportedType, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	//var unexportedTypesResources = make([]*code.TypeName, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
-		if alsoShowNonExporteds || tn.Exported() {
+		if (alsoShowNonExporteds || tn.Exported()) && !isHiddenDeprecated(tn) {
 			denoting := tn.Denoting()
 			et := &ExportedType{TypeName: tn}
 			exportedTypesResources = append(exportedTypesResources, et)
@@ -586,12 +1014,13 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 				continue
 			}
 
-			et.Fields = buildTypeFieldList(denoting, alsoShowNonExporteds)
-			et.Methods = buildTypeMethodsList(denoting, alsoShowNonExporteds)
+			et.Fields = filterSelectors(buildTypeFieldList(denoting, alsoShowNonExporteds))
+			et.Methods = filterSelectors(buildTypeMethodsList(denoting, alsoShowNonExporteds))
 			//et.ImplementedBys = make([]*code.TypeInfo, 0, len(denoting.ImplementedBys))
 			et.ImplementedBys = buildTypeImplementedByList(analyzer, denoting, alsoShowNonExporteds, tn)
 			//et.Implements = make([]code.Implementation, 0, len(denoting.Implements))
 			et.Implements = buildTypeImplementsList(analyzer, denoting, alsoShowNonExporteds)
+			et.Instantiations = denoting.Instantiations
 
 			if isBuiltin {
 				continue
@@ -633,9 +1062,15 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 					}
 				}
 				for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
-					if f.Exported() { //} && !f.IsMethod() {
-						collectAsParamsAndAsResults(f)
+					if !f.Exported() { //} && !f.IsMethod() {
+						continue
+					}
+					if f.IsMethod() {
+						if _, _, _, ok := f.ReceiverTypeName(); !ok {
+							continue // invalid receivers in ill-typed packages
+						}
 					}
+					collectAsParamsAndAsResults(f)
 				}
 
 				var nil []code.ValueResource
@@ -692,6 +1127,13 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	//	return strings.ToLower(unexportedTypesResources[i].Name()) < strings.ToLower(unexportedTypesResources[j].Name())
 	//})
 
+	var otherPlatformDecls []code.PlatformDeclaration
+	for _, decl := range analyzer.OtherPlatformDeclarations(pkg) {
+		if alsoShowNonExporteds || isExportedDeclarationName(decl.Name) {
+			otherPlatformDecls = append(otherPlatformDecls, decl)
+		}
+	}
+
 	// ...
 	return &PackageDetails{
 		//PPkg: pkg.PPkg,
@@ -708,9 +1150,15 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 		ValueResources:    valueResources,
 		ExportedTypeNames: exportedTypesResources,
 		//UnexportedTypeNames: unexportedTypesResources,
+		TestFunctions: testFunctions,
+
+		OtherPlatformFiles:        analyzer.OtherPlatformFiles(pkg),
+		OtherPlatformDeclarations: otherPlatformDecls,
 
 		HasHiddenTypeNames: len(pkg.PackageAnalyzeResult.AllTypeNames) > len(exportedTypesResources),
 
+		NumDeprecateds: numDeprecateds,
+
 		//FileLineNumberOffsets: lineStartOffsets,
 
 		NumDeps:     uint32(len(pkg.Deps)),
@@ -807,10 +1255,12 @@ func (ds *docServer) sortValueList(valueList []ValueForListing, pkg *code.Packag
 
 		if oka && okb {
 			if p, q := fa.IsMethod(), fb.IsMethod(); p && q {
-				_, ida, _ := fa.ReceiverTypeName()
-				_, idb, _ := fb.ReceiverTypeName()
-				if r := strings.Compare(strings.ToLower(ida.Name), strings.ToLower(idb.Name)); r != 0 {
-					return r < 0
+				_, ida, _, oka := fa.ReceiverTypeName()
+				_, idb, _, okb := fb.ReceiverTypeName()
+				if oka && okb {
+					if r := strings.Compare(strings.ToLower(ida.Name), strings.ToLower(idb.Name)); r != 0 {
+						return r < 0
+					}
 				}
 			} else if p != q {
 				return q
@@ -918,7 +1368,8 @@ func (ds *docServer) writeValueForListing(page *htmlPage, v *ValueForListing, pk
 
 		if res.IsMethod() {
 			// note: recvParam might be nil for interface method.
-			recvParam, typeId, isStar := res.ReceiverTypeName()
+			// Methods with invalid receivers are not listed.
+			recvParam, typeId, isStar, _ := res.ReceiverTypeName()
 			if isStar {
 				if v.Package() != pkg {
 					//fmt.Fprintf(page, `(*<a href="/pkg:%[1]s#name-%[2]s">%[2]s</a>).`, v.Package().Path(), typeId.Name)
@@ -1018,6 +1469,16 @@ func (ds *docServer) sortTypeList(typeList []TypeForListing, pkg *code.Package)
 	return result
 }
 
+// sortInstantiationList sorts instantiated types by their type strings.
+func sortInstantiationList(insts []*code.TypeInfo) []*code.TypeInfo {
+	result := make([]*code.TypeInfo, len(insts))
+	copy(result, insts)
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].TT.String() < result[b].TT.String()
+	})
+	return result
+}
+
 const (
 	DotMStyle_Unexported = -1
 	DotMStyle_NotShow    = 0
@@ -1218,6 +1679,36 @@ func (ds *docServer) writeFieldForListing(page *htmlPage, pkg *code.Package, sel
 	page.WriteString(" <i>")
 	ds.WriteAstType(page, sel.Field.AstField.Type, sel.Field.Pkg, pkg, true, nil, forTypeName)
 	page.WriteString("</i>")
+	ds.writeDeprecatedBadge(page, sel.Selector.Deprecated())
+}
+
+func (ds *docServer) writeStructLayout(page *htmlPage, pkg *code.Package, layout *code.StructLayout) {
+	var qualifier = func(p *types.Package) string {
+		if p == pkg.PPkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+
+	fmt.Fprintf(page, "\n\t\t\t<i>%s</i>", ds.currentTranslation.Text_MemoryLayoutColumns())
+	for _, f := range layout.Fields {
+		fmt.Fprintf(page, "\n\t\t\t%6d %5d %5d  %s ", f.Offset, f.Size, f.Align, f.Name)
+		WriteHtmlEscapedBytes(page, []byte(types.TypeString(f.Type, qualifier)))
+		if f.Padding > 0 {
+			fmt.Fprintf(page, "\n\t\t\t%6d %5d        <i>%s</i>", f.Offset+f.Size, f.Padding, ds.currentTranslation.Text_PaddingBytes(f.Padding))
+		}
+	}
+	platform := ds.analyzer.TargetPlatform()
+	fmt.Fprintf(page, "\n\t\t\t<i>%s</i>", ds.currentTranslation.Text_MemoryLayoutSummary(layout.Size, layout.Align, platform.String()))
+	if layout.SuggestedOrder != nil {
+		fmt.Fprintf(page, "\n\t\t\t<i>%s</i> ", ds.currentTranslation.Text_SuggestedFieldOrder(layout.SuggestedSize))
+		for i, k := range layout.SuggestedOrder {
+			if i > 0 {
+				page.WriteString(", ")
+			}
+			page.WriteString(layout.Fields[k].Name)
+		}
+	}
 }
 
 func (ds *docServer) writeFieldCodeLink(page *htmlPage, sel *code.Selector) {
@@ -1261,6 +1752,50 @@ func (ds *docServer) writeMethodForListing(page *htmlPage, docPkg *code.Package,
 	if !onlyWriteMethodName {
 		ds.writeMethodType(page, docPkg, method, forTypeName)
 	}
+
+	if sel.EmbeddingChain == nil {
+		ds.writePlatformsNote(page, ds.analyzer.MethodPlatforms(method))
+		if !onlyWriteMethodName && method.AstFunc != nil {
+			ds.writeCallGraphLink(page, method.Pkg, code.FuncDeclName(method.AstFunc))
+		}
+	}
+	if !onlyWriteMethodName {
+		ds.writeDeprecatedBadge(page, method.Deprecated())
+	}
+}
+
+// isExportedDeclarationName reports whether a declaration name, in the
+// form "Name" or "Type.Method", is exported (both parts for methods).
+func isExportedDeclarationName(name string) bool {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return token.IsExported(name[:i]) && token.IsExported(name[i+1:])
+	}
+	return token.IsExported(name)
+}
+
+// writeOtherPlatformDeclaration writes a declaration which is not analyzed,
+// so only its kind and name are known.
+func writeOtherPlatformDeclaration(page *htmlPage, decl code.PlatformDeclaration) {
+	page.WriteString(decl.Kind.String())
+	page.WriteByte(' ')
+	if i := strings.IndexByte(decl.Name, '.'); i >= 0 {
+		fmt.Fprintf(page, "(%s) %s", decl.Name[:i], decl.Name[i+1:])
+	} else {
+		page.WriteString(decl.Name)
+	}
+}
+
+// writePlatformsNote notes the platforms a declaration or file is only for.
+// Nothing is written for zero sets, which mean all the analyzed platforms.
+func (ds *docServer) writePlatformsNote(page *htmlPage, set code.PlatformSet) {
+	if set == 0 {
+		return
+	}
+	var names []string
+	for _, p := range ds.analyzer.PlatformsOf(set) {
+		names = append(names, p.String())
+	}
+	fmt.Fprintf(page, ` <span class="platforms">%s</span>`, ds.currentTranslation.Text_OnlyForPlatforms(strings.Join(names, ", ")))
 }
 
 func (ds *docServer) writeMethodType(page *htmlPage, docPkg *code.Package, method *code.Method, forTypeName *code.TypeName) {
@@ -1337,14 +1872,8 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 			}
 
 			fPkg = ds.analyzer.RuntimePackage()
-			switch res.Name() {
-			default:
-			case "close":
-				fPosition = ds.analyzer.RuntimeFunctionCodePosition("closechan")
-			case "panic":
-				fPosition = ds.analyzer.RuntimeFunctionCodePosition("gopanic")
-			case "recover":
-				fPosition = ds.analyzer.RuntimeFunctionCodePosition("gorecover")
+			if f := builtinFunctionRuntimeFuncs[res.Name()]; f != "" {
+				fPosition = ds.analyzer.RuntimeFunctionCodePosition(f)
 			}
 		} else {
 			fPosition = res.Position()
@@ -1373,6 +1902,10 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 
 		writeResName()
 
+		if !writeResNameOnly && !isBuiltin {
+			ds.WriteAstTypeParams(page, res.AstSpec.TypeParams, res.Pkg, res.Pkg, nil)
+		}
+
 		if !writeResNameOnly {
 			showSource := false
 			if isBuiltin {
@@ -1417,6 +1950,8 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 				//ds.writeValueTType(page, res.Denoting().TT, res.Pkg, true, nil)
 			}
 			writeKindText(page, res.Denoting().TT)
+			ds.writeMethodSetsLink(page, res)
+			ds.writeTypeHierarchyLink(page, res)
 		}
 	case *code.Constant:
 		if !writeResNameOnly {
@@ -1511,6 +2046,10 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 		if !writeResNameOnly {
 			ds.WriteAstType(page, res.AstDecl.Type, res.Pkg, res.Pkg, false, nil, nil)
 			//ds.writeValueTType(page, res.TType(), res.Pkg, false)
+			if res.Func != nil && !isBuiltin && res.Pkg.Path() != "unsafe" {
+				ds.writeCallGraphLink(page, res.Pkg, res.Name())
+			}
+			ds.writeAsmImplementationLinks(page, res)
 		}
 	}
 
@@ -1524,7 +2063,8 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 	//fmt.Fprint(page, ` <a href="#">{/}</a>`)
 }
 
-func (ds *docServer) writeTypeName(page *htmlPage, tt *types.Named, docPkg *code.Package, alternativeTypeName string) {
+// tt might be a *types.Named or a *types.Alias.
+func (ds *docServer) writeTypeName(page *htmlPage, tt interface{ Obj() *types.TypeName }, docPkg *code.Package, alternativeTypeName string) {
 	objpkg := tt.Obj().Pkg()
 	isBuiltin := objpkg == nil
 	if isBuiltin {
@@ -1562,6 +2102,23 @@ func (ds *docServer) writeValueTType(page *htmlPage, tt types.Type, docPkg *code
 		} else {
 			ds.writeTypeName(page, tt, docPkg, "")
 		}
+		ds.writeTypeArguments(page, tt.TypeArgs(), docPkg, forTypeName)
+	case *types.Alias:
+		ds.writeTypeName(page, tt, docPkg, "")
+		ds.writeTypeArguments(page, tt.TypeArgs(), docPkg, forTypeName)
+	case *types.TypeParam:
+		page.WriteString(tt.Obj().Name())
+	case *types.Union:
+		for i := 0; i < tt.Len(); i++ {
+			if i > 0 {
+				page.WriteString(" | ")
+			}
+			term := tt.Term(i)
+			if term.Tilde() {
+				page.WriteByte('~')
+			}
+			ds.writeValueTType(page, term.Type(), docPkg, true, forTypeName)
+		}
 	case *types.Basic:
 		if forTypeName != nil && tt == forTypeName.Denoting().TT {
 			page.WriteString(tt.Name())
@@ -1631,6 +2188,20 @@ func (ds *docServer) writeValueTType(page *htmlPage, tt types.Type, docPkg *code
 	}
 }
 
+func (ds *docServer) writeTypeArguments(page *htmlPage, args *types.TypeList, docPkg *code.Package, forTypeName *code.TypeName) {
+	if args.Len() == 0 {
+		return
+	}
+	page.Write(leftSquare)
+	for i := 0; i < args.Len(); i++ {
+		if i > 0 {
+			page.Write(comma)
+		}
+		ds.writeValueTType(page, args.At(i), docPkg, true, forTypeName)
+	}
+	page.Write(rightSquare)
+}
+
 func (ds *docServer) writeTuple(page *htmlPage, tuple *types.Tuple, docPkg *code.Package, variadic bool, forTypeName *code.TypeName) {
 	n := tuple.Len()
 	for i := 0; i < n; i++ {
@@ -1718,6 +2289,20 @@ func (ds *docServer) writeInterfaceMethods(page *htmlPage, it *types.Interface,
 			page.WriteString("; ")
 		}
 	}
+
+	// Type set terms of constraint interfaces.
+	if !it.IsMethodSet() {
+		for i := 0; i < it.NumEmbeddeds(); i++ {
+			et := it.EmbeddedType(i)
+			if _, ok := et.Underlying().(*types.Interface); ok {
+				continue // its methods are already written
+			}
+			if k > 0 || i > 0 {
+				page.WriteString("; ")
+			}
+			ds.writeValueTType(page, et, docPkg, true, forTypeName)
+		}
+	}
 }
 
 var (
@@ -1757,7 +2342,43 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 		w.Write(leftParen)
 		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
 		w.Write(rightParen)
+	case *ast.IndexExpr: // instantiated types
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(leftSquare)
+		ds.WriteAstType(w, node.Index, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(rightSquare)
+	case *ast.IndexListExpr: // instantiated types
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.Write(leftSquare)
+		for i, index := range node.Indices {
+			if i > 0 {
+				w.Write(comma)
+			}
+			ds.WriteAstType(w, index, codePkg, docPkg, true, nil, forTypeName)
+		}
+		w.Write(rightSquare)
+	case *ast.UnaryExpr: // ~T in constraints
+		if node.Op != token.TILDE {
+			panic(fmt.Sprint("WriteType, unknown unary operator: ", node.Op))
+		}
+		w.WriteString("~")
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+	case *ast.BinaryExpr: // A | B in constraints
+		if node.Op != token.OR {
+			panic(fmt.Sprint("WriteType, unknown binary operator: ", node.Op))
+		}
+		ds.WriteAstType(w, node.X, codePkg, docPkg, true, nil, forTypeName)
+		w.WriteString(" | ")
+		ds.WriteAstType(w, node.Y, codePkg, docPkg, true, nil, forTypeName)
 	case *ast.Ident:
+		// Type parameters are not declared at package level.
+		if tn, ok := codePkg.PPkg.TypesInfo.ObjectOf(node).(*types.TypeName); ok {
+			if _, ok := tn.Type().(*types.TypeParam); ok {
+				w.WriteString(node.Name)
+				return
+			}
+		}
+
 		// obj := codePkg.PPkg.TypesInfo.ObjectOf(node)
 		// The above one might return a *types.Var object for embedding field.
 		// So us the following one instead, to make sure it is a *types.TypeName.
@@ -1913,6 +2534,7 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 			w.Write(funcKeyword)
 			//w.Write(space)
 		}
+		ds.WriteAstTypeParams(w, node.TypeParams, codePkg, docPkg, forTypeName)
 		w.Write(leftParen)
 		ds.WriteAstFieldList(w, node.Params, true, comma, codePkg, docPkg, true, recvParam, forTypeName)
 		w.Write(rightParen)
@@ -1941,6 +2563,16 @@ func (ds *docServer) WriteAstType(w *htmlPage, typeLit ast.Expr, codePkg, docPkg
 	}
 }
 
+// WriteAstTypeParams writes the type parameter list of a generic type or function.
+func (ds *docServer) WriteAstTypeParams(w *htmlPage, typeParams *ast.FieldList, codePkg, docPkg *code.Package, forTypeName *code.TypeName) {
+	if typeParams == nil || len(typeParams.List) == 0 {
+		return
+	}
+	w.Write(leftSquare)
+	ds.WriteAstFieldList(w, typeParams, true, comma, codePkg, docPkg, true, nil, forTypeName)
+	w.Write(rightSquare)
+}
+
 func (ds *docServer) WriteAstFieldList(w *htmlPage, fieldList *ast.FieldList, isParamOrResultList bool, sep []byte, codePkg, docPkg *code.Package, funcKeywordNeeded bool, recvParam *ast.Field, forTypeName *code.TypeName) {
 	if fieldList == nil {
 		return
@@ -12,6 +12,8 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"go101.org/gold/code"
 )
@@ -35,6 +37,18 @@ func (ds *docServer) sourceCodePage(w http.ResponseWriter, r *http.Request, pkgP
 		return
 	}
 
+	// The bare filenames of embedded files in sub-directories contain slashes.
+	for p, f := pkgPath, bareFilename; ds.analyzer.PackageByPath(p) == nil; {
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			break
+		}
+		p, f = p[:i], p[i+1:]+"/"+f
+		if ds.analyzer.PackageByPath(p) != nil {
+			pkgPath, bareFilename = p, f
+		}
+	}
+
 	// Browers will replace all \ in url to / automatically, so we need convert them back.
 	// Otherwise, the file will not be found on Windows.
 	//srcPath = strings.Replace(srcPath, "/", string(filepath.Separator), -1)
@@ -146,6 +160,16 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 	page.WriteString(`
 <pre class="line-numbers">`)
 
+	var errs = result.Errors
+	var writeErrors = func(lineNumber int) {
+		for ; len(errs) > 0 && (errs[0].Position.Line <= lineNumber || lineNumber == len(result.Lines)); errs = errs[1:] {
+			page.WriteString("\n")
+			page.WriteString(`<span class="package-error">`)
+			WriteHtmlEscapedBytes(page, []byte(errs[0].Msg))
+			page.WriteString(`</span>`)
+		}
+	}
+
 	var outputNewLine = true
 	for i, line := range result.Lines {
 		//		fmt.Fprintf(page, `
@@ -159,6 +183,7 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 			page.WriteString(`<div class="anchor" id="doc">`)
 		}
 		fmt.Fprintf(page, `<span class="codeline" id="line-%d"><code>%s</code></span>`, lineNumber, line)
+		writeErrors(lineNumber)
 		if lineNumber == result.DocEndLine {
 			page.WriteString(`</div>`)
 			outputNewLine = false
@@ -182,6 +207,9 @@ type SourceFileAnalyzeResult struct {
 	NumRatios     int32
 	DocStartLine  int
 	DocEndLine    int
+
+	// The errors in the file, sorted by positions.
+	Errors []code.PackageError
 }
 
 var (
@@ -450,6 +478,11 @@ func WriteTypeEx(w io.Writer, typeLit ast.Expr, info *types.Info, funcKeywordNee
 //	return 0
 //}
 
+type enclosingFunc struct {
+	nodeDepth int32
+	sig       *types.Signature
+}
+
 // ToDo: write to page directly.
 type astVisitor struct {
 	currentPathInfo pagePathInfo
@@ -459,6 +492,7 @@ type astVisitor struct {
 	fset         *token.FileSet
 	file         *token.File
 	info         *types.Info
+	sizes        types.Sizes
 	content      []byte
 
 	// ToDo: Some Go files might contains line-repositions.
@@ -485,11 +519,19 @@ type astVisitor struct {
 
 	sameFileObjects map[types.Object]int32
 
+	// The runtime functions for some expressions which are decided by
+	// their contexts, such as "mapassign" for m[k] in m[k] = v.
+	runtimeFuncsByExpr map[ast.Expr]string
+
 	astNodeDepth int32
 
 	topLevelFuncNodeDepth int32
 	topLevelFuncInfo      *astFunctionInfo
 
+	// The signatures of the functions (including function
+	// literals) enclosing the current node, for return statements.
+	enclosingFuncs []enclosingFunc
+
 	// ToDo: also support implementation page for local interface types (including unnamed ones).
 	//       Local interface types should get IDs like Name-1234.
 	topLevelInterfaceTypeNodeDepth int32
@@ -530,6 +572,23 @@ type ChanCommOprator struct {
 	pos   token.Pos
 }
 
+// A RuntimeOperator is an operator (or a bracket/parenthesis) which is
+// implemented by a runtime function, such as the "[" in m[k] and the
+// "(" in string(bytes).
+type RuntimeOperator struct {
+	f   string // the runtime function name
+	pos token.Pos
+	len int
+}
+
+func (ro *RuntimeOperator) Pos() token.Pos {
+	return ro.pos
+}
+
+func (ro *RuntimeOperator) End() token.Pos {
+	return ro.pos + token.Pos(ro.len)
+}
+
 func (ccp *ChanCommOprator) Pos() token.Pos {
 	return ccp.pos
 }
@@ -584,6 +643,13 @@ func (v *astVisitor) tryToHandleSomeSpecialNodes(beforeNode ast.Node) {
 				end := v.pkg.PPkg.Fset.PositionFor(node.End(), false)
 				v.buildText(start, end, "", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
 			}
+		case *RuntimeOperator:
+			fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition(node.f)
+			if fPosition.IsValid() {
+				start := v.pkg.PPkg.Fset.PositionFor(node.Pos(), false)
+				end := v.pkg.PPkg.Fset.PositionFor(node.End(), false)
+				v.buildText(start, end, "", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
+			}
 		}
 
 		// This line will clear the the prev and next elements of e.
@@ -900,6 +966,9 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		if v.topLevelInterfaceTypeInfo != nil && v.astNodeDepth == v.topLevelInterfaceTypeNodeDepth {
 			v.topLevelInterfaceTypeInfo = nil
 		}
+		if n := len(v.enclosingFuncs); n > 0 && v.astNodeDepth == v.enclosingFuncs[n-1].nodeDepth {
+			v.enclosingFuncs = v.enclosingFuncs[:n-1]
+		}
 		return
 	} else {
 		// ToDo: also replace topLevelFuncNodeDepth and topLevelInterfaceTypeNodeDepth in this way?
@@ -925,6 +994,10 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 							typeExpr = e.X
 						case *ast.StarExpr:
 							typeExpr = e.X
+						case *ast.IndexExpr: // T[P]
+							typeExpr = e.X
+						case *ast.IndexListExpr: // T[P1, P2]
+							typeExpr = e.X
 						default:
 							panic(fmt.Sprintf("impossible type: %T", e))
 						}
@@ -967,6 +1040,21 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		}
 	}
 
+	switch f := n.(type) {
+	case *ast.FuncDecl, *ast.FuncLit:
+		var funcType types.Type
+		if fd, ok := f.(*ast.FuncDecl); ok {
+			if obj := v.info.Defs[fd.Name]; obj != nil {
+				funcType = obj.Type()
+			}
+		} else {
+			funcType = v.info.TypeOf(f.(*ast.FuncLit))
+		}
+		// sig is nil for ill-typed functions.
+		sig, _ := funcType.(*types.Signature)
+		v.enclosingFuncs = append(v.enclosingFuncs, enclosingFunc{nodeDepth: v.astNodeDepth, sig: sig})
+	}
+
 	// ...
 	v.astNodeDepth++
 
@@ -1049,17 +1137,12 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 
 		f := "selectgo"
 		if numDefaults == 1 && numCases == 1 {
-			switch caseStmt := caseComm.(type) {
+			switch caseComm.(type) {
 			case *ast.SendStmt:
 				f = "selectnbsend"
-			case *ast.ExprStmt: // <-c
+			case *ast.ExprStmt, *ast.AssignStmt: // <-c, v = <-c, v, ok = <-c
+				// selectnbrecv2 has been merged into selectnbrecv since Go 1.17.
 				f = "selectnbrecv"
-			case *ast.AssignStmt:
-				if len(caseStmt.Lhs) < 2 {
-					f = "selectnbrecv"
-				} else {
-					f = "selectnbrecv2"
-				}
 			}
 		}
 
@@ -1118,7 +1201,13 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 	case *ast.BranchStmt:
 		v.handleKeyword(node.TokPos, node.Tok)
 	case *ast.ReturnStmt:
-		v.handleKeyword(node.Return, token.RETURN)
+		// Return statements implicitly converting values
+		// to interface values link to the runtime functions.
+		if fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition(v.implicitConversionRuntimeFunc(node)); fPosition.IsValid() {
+			v.handleToken(node.Return, token.RETURN.String(), "keyword", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
+		} else {
+			v.handleKeyword(node.Return, token.RETURN)
+		}
 	case *ast.IfStmt:
 		v.handleKeyword(node.If, token.IF)
 		if node.Else != nil {
@@ -1134,9 +1223,69 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 	case *ast.DeferStmt:
 		v.handleKeyword(node.Defer, token.DEFER)
 	case *ast.GoStmt:
-		v.handleKeyword(node.Go, token.GO)
+		if fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition("newproc"); fPosition.IsValid() {
+			v.handleToken(node.Go, token.GO.String(), "keyword", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
+		} else {
+			v.handleKeyword(node.Go, token.GO)
+		}
+	// runtime operations
+	case *ast.AssignStmt:
+		for _, lhs := range node.Lhs {
+			v.markRuntimeFuncForExpr(lhs, "mapassign")
+		}
+		if len(node.Lhs) == 2 && len(node.Rhs) == 1 {
+			v.markRuntimeFuncForExpr(node.Rhs[0], "mapaccess2", "assertE2I2")
+		}
+		if f := v.implicitConversionRuntimeFunc(node); f != "" {
+			v.addSpecialNode(&RuntimeOperator{f: f, pos: node.TokPos, len: len(node.Tok.String())})
+		}
+	case *ast.ValueSpec:
+		if len(node.Names) == 2 && len(node.Values) == 1 {
+			v.markRuntimeFuncForExpr(node.Values[0], "mapaccess2", "assertE2I2")
+		}
+		if f := v.implicitConversionRuntimeFunc(node); f != "" {
+			assign := v.findTokenBetween(node.Type.End(), node.Values[0].Pos(), "=", true)
+			v.addSpecialNode(&RuntimeOperator{f: f, pos: assign.pos, len: 1})
+		}
+	case *ast.IncDecStmt:
+		v.markRuntimeFuncForExpr(node.X, "mapassign")
+	case *ast.IndexExpr:
+		if t := v.info.TypeOf(node.X); t != nil {
+			if _, ok := t.Underlying().(*types.Map); ok {
+				f := v.runtimeFuncsByExpr[node]
+				if f == "" {
+					f = "mapaccess1"
+				}
+				v.addSpecialNode(&RuntimeOperator{f: f, pos: node.Lbrack, len: 1})
+			}
+		}
+	case *ast.TypeAssertExpr:
+		if node.Type != nil { // not in type switches
+			if t := v.info.TypeOf(node.Type); t != nil && !isTypeParam(t) {
+				if it, ok := t.Underlying().(*types.Interface); ok && it.NumMethods() > 0 {
+					f := v.runtimeFuncsByExpr[node]
+					if f == "" {
+						f = "assertE2I"
+					}
+					v.addSpecialNode(&RuntimeOperator{f: f, pos: node.Lparen, len: 1})
+				}
+			}
+		}
+	case *ast.CallExpr:
+		f := v.conversionRuntimeFunc(node)
+		if f == "" {
+			f = v.implicitConversionRuntimeFunc(node)
+		}
+		if f != "" {
+			v.addSpecialNode(&RuntimeOperator{f: f, pos: node.Lparen, len: 1})
+		}
 	case *ast.FuncDecl:
-		v.handleKeyword(node.Type.Func, token.FUNC)
+		// The func keywords of function declarations link to call graph pages.
+		if name := code.FuncDeclName(node); hasCallGraphPage(name) && v.pkg.Path() != "builtin" && v.pkg.Path() != "unsafe" {
+			v.handleToken(node.Type.Func, token.FUNC.String(), "keyword", buildPageHref(v.currentPathInfo, pagePathInfo{ResTypeCallGraph, v.pkg.Path() + ".." + name}, nil, ""))
+		} else {
+			v.handleKeyword(node.Type.Func, token.FUNC)
+		}
 	case *ast.GenDecl:
 		v.handleKeyword(node.TokPos, node.Tok)
 	case *ast.InterfaceType:
@@ -1170,6 +1319,338 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 	return
 }
 
+// builtinFunctionRuntimeFuncs lists the runtime functions implementing
+// the built-in functions which don't depend on argument types.
+var builtinFunctionRuntimeFuncs = map[string]string{
+	"append":  "growslice",
+	"close":   "closechan",
+	"copy":    "slicecopy",
+	"delete":  "mapdelete",
+	"new":     "newobject",
+	"panic":   "gopanic",
+	"recover": "gorecover",
+}
+
+// builtinRuntimeFunc returns the runtime function implementing a call
+// to a built-in function.
+func (v *astVisitor) builtinRuntimeFunc(ident *ast.Ident, obj types.Object) string {
+	if _, ok := obj.(*types.Builtin); !ok {
+		return ""
+	}
+	if f := builtinFunctionRuntimeFuncs[obj.Name()]; f != "" {
+		return f
+	}
+
+	tv, ok := v.info.Types[ident]
+	if !ok {
+		return ""
+	}
+	sig, ok := tv.Type.(*types.Signature)
+	if !ok || sig.Params().Len() == 0 {
+		return ""
+	}
+	arg := sig.Params().At(0).Type()
+	if isTypeParam(arg) {
+		return ""
+	}
+	switch arg.Underlying().(type) {
+	case *types.Chan:
+		if obj.Name() == "make" {
+			return "makechan"
+		}
+	case *types.Map:
+		switch obj.Name() {
+		case "make":
+			return "makemap"
+		case "clear":
+			return "mapclear"
+		case "len":
+			return "reflect_maplen"
+		}
+	case *types.Slice:
+		if obj.Name() == "make" {
+			return "makeslice"
+		}
+	}
+	return ""
+}
+
+// markRuntimeFuncForExpr records the runtime function for a map index
+// expression or a type assertion, decided by the context of the expression.
+// The second function (if provided) is for type assertions.
+func (v *astVisitor) markRuntimeFuncForExpr(expr ast.Expr, fs ...string) {
+	expr = ast.Unparen(expr)
+	switch expr.(type) {
+	default:
+		return
+	case *ast.IndexExpr:
+	case *ast.TypeAssertExpr:
+		if len(fs) < 2 {
+			return
+		}
+		fs = fs[1:]
+	}
+	if v.runtimeFuncsByExpr == nil {
+		v.runtimeFuncsByExpr = make(map[ast.Expr]string)
+	}
+	v.runtimeFuncsByExpr[expr] = fs[0]
+}
+
+func isTypeParam(t types.Type) bool {
+	_, ok := t.(*types.TypeParam)
+	return ok
+}
+
+func isBasicKind(t types.Type, kind types.BasicKind) bool {
+	bt, ok := t.Underlying().(*types.Basic)
+	return ok && bt.Kind() == kind
+}
+
+// conversionRuntimeFunc returns the runtime function for a conversion, such as
+// string(bytes) and any(v). Blank is returned if the call is not a conversion
+// or the conversion is implemented without calling runtime functions.
+func (v *astVisitor) conversionRuntimeFunc(call *ast.CallExpr) string {
+	tv, ok := v.info.Types[call.Fun]
+	if !ok || !tv.IsType() || len(call.Args) != 1 {
+		return ""
+	}
+	if result, ok := v.info.Types[call]; ok && result.Value != nil {
+		return "" // constant conversions
+	}
+	arg, ok := v.info.Types[call.Args[0]]
+	if !ok || arg.IsNil() {
+		return ""
+	}
+	to, from := tv.Type, arg.Type
+	if isTypeParam(to) || isTypeParam(from) {
+		return ""
+	}
+
+	switch tt := to.Underlying().(type) {
+	case *types.Basic:
+		if tt.Info()&types.IsString == 0 {
+			return ""
+		}
+		switch ft := from.Underlying().(type) {
+		case *types.Slice:
+			if isBasicKind(ft.Elem(), types.Byte) {
+				return "slicebytetostring"
+			}
+			if isBasicKind(ft.Elem(), types.Rune) {
+				return "slicerunetostring"
+			}
+		case *types.Basic:
+			if ft.Info()&types.IsInteger != 0 {
+				return "intstring"
+			}
+		}
+	case *types.Slice:
+		if ft, ok := from.Underlying().(*types.Basic); !ok || ft.Info()&types.IsString == 0 {
+			return ""
+		}
+		if isBasicKind(tt.Elem(), types.Byte) {
+			return "stringtoslicebyte"
+		}
+		if isBasicKind(tt.Elem(), types.Rune) {
+			return "stringtoslicerune"
+		}
+	case *types.Interface:
+		return v.interfaceConversionRuntimeFunc(arg)
+	}
+	return ""
+}
+
+// implicitConversionRuntimeFunc returns the runtime function for the first
+// implicit conversion of a non-interface value to an interface value in a
+// function call, an assignment, a variable declaration or a return statement.
+// Blank is returned if there are no such conversions or the conversions are
+// implemented without calling runtime functions. The implicit conversions in
+// the other places, such as composite literals and channel sends, are not
+// handled.
+func (v *astVisitor) implicitConversionRuntimeFunc(n ast.Node) string {
+	var convert = func(to types.Type, value ast.Expr) string {
+		if to == nil || isTypeParam(to) || !types.IsInterface(to) {
+			return ""
+		}
+		return v.interfaceConversionRuntimeFunc(v.info.Types[value])
+	}
+
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		fun, ok := v.info.Types[n.Fun]
+		if !ok || fun.IsType() || fun.Type == nil {
+			return ""
+		}
+		sig, ok := fun.Type.Underlying().(*types.Signature)
+		if !ok {
+			return ""
+		}
+		params := sig.Params()
+		for i, arg := range n.Args {
+			var to types.Type
+			switch {
+			case sig.Variadic() && i >= params.Len()-1:
+				if n.Ellipsis.IsValid() {
+					return "" // f(s...)
+				}
+				if s, ok := params.At(params.Len() - 1).Type().Underlying().(*types.Slice); ok {
+					to = s.Elem()
+				}
+			case i < params.Len():
+				to = params.At(i).Type()
+			}
+			if f := convert(to, arg); f != "" {
+				return f
+			}
+		}
+	case *ast.AssignStmt:
+		if n.Tok != token.ASSIGN && n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+			return ""
+		}
+		for i, lhs := range n.Lhs {
+			if f := convert(v.info.TypeOf(lhs), n.Rhs[i]); f != "" {
+				return f
+			}
+		}
+	case *ast.ValueSpec:
+		if n.Type == nil || len(n.Names) != len(n.Values) {
+			return ""
+		}
+		to := v.info.TypeOf(n.Type)
+		for _, value := range n.Values {
+			if f := convert(to, value); f != "" {
+				return f
+			}
+		}
+	case *ast.ReturnStmt:
+		if len(v.enclosingFuncs) == 0 {
+			return ""
+		}
+		sig := v.enclosingFuncs[len(v.enclosingFuncs)-1].sig
+		if sig == nil || sig.Results().Len() != len(n.Results) {
+			return ""
+		}
+		for i, result := range n.Results {
+			if f := convert(sig.Results().At(i).Type(), result); f != "" {
+				return f
+			}
+		}
+	}
+	return ""
+}
+
+// interfaceConversionRuntimeFunc returns the runtime function for converting
+// a value to an interface value. Blank is returned if the value is already an
+// interface value or the conversion is implemented without calling runtime
+// functions.
+//
+// The choice follows the gc compiler (see dataWord and dataWordFuncName in
+// cmd/compile/internal/walk/convert.go), by the size, the alignment and the
+// kind of the value type. However, the compiler also avoids the calls for
+// the values which don't escape or are read-only, which is not judged here.
+func (v *astVisitor) interfaceConversionRuntimeFunc(from types.TypeAndValue) string {
+	if from.Value != nil || from.IsNil() || from.Type == nil {
+		return "" // constants are stored in static data
+	}
+	t := from.Type
+	switch t.(type) {
+	case *types.Tuple: // f(g())
+		return ""
+	}
+	if isTypeParam(t) || types.IsInterface(t) || isPointerShaped(t) {
+		return "" // pointer-shaped values are stored in interfaces directly
+	}
+
+	sizes := v.sizes
+	if sizes == nil {
+		sizes = types.SizesFor("gc", "amd64")
+	}
+	size, align := sizes.Sizeof(t), sizes.Alignof(t)
+	if size == 0 {
+		return "" // zerobase is used
+	}
+	if bt, ok := t.Underlying().(*types.Basic); ok && (bt.Kind() == types.Bool || size == 1 && bt.Info()&types.IsInteger != 0) {
+		return "" // staticuint64s is used
+	}
+
+	pointers := hasPointers(t)
+	switch {
+	case size == 2 && align == 2:
+		return "convT16"
+	case size == 4 && align == 4 && !pointers:
+		return "convT32"
+	case size == 8 && align == sizes.Alignof(types.Typ[types.Uint64]) && !pointers:
+		return "convT64"
+	}
+	switch sc := soleComponent(t).Underlying().(type) {
+	case *types.Basic:
+		if sc.Info()&types.IsString != 0 {
+			return "convTstring"
+		}
+	case *types.Slice:
+		return "convTslice"
+	}
+	if pointers {
+		return "convT"
+	}
+	return "convTnoptr"
+}
+
+// isPointerShaped reports whether or not the values of a type
+// are stored in interface values directly.
+func isPointerShaped(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Pointer, *types.Chan, *types.Map, *types.Signature:
+		return true
+	case *types.Basic:
+		return u.Kind() == types.UnsafePointer
+	case *types.Array:
+		return u.Len() == 1 && isPointerShaped(u.Elem())
+	case *types.Struct:
+		return u.NumFields() == 1 && isPointerShaped(u.Field(0).Type())
+	}
+	return false
+}
+
+// hasPointers reports whether or not the values of a type contain pointers.
+func hasPointers(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&types.IsString != 0 || u.Kind() == types.UnsafePointer
+	case *types.Array:
+		return u.Len() > 0 && hasPointers(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if hasPointers(u.Field(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true // pointers, slices, maps, channels, functions, interfaces
+}
+
+// soleComponent returns the only non-struct and non-array component
+// of a type, or the type itself if it has several components.
+func soleComponent(t types.Type) types.Type {
+	for {
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			if u.NumFields() != 1 {
+				return t
+			}
+			t = u.Field(0).Type()
+		case *types.Array:
+			if u.Len() != 1 {
+				return t
+			}
+			t = u.Elem()
+		default:
+			return t
+		}
+	}
+}
+
 func (v *astVisitor) handleNode(node ast.Node, class string) {
 	start := v.fset.PositionFor(node.Pos(), false)
 	end := v.fset.PositionFor(node.End(), false)
@@ -1245,32 +1726,16 @@ func (v *astVisitor) handleIdent(ident *ast.Ident) {
 	objPPkg := obj.Pkg()
 	if objPPkg == nil {
 		if obj.Parent() == types.Universe {
-			for obj.Name() == "make" {
-				tv, ok := v.info.Types[ident]
-				if !ok {
-					break
+			if f := v.builtinRuntimeFunc(ident, obj); f != "" {
+				if fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition(f); fPosition.IsValid() {
+					v.buildText(start, end, "", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
+					return
 				}
-				sig, ok := tv.Type.Underlying().(*types.Signature)
-				if !ok {
-					break
-				}
-				_, ok = sig.Params().At(0).Type().Underlying().(*types.Chan)
-				if !ok {
-					break
-				}
-				fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition("makechan")
-				if !fPosition.IsValid() {
-					break
-				}
-				v.buildText(start, end, "", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
-				return
 			}
 
 			//log.Println(fmt.Sprintf("ppkg for identifier %s (%v) is not found", ident.Name, obj))
 			//v.buildIdentifier(start, end, -1, "/pkg:builtin#name-"+obj.Name())
 			v.buildIdentifier(start, end, -1, buildPageHref(v.currentPathInfo, pagePathInfo{ResTypePackage, "builtin"}, nil, "")+"#name-"+obj.Name())
-
-			// ToDo: link to runtime.panic/recover/...
 			return
 		}
 
@@ -1517,6 +1982,7 @@ func buildSrouceCodeLineLink(currentPathInfo pagePathInfo, analyzer *code.CodeAn
 		//if sourceFilename == "" {
 		//	sourceFilename = fileInfo.BareGeneratedFilename
 		//}
+		fileInfo, p = originalSourcePosition(pkg, fileInfo, p)
 		sourceFilename = fileInfo.AstBareFileName()
 	}
 
@@ -1542,6 +2008,7 @@ func writeSrouceCodeLineLink(page *htmlPage, pkg *code.Package, p token.Position
 		//if sourceFilename == "" {
 		//	sourceFilename = fileInfo.BareGeneratedFilename
 		//}
+		fileInfo, p = originalSourcePosition(pkg, fileInfo, p)
 		sourceFilename = fileInfo.AstBareFileName()
 	}
 
@@ -1550,6 +2017,29 @@ func writeSrouceCodeLineLink(page *htmlPage, pkg *code.Package, p token.Position
 	fmt.Fprintf(page, `#line-%d"%s>%s</a>`, p.Line, class, text)
 }
 
+// originalSourcePosition maps a position in a file generated with
+// //line directives to the position in the original file, if the original
+// file has its own source page. A file generated by cgo shares the source
+// page with its original file, and the page is numbered by the lines of
+// the original file (see linesInOriginalFile).
+func originalSourcePosition(pkg *code.Package, fileInfo *code.SourceFileInfo, p token.Position) (*code.SourceFileInfo, token.Position) {
+	original := fileInfo.OriginalPosition(p)
+	if original.Filename == p.Filename {
+		return fileInfo, p
+	}
+	if original.Filename == fileInfo.OriginalFile {
+		if isCgoGeneratedFile(fileInfo) {
+			return fileInfo, original
+		}
+		return fileInfo, p
+	}
+	originalInfo := pkg.SourceFileInfoByFilePath(original.Filename)
+	if originalInfo == nil || originalInfo.AstFile != nil {
+		return fileInfo, p
+	}
+	return originalInfo, original
+}
+
 func writeSrouceCodeFileLink(page *htmlPage, pkg *code.Package, sourceFilename string) {
 	//originalFile := ds.analyzer.OriginalGoSourceFile(sourceFilename)
 	////fmt.Fprintf(page, `<a href="/src:%[1]s">%[1]s</a>`, originalFile)
@@ -1565,6 +2055,37 @@ func writeSourceCodeDocLink(page *htmlPage, pkg *code.Package, sourceFilename st
 	page.WriteByte(' ')
 }
 
+// writeLinknameLinks writes the "linked to" and "linked from" links
+// of a function connected with other functions by //go:linkname directives.
+func (ds *docServer) writeLinknameLinks(w io.StringWriter, currentPathInfo pagePathInfo, f *code.Function) {
+	var writeLink = func(f *code.Function) {
+		w.WriteString(` <a href="`)
+		w.WriteString(buildSrouceCodeLineLink(currentPathInfo, ds.analyzer, f.Pkg, f.Position()))
+		w.WriteString(`">`)
+		w.WriteString(f.Pkg.Path())
+		w.WriteString(".")
+		w.WriteString(f.Name())
+		w.WriteString(`</a>`)
+	}
+	if to := f.LinkedTo(); to != nil {
+		w.WriteString(`<i class="linknames">`)
+		w.WriteString(ds.currentTranslation.Text_LinkedTo())
+		writeLink(to)
+		w.WriteString(`</i>`)
+	}
+	if froms := f.LinkedFroms(); len(froms) > 0 {
+		if f.LinkedTo() != nil {
+			w.WriteString(" ")
+		}
+		w.WriteString(`<i class="linknames">`)
+		w.WriteString(ds.currentTranslation.Text_LinkedFrom())
+		for _, from := range froms {
+			writeLink(from)
+		}
+		w.WriteString(`</i>`)
+	}
+}
+
 func writeMainFunctionArrow(page *htmlPage, pkg *code.Package, mainPos token.Position) {
 	if mainPos.IsValid() {
 		//mainPos.Line += ds.analyzer.SourceFileLineOffset(mainPos.Filename)
@@ -1671,25 +2192,31 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			DocStartLine:  docStartLine,
 			DocEndLine:    docEndLine,
 		}
-		var buf bytes.Buffer
-		buf.Grow(1024)
-		for data := content; len(data) > 0; {
-			i := bytes.IndexByte(data, '\n')
-			k := i
-			if k < 0 {
-				k = len(data)
-			}
-			if k > 0 && data[k-1] == '\r' {
-				k--
-			}
-			WriteHtmlEscapedBytes(&buf, data[:k])
-			result.Lines = append(result.Lines, buf.String())
-			buf.Reset()
+		if strings.HasSuffix(bareFilename, ".s") {
+			result.Lines = ds.buildAssemblyLines(pagePathInfo{ResTypeSource, pkg.Path() + "/" + bareFilename}, fileInfo, content)
+		} else if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+			result.Lines = []string{"<i>" + ds.currentTranslation.Text_BinaryFile(len(content)) + "</i>"}
+		} else {
+			var buf bytes.Buffer
+			buf.Grow(1024)
+			for data := content; len(data) > 0; {
+				i := bytes.IndexByte(data, '\n')
+				k := i
+				if k < 0 {
+					k = len(data)
+				}
+				if k > 0 && data[k-1] == '\r' {
+					k--
+				}
+				WriteHtmlEscapedBytes(&buf, data[:k])
+				result.Lines = append(result.Lines, buf.String())
+				buf.Reset()
 
-			if i < 0 {
-				break
+				if i < 0 {
+					break
+				}
+				data = data[i+1:]
 			}
-			data = data[i+1:]
 		}
 	} else {
 
@@ -1718,6 +2245,7 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			fset:         pkg.PPkg.Fset,
 			file:         file,
 			info:         pkg.PPkg.TypesInfo,
+			sizes:        pkg.PPkg.TypesSizes,
 			content:      content,
 
 			goFilePath: filePath,
@@ -1754,12 +2282,74 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 		ast.Walk(av, fileInfo.AstFile)
 		av.finish()
 
+		// Append the //go:linkname relations to function declaration lines.
+		var buf strings.Builder
+		for _, f := range pkg.AllFunctions {
+			if f.LinkedTo() == nil && len(f.LinkedFroms()) == 0 {
+				continue
+			}
+			if pos := f.Position(); pos.Filename == filePath && pos.Line <= len(av.result.Lines) {
+				buf.WriteString("  ")
+				ds.writeLinknameLinks(&buf, av.currentPathInfo, f)
+				av.result.Lines[pos.Line-1] += buf.String()
+				buf.Reset()
+			}
+		}
+
 		if n := av.specialAstNodes.Len(); n > 0 {
 			log.Println("!!!", filePath, "has still", n, "special ast node(s) not handled yet.")
 		}
 
+		if isCgoGeneratedFile(fileInfo) {
+			var toOriginal []int
+			av.result.Lines, toOriginal = linesInOriginalFile(fileInfo, file, av.result.Lines)
+			if docStartLine > 0 && docEndLine <= len(toOriginal) {
+				av.result.DocStartLine = toOriginal[docStartLine-1]
+				av.result.DocEndLine = toOriginal[docEndLine-1]
+			}
+		}
+
 		result = av.result
 	}
 
+	for _, e := range pkg.Errors {
+		if f := e.Position.Filename; f != "" && (f == fileInfo.OriginalFile || f == fileInfo.GeneratedFile) {
+			result.Errors = append(result.Errors, e)
+		}
+	}
+
 	return result, nil
 }
+
+// isCgoGeneratedFile reports whether or not a Go file is generated by cgo
+// from an original Go file.
+func isCgoGeneratedFile(fileInfo *code.SourceFileInfo) bool {
+	return fileInfo.AstFile != nil && fileInfo.OriginalFile != "" && fileInfo.OriginalFile != fileInfo.GeneratedFile
+}
+
+// linesInOriginalFile rearranges the lines of a file generated by cgo by
+// their line numbers in the original file. The generated lines not from the
+// original file are dropped and the original lines without generated ones
+// are left blank. The line numbers in the original file of the generated
+// lines are also returned (0 for the dropped ones).
+func linesInOriginalFile(fileInfo *code.SourceFileInfo, file *token.File, lines []string) (originalLines []string, toOriginal []int) {
+	toOriginal = make([]int, len(lines))
+	originalLines = make([]string, 0, len(lines))
+	for i, line := range lines {
+		if i >= file.LineCount() {
+			break
+		}
+		original := fileInfo.OriginalPosition(file.PositionFor(file.LineStart(i+1), false))
+		if original.Filename != fileInfo.OriginalFile || original.Line <= 0 {
+			continue
+		}
+		toOriginal[i] = original.Line
+		for len(originalLines) < original.Line {
+			originalLines = append(originalLines, "")
+		}
+		if originalLines[original.Line-1] == "" {
+			originalLines[original.Line-1] = line
+		}
+	}
+	return
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
+
+	"go101.org/gold/code"
 )
 
 func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request) {
@@ -24,19 +28,98 @@ func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request) {
 	//}
 	//w.Write(ds.theStatisticsPage)
 
+	scope := r.FormValue("scope")
 	pageKey := pageCacheKey{
 		resType: ResTypeNone,
 		res:     "statistics",
+		options: scope,
 	}
 	data, ok := ds.cachedPage(pageKey)
 	if !ok {
-		data = ds.buildStatisticsPage(w)
+		stats, ok := ds.statisticsOfScope(scope)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Unknown statistics scope: %s", scope)
+			return
+		}
+		data = ds.buildStatisticsPage(w, scope, &stats)
 		ds.cachePage(pageKey, data)
 	}
 	w.Write(data)
 }
 
-func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
+// A statistics scope is one of the followings:
+// * "": all the analyzed packages.
+// * "std": the standard packages.
+// * "wd": the packages under the working directory.
+// * "mod:ModulePath": the packages in a module.
+// * "pkg:PackagePath": a single package.
+func (ds *docServer) statisticsOfScope(scope string) (stats code.Stats, ok bool) {
+	var pkgs []*code.Package
+	switch {
+	case scope == "":
+		return ds.analyzer.Statistics(), true
+	case scope == "std":
+		pkgs = ds.analyzer.StandardModule().Pkgs
+	case scope == "wd":
+		pkgs = packagesUnderDirectory(ds.analyzer, ds.workingDirectory)
+	case strings.HasPrefix(scope, "mod:"):
+		mod := ds.analyzer.ModuleByPath(scope[len("mod:"):])
+		if mod == nil {
+			return
+		}
+		pkgs = mod.Pkgs
+	case strings.HasPrefix(scope, "pkg:"):
+		pkg := ds.analyzer.PackageByPath(scope[len("pkg:"):])
+		if pkg == nil {
+			return
+		}
+		pkgs = []*code.Package{pkg}
+	default:
+		return
+	}
+	return ds.analyzer.StatisticsOf(pkgs), true
+}
+
+// buildStatisticsScopeHref returns the href of a statistics page or chart
+// for a scope. Scopes are not supported in the docs generation mode.
+func buildStatisticsScopeHref(currentPageInfo, linkedPageInfo pagePathInfo, scope string) string {
+	href := buildPageHref(currentPageInfo, linkedPageInfo, nil, "")
+	if scope != "" && !genDocsMode {
+		href += "?scope=" + url.QueryEscape(scope)
+	}
+	return href
+}
+
+func (ds *docServer) writeStatisticsScopes(page *htmlPage, currentScope string) {
+	scopes := []string{"", "std", "wd"}
+	for i := 0; i < ds.analyzer.NumModules(); i++ {
+		if mod := ds.analyzer.ModuleAt(i); mod.IsMain {
+			scopes = append(scopes, "mod:"+mod.Root)
+		}
+	}
+	for _, scope := range scopes {
+		if scope == currentScope {
+			goto Write
+		}
+	}
+	scopes = append(scopes, currentScope)
+
+Write:
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_StatisticsScopes())
+	for _, scope := range scopes {
+		page.WriteString("\n\t")
+		text := ds.currentTranslation.Text_StatisticsScope(scope)
+		if scope == currentScope {
+			fmt.Fprintf(page, "<b>%s</b>", text)
+		} else {
+			fmt.Fprintf(page, `<a href="%s">%s</a>`, buildStatisticsScopeHref(page.PathInfo, pagePathInfo{ResTypeNone, "statistics"}, scope), text)
+		}
+	}
+	page.WriteString("\n</code></pre>")
+}
+
+func (ds *docServer) buildStatisticsPage(w http.ResponseWriter, scope string, stats *code.Stats) []byte {
 	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Statistics(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "statistics"})
 	fmt.Fprintf(page, `
 <pre><code><span style="font-size:xx-large;">%s</span></code></pre>
@@ -44,7 +127,18 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		ds.currentTranslation.Text_Statistics(),
 	)
 
-	stats := ds.analyzer.Statistics()
+	if !genDocsMode {
+		ds.writeStatisticsScopes(page, scope)
+	}
+
+	var chartURL = func(chartName string) string {
+		return buildStatisticsScopeHref(page.PathInfo, pagePathInfo{ResTypeSVG, chartName}, scope)
+	}
+
+	var commentToCodeRatio float64
+	if codeLines := stats.CodeLines - stats.CommentCodeLines - stats.BlankCodeLines; codeLines > 0 {
+		commentToCodeRatio = float64(stats.CommentCodeLines) / float64(codeLines)
+	}
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("packages"))
 	page.WriteString(ds.currentTranslation.Text_PackageStatistics(map[string]interface{}{
@@ -57,8 +151,15 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"averageImportCountPerFile":        float64(stats.Imports) / float64(stats.AstFiles),
 		"averageDependencyCountPerPackage": float64(stats.AllPackageDeps) / float64(stats.Packages),
 
-		"gosourcefilesByImportsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "gosourcefiles-by-imports"}, nil, ""),
-		"packagesByDependenciesChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "packages-by-dependencies"}, nil, ""),
+		"codeLineCount":        stats.CodeLines,
+		"commentCodeLineCount": stats.CommentCodeLines,
+		"blankCodeLineCount":   stats.BlankCodeLines,
+		"commentToCodeRatio":   commentToCodeRatio,
+
+		"gosourcefilesByImportsChartURL":   chartURL("gosourcefiles-by-imports"),
+		"packagesByDependenciesChartURL":   chartURL("packages-by-dependencies"),
+		"gosourcefilesByCodelinesChartURL": chartURL("gosourcefiles-by-codelines"),
+		"packagesByCodelinesChartURL":      chartURL("packages-by-codelines"),
 	}))
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("types"))
@@ -70,29 +171,29 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"exportedIntergerTypeNames":  stats.ExportedIntergerTypeNames,
 		"exportedUnsignedTypeNames":  stats.ExportedUnsignedTypeNames,
 
-		"exportedtypenamesByKindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedtypenames-by-kinds"}, nil, ""),
+		"exportedtypenamesByKindsChartURL": chartURL("exportedtypenames-by-kinds"),
 
 		"exportedStructTypeNames":                     stats.ExportedTypeNamesByKind[reflect.Struct],
 		"exportedNamedStructTypesWithEmbeddingFields": stats.ExportedNamedStructTypesWithEmbeddingFields,
 		"exportedNamedStructTypesWithPromotedFields":  stats.ExportedNamedStructTypesWithPromotedFields,
 
-		"exportedstructtypesByEmbeddingfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-embeddingfields"}, nil, ""),
+		"exportedstructtypesByEmbeddingfieldsChartURL": chartURL("exportedstructtypes-by-embeddingfields"),
 
 		"exportedNamedStructTypeFieldsPerExportedStruct":                 float64(stats.ExportedNamedStructTypeFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
 		"exportedNamedStructTypeExplicitFieldsPerExportedStruct":         float64(stats.ExportedNamedStructTypeExplicitFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
 		"exportedNamedStructTypeExportedFieldsPerExportedStruct":         float64(stats.ExportedNamedStructTypeExportedFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
 		"exportedNamedStructTypeExportedExplicitFieldsPerExportedStruct": float64(stats.ExportedNamedStructTypeExportedExplicitFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
 
-		"exportedstructtypesByExplicitfieldsChartURL":         buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-explicitfields"}, nil, ""),
-		"exportedstructtypesByExportedexplicitfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedexplicitfields"}, nil, ""),
-		//"exportedstructtypesByExportedfieldsChartURL":         buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedfields"}, nil, ""),
-		"exportedstructtypesByExportedpromotedfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedpromotedfields"}, nil, ""),
+		"exportedstructtypesByExplicitfieldsChartURL":         chartURL("exportedstructtypes-by-explicitfields"),
+		"exportedstructtypesByExportedexplicitfieldsChartURL": chartURL("exportedstructtypes-by-exportedexplicitfields"),
+		//"exportedstructtypesByExportedfieldsChartURL":         chartURL("exportedstructtypes-by-exportedfields"),
+		"exportedstructtypesByExportedpromotedfieldsChartURL": chartURL("exportedstructtypes-by-exportedpromotedfields"),
 
 		"exportedNamedNonInterfacesExportedMethodsPerExportedNonInterfaceType": float64(stats.ExportedNamedNonInterfacesExportedMethods) / float64(stats.ExportedNamedNonInterfacesWithExportedMethods),
 		"exportedNamedInterfacesExportedMethodsPerExportedInterfaceType":       float64(stats.ExportedNamedInterfacesExportedMethods) / float64(stats.ExportedTypeNamesByKind[reflect.Interface]),
 
-		"exportednoninterfacetypesByExportedmethodsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportednoninterfacetypes-by-exportedmethods"}, nil, ""),
-		"exportedinterfacetypesByExportedmethodsChartURL":    buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedinterfacetypes-by-exportedmethods"}, nil, ""),
+		"exportednoninterfacetypesByExportedmethodsChartURL": chartURL("exportednoninterfacetypes-by-exportedmethods"),
+		"exportedinterfacetypesByExportedmethodsChartURL":    chartURL("exportedinterfacetypes-by-exportedmethods"),
 	}))
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("values"))
@@ -100,8 +201,8 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"exportedVariables": stats.ExportedVariables,
 		"exportedConstants": stats.ExportedConstants,
 
-		"exportedvariablesByTypekindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedvariables-by-typekinds"}, nil, ""),
-		"exportedconstantsByTypekindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedconstants-by-typekinds"}, nil, ""),
+		"exportedvariablesByTypekindsChartURL": chartURL("exportedvariables-by-typekinds"),
+		"exportedconstantsByTypekindsChartURL": chartURL("exportedconstants-by-typekinds"),
 
 		"exportedFunctions":                             stats.ExportedFunctions,
 		"exportedMethods":                               stats.ExportedMethods,
@@ -110,16 +211,69 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"exportedFunctionWithLastErrorResult":           stats.ExportedFunctionWithLastErrorResult,
 		"exportedFunctionWithLastErrorResultPercentage": int(math.Round(100 * float64(stats.ExportedFunctionWithLastErrorResult) / float64(stats.ExportedFunctions+stats.ExportedMethods))),
 
-		"exportedfunctionsByParametersChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedfunctions-by-parameters"}, nil, ""),
-		"exportedfunctionsByResultsChartURL":    buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedfunctions-by-results"}, nil, ""),
+		"exportedfunctionsByParametersChartURL": chartURL("exportedfunctions-by-parameters"),
+		"exportedfunctionsByResultsChartURL":    chartURL("exportedfunctions-by-results"),
 	}))
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("others"))
 	page.WriteString(ds.currentTranslation.Text_Othertatistics(map[string]interface{}{
 		"averageIdentiferLength": float64(stats.ExportedIdentifersSumLength) / float64(stats.ExportedIdentifers),
 
-		"exportedidentifiersByLengthsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedidentifiers-by-lengths"}, nil, ""),
+		"exportedidentifiersByLengthsChartURL": chartURL("exportedidentifiers-by-lengths"),
 	}))
 
+	if scope == "" {
+		ds.writeTopNLists(page)
+	}
+
 	return page.Done(w)
 }
+
+func (ds *docServer) writeTopNLists(page *htmlPage) {
+	lists := ds.analyzer.TopNLists()
+
+	var writePackages = func(items []code.RankedItem) {
+		for _, item := range items {
+			page.WriteString("\n\t\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, item.Pkg.Path()}, page, item.Pkg.Path())
+			fmt.Fprintf(page, " (%d)", item.Value)
+		}
+	}
+	var writeTypeNames = func(items []code.RankedItem) {
+		for _, item := range items {
+			page.WriteString("\n\t\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, item.Pkg.Path()}, page, item.Pkg.Path()+"."+item.Name, "name-", item.Name)
+			fmt.Fprintf(page, " (%d)", item.Value)
+		}
+	}
+	var writeIdentifiers = func(items []code.RankedItem) {
+		if !buildIdUsesPages {
+			writeTypeNames(items)
+			return
+		}
+		for _, item := range items {
+			page.WriteString("\n\t\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypeReference, item.Pkg.Path() + ".." + item.Name}, page, item.Pkg.Path()+"."+item.Name)
+			fmt.Fprintf(page, " (%d)", item.Value)
+		}
+	}
+
+	var writeList = func(listName string, items []code.RankedItem, writeItems func([]code.RankedItem)) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(page, "\n\n\t<i>%s</i>", ds.currentTranslation.Text_TopNList(listName))
+		writeItems(items)
+	}
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_StatisticsTitle("toplists"))
+	writeList("most-imported-packages", lists.MostImportedPackages, writePackages)
+	writeList("most-depending-packages", lists.MostDependingPackages, writePackages)
+	writeList("packages-with-most-files", lists.PackagesWithMostFiles, writePackages)
+	writeList("packages-with-most-codelines", lists.PackagesWithMostCodeLines, writePackages)
+	writeList("structs-with-most-fields", lists.StructsWithMostFields, writeTypeNames)
+	writeList("interfaces-with-most-methods", lists.InterfacesWithMostMethods, writeTypeNames)
+	writeList("most-implemented-interfaces", lists.MostImplementedInterfaces, writeTypeNames)
+	writeList("most-referenced-identifiers", lists.MostReferencedIdentifiers, writeIdentifiers)
+	page.WriteString("\n</code></pre>")
+}
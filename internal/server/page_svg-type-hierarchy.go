@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"html"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// A type hierarchy graph shows, for a named type, the interfaces it
+// implements (the left column), the types embedded in it, recursively
+// (under the type), and the types implementing it (the right column)
+// if it is an interface type. The resource path of the graph is
+// "types/pkg..TypeName".
+
+const typeHierarchySVGPrefix = "types/"
+
+const maxTypeHierarchyEmbeddingDepth = 8
+const maxTypeHierarchyColumnNodes = 64
+
+type typeHierarchyNode struct {
+	text  string
+	href  string // blank for no links
+	depth int    // for embedded types only
+}
+
+// typeHierarchyOf returns a nil tn if the type can't be found.
+func (ds *docServer) typeHierarchyOf(pkgPath, typeName string) (tn *code.TypeName, self typeHierarchyNode, implements, embeddings, implementers []typeHierarchyNode) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return
+	}
+	for _, t := range pkg.AllTypeNames {
+		if t.Name() == typeName && t.Alias == nil {
+			tn = t
+			break
+		}
+	}
+	if tn == nil {
+		return
+	}
+
+	svgPathInfo := pagePathInfo{ResTypeSVG, typeHierarchySVGPrefix + pkgPath + ".." + typeName}
+	var qualifier = func(p *types.Package) string {
+		if p == pkg.PPkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+	var newNode = func(tt types.Type, depth int) typeHierarchyNode {
+		node := typeHierarchyNode{text: types.TypeString(tt, qualifier), depth: depth}
+		if ptr, ok := tt.(*types.Pointer); ok {
+			tt = ptr.Elem()
+		}
+		if named, ok := types.Unalias(tt).(*types.Named); ok {
+			obj := named.Obj()
+			objPkgPath := "builtin"
+			if obj.Pkg() != nil {
+				objPkgPath = obj.Pkg().Path()
+			}
+			if ds.analyzer.PackageByPath(objPkgPath) != nil {
+				node.href = buildPageHref(svgPathInfo, pagePathInfo{ResTypePackage, objPkgPath}, nil, "")
+				if obj.Exported() || obj.Pkg() == nil {
+					node.href += "#name-" + obj.Name()
+				}
+			}
+		}
+		return node
+	}
+
+	t := tn.Denoting()
+	self = newNode(t.TT, 0)
+	for _, impl := range ds.analyzer.CleanImplements(t) {
+		implements = append(implements, newNode(impl.Interface.TT, 0))
+		if _, ok := impl.Impler.TT.(*types.Pointer); ok {
+			implements[len(implements)-1].text += " (*T)"
+		}
+	}
+
+	var visited = map[*code.TypeInfo]bool{t: true}
+	var collectEmbeddings func(t *code.TypeInfo, depth int)
+	collectEmbeddings = func(t *code.TypeInfo, depth int) {
+		if depth > maxTypeHierarchyEmbeddingDepth || t.Underlying == nil {
+			return
+		}
+		for _, sel := range t.Underlying.DirectSelectors {
+			if sel.Field == nil || sel.Field.Mode == code.EmbedMode_None || sel.Field.Type == nil {
+				continue
+			}
+			ft := sel.Field.Type
+			embeddings = append(embeddings, newNode(ft.TT, depth))
+			if ptr, ok := ft.TT.(*types.Pointer); ok {
+				ft = ds.analyzer.TryRegisteringType(ptr.Elem(), false)
+			}
+			if ft != nil && !visited[ft] {
+				visited[ft] = true
+				collectEmbeddings(ft, depth+1)
+				delete(visited, ft)
+			}
+		}
+	}
+	collectEmbeddings(t, 1)
+
+	for _, impler := range t.ImplementedBys {
+		implementers = append(implementers, newNode(impler.TT, 0))
+	}
+	return
+}
+
+func (ds *docServer) hasTypeHierarchy(tn *code.TypeName) bool {
+	if tn.Alias != nil || tn.Pkg.Path() == "builtin" || tn.Pkg.Path() == "unsafe" {
+		return false
+	}
+	t := tn.Denoting()
+	if len(t.ImplementedBys) > 0 || ds.analyzer.HasCleanImplements(t) {
+		return true
+	}
+	if t.Underlying != nil {
+		for _, sel := range t.Underlying.DirectSelectors {
+			if sel.Field != nil && sel.Field.Mode != code.EmbedMode_None {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeTypeHierarchyLink writes a small link to the type hierarchy graph
+// of a type declared in a package.
+func (ds *docServer) writeTypeHierarchyLink(page *htmlPage, tn *code.TypeName) {
+	if !ds.hasTypeHierarchy(tn) {
+		return
+	}
+	page.WriteString(` <i class="type-hierarchy">`)
+	buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, typeHierarchySVGPrefix + tn.Pkg.Path() + ".." + tn.Name()}, page, ds.currentTranslation.Text_TypeHierarchy())
+	page.WriteString(`</i>`)
+}
+
+// buildTypeHierarchySVG returns nil if the type is not found.
+func (ds *docServer) buildTypeHierarchySVG(pkgPath, typeName string) []byte {
+	tn, self, implements, embeddings, implementers := ds.typeHierarchyOf(pkgPath, typeName)
+	if tn == nil {
+		return nil
+	}
+
+	var numMoreImplementers int
+	if len(implementers) > maxTypeHierarchyColumnNodes {
+		numMoreImplementers = len(implementers) - maxTypeHierarchyColumnNodes
+		implementers = implementers[:maxTypeHierarchyColumnNodes]
+	}
+	if len(implements) > maxTypeHierarchyColumnNodes {
+		implements = implements[:maxTypeHierarchyColumnNodes]
+	}
+
+	const charW, nodeH, nodePaddingH, rowH = 7.2, 20, 6, 28
+	const marginH, marginV, columnGap, embeddingIndent, titleH = 12, 12, 48, 24, 24
+	var textWidth = func(text string) float64 {
+		return float64(len([]rune(text)))*charW + 2*nodePaddingH
+	}
+	var columnWidth = func(nodes []typeHierarchyNode, title string) float64 {
+		w := textWidth(title)
+		for _, n := range nodes {
+			if nw := textWidth(n.text) + float64(n.depth*embeddingIndent); nw > w {
+				w = nw
+			}
+		}
+		return w
+	}
+
+	leftTitle := ds.currentTranslation.Text_TypeHierarchyColumn("implements")
+	rightTitle := ds.currentTranslation.Text_TypeHierarchyColumn("implementedby")
+	if numMoreImplementers > 0 {
+		rightTitle += fmt.Sprintf(" (%d/%d)", len(implementers), len(implementers)+numMoreImplementers)
+	}
+
+	var leftW, rightW float64
+	if len(implements) > 0 {
+		leftW = columnWidth(implements, leftTitle)
+	}
+	if len(implementers) > 0 {
+		rightW = columnWidth(implementers, rightTitle)
+	}
+	centerW := columnWidth(embeddings, self.text)
+
+	leftX := float64(marginH)
+	centerX := leftX
+	if leftW > 0 {
+		centerX += leftW + columnGap
+	}
+	rightX := centerX + centerW + columnGap
+
+	svgW := centerX + centerW + marginH
+	if rightW > 0 {
+		svgW = rightX + rightW + marginH
+	}
+	numRows := 1 + len(embeddings)
+	if len(implements) > numRows {
+		numRows = len(implements)
+	}
+	if len(implementers) > numRows {
+		numRows = len(implementers)
+	}
+	svgH := marginV + titleH + numRows*rowH + marginV
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	fmt.Fprintf(buf, `<svg width="%.0f" height="%d" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+<rect fill="#fff" id="canvas_background" width="%.0f" height="%d" y="-1" x="-1"/>
+`,
+		svgW, svgH, svgW+2, svgH+2,
+	)
+
+	var writeText = func(x float64, y int, text, extraAttrs string) {
+		fmt.Fprintf(buf, `<text xml:space="preserve" font-family='"Courier New", Courier, monospace' font-size="12" x="%.1f" y="%d" fill="#000"%s>%s</text>
+`,
+			x, y, extraAttrs, html.EscapeString(text),
+		)
+	}
+	var writeNode = func(n typeHierarchyNode, x float64, y int, fill string) {
+		if n.href != "" {
+			fmt.Fprintf(buf, `<a xlink:href="%s" href="%s" target="_top">
+`, n.href, n.href)
+		}
+		fmt.Fprintf(buf, `<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="3" fill="%s" stroke="#666"/>
+`,
+			x, y, textWidth(n.text), nodeH, fill,
+		)
+		writeText(x+nodePaddingH, y+nodeH-6, n.text, "")
+		if n.href != "" {
+			buf.WriteString("</a>\n")
+		}
+	}
+	var writeLine = func(x1 float64, y1 int, x2 float64, y2 int) {
+		fmt.Fprintf(buf, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#999"/>
+`,
+			x1, y1, x2, y2,
+		)
+	}
+
+	topY := marginV + titleH
+	selfMidY := topY + nodeH/2
+	if len(implements) > 0 {
+		writeText(leftX, topY-8, leftTitle, ` font-style="italic"`)
+	}
+	if len(implementers) > 0 {
+		writeText(rightX, topY-8, rightTitle, ` font-style="italic"`)
+	}
+
+	for i, n := range implements {
+		y := topY + i*rowH
+		writeLine(leftX+textWidth(n.text), y+nodeH/2, centerX, selfMidY)
+		writeNode(n, leftX, y, "#eef")
+	}
+	for i, n := range implementers {
+		y := topY + i*rowH
+		writeLine(rightX, y+nodeH/2, centerX+textWidth(self.text), selfMidY)
+		writeNode(n, rightX, y, "#efe")
+	}
+
+	// The embedding tree. The line to an embedded type starts from
+	// the nearest node above it with a smaller depth.
+	var parentYs = make([]int, maxTypeHierarchyEmbeddingDepth+2)
+	parentYs[0] = topY + nodeH
+	for i, n := range embeddings {
+		y := topY + (i+1)*rowH
+		x := centerX + float64(n.depth*embeddingIndent)
+		lineX := x - embeddingIndent + nodePaddingH
+		writeLine(lineX, parentYs[n.depth-1], lineX, y+nodeH/2)
+		writeLine(lineX, y+nodeH/2, x, y+nodeH/2)
+		writeNode(n, x, y, "#ffe")
+		parentYs[n.depth] = y + nodeH
+	}
+
+	writeNode(self, centerX, topY, "#fdd")
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// parseTypeHierarchySVGPath parses "types/pkg..TypeName".
+func parseTypeHierarchySVGPath(svgFile string) (pkgPath, typeName string, ok bool) {
+	if !strings.HasPrefix(svgFile, typeHierarchySVGPrefix) {
+		return "", "", false
+	}
+	svgFile = svgFile[len(typeHierarchySVGPrefix):]
+	i := strings.LastIndex(svgFile, "..")
+	if i < 0 {
+		return "", "", false
+	}
+	return svgFile[:i], svgFile[i+2:], true
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+
+	"go101.org/gold/code"
 )
 
 func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile string) {
@@ -20,13 +22,25 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 	}
 	w.Header().Set("Content-Type", "image/svg+xml")
 
+	scope := r.FormValue("scope") // for statistics charts only
 	pageKey := pageCacheKey{
 		resType: ResTypeSVG,
 		res:     svgFile,
+		options: scope,
 	}
 	data, ok := ds.cachedPage(pageKey)
 	if !ok {
-		data = ds.buildSVG(svgFile)
+		if pkgPath, typeName, ok := parseTypeHierarchySVGPath(svgFile); ok {
+			data = ds.buildTypeHierarchySVG(pkgPath, typeName)
+		} else {
+			data = ds.buildSVG(svgFile, scope)
+		}
+		if data == nil {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "svg file ", svgFile, " is not found")
+			return
+		}
 		ds.cachePage(pageKey, data)
 
 		// For docs generation.
@@ -37,7 +51,12 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 	w.Write(data)
 }
 
-func (ds *docServer) buildSVG(svgFile string) (svgData []byte) {
+func (ds *docServer) buildSVG(svgFile, scope string) (svgData []byte) {
+	stats, ok := ds.statisticsOfScope(scope)
+	if !ok {
+		return nil
+	}
+
 	xName := func(max int) func(int) string {
 		return func(i int) string {
 			if i == max {
@@ -59,6 +78,16 @@ func (ds *docServer) buildSVG(svgFile string) (svgData []byte) {
 		}
 	}
 
+	xRange := func(step, max int) func(int) string {
+		return func(i int) string {
+			if i == max {
+				return fmt.Sprintf("(%d+)", i*step)
+			} else {
+				return fmt.Sprintf("[%d, %d)", i*step, (i+1)*step)
+			}
+		}
+	}
+
 	kindName := func(i int) string {
 		k := reflect.Kind(i + 1)
 		switch k {
@@ -73,13 +102,16 @@ func (ds *docServer) buildSVG(svgFile string) (svgData []byte) {
 		}
 	}
 
-	stats := ds.analyzer.Statistics()
 	chartTitle := ds.currentTranslation.Text_ChartTitle(svgFile)
 	switch svgFile {
 	case "gosourcefiles-by-imports":
 		svgData = createSourcefileImportsSVG(chartTitle, stats.FilesByImportCount[:], xName(len(stats.FilesByImportCount)-1))
 	case "packages-by-dependencies":
 		svgData = createSourcefileImportsSVG(chartTitle, stats.PackagesByDeps[:], xName(len(stats.PackagesByDeps)-1))
+	case "gosourcefiles-by-codelines":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.FilesByCodeLines[:], xRange(code.FileCodeLinesStep, len(stats.FilesByCodeLines)-1))
+	case "packages-by-codelines":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.PackagesByCodeLines[:], xRange(code.PackageCodeLinesStep, len(stats.PackagesByCodeLines)-1))
 	case "exportedtypenames-by-kinds":
 		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedTypeNamesByKind[1:], kindName)
 	case "exportedstructtypes-by-embeddingfields":
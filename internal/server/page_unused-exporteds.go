@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+type unusedExportedsPageOptions struct {
+	includeMainPackages     bool
+	includeInterfaceMethods bool
+}
+
+func (ds *docServer) unusedExportedsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "unused",
+	}
+	options, _ := ds.cachedPageOptions(pageKey).(unusedExportedsPageOptions)
+	switch r.FormValue("mains") {
+	case "include":
+		options.includeMainPackages = true
+	case "exclude":
+		options.includeMainPackages = false
+	}
+	switch r.FormValue("ifacemethods") {
+	case "include":
+		options.includeInterfaceMethods = true
+	case "exclude":
+		options.includeInterfaceMethods = false
+	}
+	ds.cachePageOptions(pageKey, options)
+
+	pageKey.options = options
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		unuseds := ds.analyzer.UnusedExporteds(packagesUnderDirectory(ds.analyzer, ds.workingDirectory))
+		unuseds = filterUnusedExporteds(unuseds, options.includeMainPackages, options.includeInterfaceMethods)
+		data = ds.buildUnusedExportedsPage(w, unuseds, options)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// packagesUnderDirectory returns the analyzed packages
+// in a directory or (recursively) in its subdirectories.
+func packagesUnderDirectory(analyzer *code.CodeAnalyzer, dir string) []*code.Package {
+	var pkgs []*code.Package
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		if pkg.Directory != "" && isUnderDirectory(pkg.Directory, dir) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+// isUnderDirectory reports whether or not path is dir or
+// in dir. A sibling path sharing a prefix with dir is not.
+func isUnderDirectory(path, dir string) bool {
+	sep := string(filepath.Separator)
+	dir = strings.TrimSuffix(dir, sep)
+	return path == dir || strings.HasPrefix(path, dir+sep)
+}
+
+func filterUnusedExporteds(unuseds []code.UnusedExported, includeMainPackages, includeInterfaceMethods bool) []code.UnusedExported {
+	var filtered = unuseds[:0]
+	for _, u := range unuseds {
+		if !includeMainPackages && u.Pkg.PPkg.Name == "main" {
+			continue
+		}
+		if !includeInterfaceMethods && u.SatisfiesInterface {
+			continue
+		}
+		filtered = append(filtered, u)
+	}
+	return filtered
+}
+
+func (ds *docServer) buildUnusedExportedsPage(w http.ResponseWriter, unuseds []code.UnusedExported, options unusedExportedsPageOptions) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_UnusedExporteds(), ds.currentTheme, ds.currentTranslation, pagePathInfo{ResTypeNone, "unused"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		ds.currentTranslation.Text_UnusedExporteds(),
+	)
+
+	var writeFilter = func(item, param string, included bool) {
+		page.WriteString("\t")
+		page.WriteString(ds.currentTranslation.Text_UnusedExportedsFilter(item, !included))
+		if genDocsMode {
+			return
+		}
+		var action = "include"
+		if included {
+			action = "exclude"
+		}
+		fmt.Fprintf(page, ` (<a href="?%s=%s">%s</a>)`, param, action, ds.currentTranslation.Text_UnusedExportedsFilterAction(included))
+	}
+	page.WriteString("<pre><code>")
+	writeFilter("mainpackages", "mains", options.includeMainPackages)
+	page.WriteString("\n")
+	writeFilter("interfacemethods", "ifacemethods", options.includeInterfaceMethods)
+	page.WriteString("\n</code></pre>\n")
+
+	var numPkgs int
+	for i, u := range unuseds {
+		if i == 0 || u.Pkg != unuseds[i-1].Pkg {
+			numPkgs++
+		}
+	}
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_UnusedExportedsStat(len(unuseds), numPkgs))
+	for i, u := range unuseds {
+		if i == 0 || u.Pkg != unuseds[i-1].Pkg {
+			page.WriteString("\n\n\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, u.Pkg.Path()}, page, u.Pkg.Path())
+		}
+		page.WriteString("\n\t\t")
+		page.WriteString(ds.currentTranslation.Text_ObjectKind(u.Kind))
+		page.WriteByte(' ')
+		writeSrouceCodeLineLink(page, u.Pkg, u.Position, u.Name, "")
+		if u.SatisfiesInterface {
+			page.WriteByte(' ')
+			page.WriteString(ds.currentTranslation.Text_SatisfyingInterfaces())
+		}
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(w)
+}
@@ -51,6 +51,7 @@ type Translation interface {
 	Text_Analyzing_MakeStatistics(d time.Duration) string
 	Text_Analyzing_CollectSourceFiles(d time.Duration) string
 	Text_Analyzing_CollectObjectReferences(d time.Duration) string
+	Text_Analyzing_BuildCallGraph(d time.Duration) string
 	Text_Analyzing_CacheSourceFiles(d time.Duration) string
 
 	// overview page
@@ -58,9 +59,9 @@ type Translation interface {
 	Text_PackageList() string
 	Text_StatisticsWithMoreLink(detailedStatsLink string) string
 	Text_SimpleStats(stats *code.Stats) string
-	Text_Modules() string                                    // to use
-	Text_BelongingModule() string                            // to use
-	Text_RequireStat(numRequires, numRequiredBys int) string // to use
+	Text_Modules() string
+	Text_BelongingModule() string                            // also used in package details page
+	Text_RequireStat(numRequires, numRequiredBys int) string // also used in module page
 	Text_UpdateTip(tipName string) string                    // tip names: "ToUpdate", "Updating", "Updated"
 
 	Text_SortBy() string                // also used in other pages
@@ -68,6 +69,10 @@ type Translation interface {
 	Text_SortByItem(by string) string   // also used in other pages
 	Text_FilterItem(fltr string) string // also used in other pages
 
+	// module page
+	Text_Module(modulePath string) string
+	Text_ModuleItem(item string) string // items: "version", "goversion", "directory", "packages", "requires", "replaces", "requiredbys"
+
 	// package details page
 	Text_Package(pkgPath string) string
 	Text_BelongingPackage() string // also used in source code page
@@ -75,10 +80,28 @@ type Translation interface {
 	Text_ImportPath() string
 	Text_ImportStat(numImports, numImportedBys int, depPageURL string) string
 	Text_InvolvedFiles(num int) string
+	Text_CodeLines() string
+	Text_CodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int, commentToCodeRatio float64) string
+	Text_FileCodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int) string
+	Text_FunctionMetrics(numFunctions int) string
+	Text_FunctionMetricsColumn(column string) string // columns: "name", "statements", "lines", "complexity", "nesting", "params", "results", "returns"
+	Text_Platforms(primary string) string
+	Text_OnlyForPlatforms(platforms string) string
+	Text_OtherPlatformDeclarations(num int) string
+	Text_AssemblyImplementations() string
+	Text_LinkedTo() string   // also used in source code page
+	Text_LinkedFrom() string // also used in source code page
+	Text_EmbeddedFiles(num int) string
+	Text_Directives(num int) string
+	Text_Deprecated() string // used in badges
+	Text_DeprecatedIdentifiers(num int) string
+	Text_DeprecatedIdentifiersState(hidden bool) string
+	Text_DeprecatedIdentifiersAction(hide bool) string
 	Text_ExportedValues(num int) string
 	Text_ExportedTypeNames(num int) string
 	Text_AllPackageLevelTypeNames(num int) string
 	Text_TypeNameListShowOption(exportedsOnly bool) string
+	Text_TestFunctions(kind string, num int) string // kinds: "test", "benchmark", "fuzz", "example"
 
 	Text_Fields(num int, exportedsOnly bool) string // ToDo: merge these into one?
 	Text_Methods(num int, exportedsOnly bool) string
@@ -87,6 +110,12 @@ type Translation interface {
 	Text_AsOutputsOf(num int) string
 	Text_AsInputsOf(num int) string
 	Text_AsTypesOf(num int) string
+	Text_Instantiations(num int) string
+	Text_MemoryLayout(size, padding int64) string
+	Text_MemoryLayoutColumns() string
+	Text_PaddingBytes(num int64) string
+	Text_MemoryLayoutSummary(size, align int64, platform string) string
+	Text_SuggestedFieldOrder(size int64) string
 	Text_References(num int) string
 
 	// package dependencies page
@@ -100,18 +129,70 @@ type Translation interface {
 
 	// object references(uses) page
 	Text_ReferenceList() string
-	Text_ObjectKind(kind string) string
-	Text_ObjectUses(num int) string // also used in other pages
+	Text_ObjectKind(kind string) string // also used in other pages. Kinds: "field", "method", "type", "func", "var", "const"
+	Text_ObjectUses(num int) string     // also used in other pages
+	Text_LinkedFunctionUses(num int) string
+
+	// call graph page
+	Text_CallGraph() string // also used in other pages
+	Text_Callers(numCalls int) string
+	Text_Callees(numCalls int) string
+	Text_CallThroughInterfaceMethod(method string) string
+	Text_PossibleCallees(num int) string
+	Text_CurrentPackage() string
+
+	// method sets page
+	Text_MethodSets() string // also used in package details page
+	Text_MethodSetOf(pointer bool, numMethods int) string
+	Text_PromotedThrough(embeddingPath string) string
+	Text_ImplementedByValueAndPointer() string
+	Text_ImplementedByPointerOnly() string
+
+	// type hierarchy graph
+	Text_TypeHierarchy() string                    // used in package details page
+	Text_TypeHierarchyColumn(column string) string // columns: "implements", "implementedby"
 
 	// source code page
 	Text_SourceCode(pkgPath, bareFilename string) string
 	Text_SourceFilePath() string
 	Text_GeneratedFrom() string
+	Text_BinaryFile(size int) string
+
+	// unused exported identifiers page
+	Text_Reports() string // used in overview page
+	Text_UnusedExporteds() string
+	Text_UnusedExportedsStat(numIdentifiers, numPackages int) string
+	Text_UnusedExportedsFilter(item string, excluded bool) string // items: "mainpackages", "interfacemethods"
+	Text_UnusedExportedsFilterAction(exclude bool) string
+	Text_SatisfyingInterfaces() string
+
+	// deprecated uses page
+	Text_DeprecatedUses() string // also used in overview page
+	Text_DeprecatedUsesStat(numUses, numPackages int) string
+
+	// most complex functions page
+	Text_MostComplexFunctions() string // also used in overview page
+	Text_MostComplexFunctionsStat(numFunctions, maxNumFunctions int) string
+
+	// API diff page
+	Text_APIChanges() string // also used in overview page
+	Text_APIDiffBeingComputed() string
+	Text_APIDiffStat(numPackages, numBreakingChanges int) string
+	Text_APIChangeKind(kind string) string // kinds: "package", "implements" and the ones of Text_ObjectKind
+	Text_BreakingChange() string
+
+	// errors page
+	Text_Errors() string
+	Text_IllTypedPackages(num int) string // also used in other pages
+	Text_IllTyped() string
 
 	// statistics
 	Text_Statistics() string
 	Text_ChartTitle(chartName string) string
 	Text_StatisticsTitle(titleName string) string
+	Text_TopNList(listName string) string
+	Text_StatisticsScopes() string
+	Text_StatisticsScope(scope string) string // also used in module and package details pages
 	Text_PackageStatistics(values map[string]interface{}) string
 	Text_TypeStatistics(values map[string]interface{}) string
 	Text_ValueStatistics(values map[string]interface{}) string
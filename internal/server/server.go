@@ -43,7 +43,9 @@ type docServer struct {
 	translationsByLangTagIndex []Translation
 
 	//
-	phase           int
+	phase int
+	// The analyzer might be replaced in the watch mode,
+	// so it must be accessed with the mutex locked.
 	analyzer        *code.CodeAnalyzer
 	analyzingLogger *log.Logger
 	analyzingLogs   []LoadingLogMessage
@@ -60,6 +62,17 @@ type docServer struct {
 	cachedPages        map[pageCacheKey][]byte
 	cachedPagesOptions map[pageCacheKey]interface{} // key.options must be nil in this map
 
+	// The watch mode.
+	watching      bool
+	watchLogger   *log.Logger
+	sourceVersion int // increased when source files are re-analyzed
+	// The source versions when the packages were last affected
+	// by re-analyzing. Zero for the never affected ones.
+	packageVersions map[string]int
+
+	// The API diff between two git revisions (nil for disabled).
+	apiDiff *apiDiffResult
+
 	//
 	currentTheme       Theme
 	currentTranslation Translation
@@ -76,7 +89,9 @@ type docServer struct {
 	visited       int32
 }
 
-func Run(recommendedPort, lang string, args []string, silentMode bool, goldVersion string, printUsage func(io.Writer), roughBuildTime func() time.Time) {
+// If watch is true, packages will be re-analyzed when source files change.
+// apiDiffRefs is in the form OLD..NEW. Blank means not to compute the API diff.
+func Run(recommendedPort, lang string, args []string, parseOptions code.ParseOptions, watch bool, apiDiffRefs string, silentMode bool, goldVersion string, printUsage func(io.Writer), roughBuildTime func() time.Time) {
 	ds := &docServer{
 		goldVersion: goldVersion,
 
@@ -85,6 +100,9 @@ func Run(recommendedPort, lang string, args []string, silentMode bool, goldVersi
 		analyzingLogger: log.New(os.Stdout, "[Analyzing] ", 0),
 		analyzingLogs:   make([]LoadingLogMessage, 0, 64),
 
+		watching:    watch,
+		watchLogger: log.New(os.Stdout, "[Watch] ", 0),
+
 		updateLogger:   log.New(os.Stdout, "[Update] ", 0),
 		roughBuildTime: roughBuildTime,
 	}
@@ -128,12 +146,24 @@ NextTry:
 	}
 
 	go func() {
-		ds.analyze(args, printUsage)
+		ds.analyze(args, parseOptions, printUsage)
 		ds.analyzingLogger.SetPrefix("")
 		serverStarted := ds.currentTranslationSafely().Text_Server_Started()
 		ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, port)
+		if ds.watching {
+			ds.watch(args, parseOptions)
+		}
 	}()
 
+	if apiDiffRefs != "" {
+		oldRef, newRef, err := parseAPIDiffRefs(apiDiffRefs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ds.apiDiff = &apiDiffResult{oldRef: oldRef, newRef: newRef}
+		go ds.computeAPIDiff(args, parseOptions)
+	}
+
 	if !silentMode {
 		err = util.OpenBrowser(fmt.Sprintf("http://localhost:%v", port))
 		if err != nil {
@@ -179,6 +209,16 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		case "statistics":
 			ds.statisticsPage(w, r)
+		case "errors":
+			ds.errorsPage(w, r)
+		case "unused":
+			ds.unusedExportedsPage(w, r)
+		case "apidiff":
+			ds.apiDiffPage(w, r)
+		case "complexity":
+			ds.complexFunctionsPage(w, r)
+		case "deprecated":
+			ds.deprecatedUsesPage(w, r)
 		}
 		return
 	}
@@ -196,6 +236,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			ds.updateAPI(w, r)
 		case "load":
 			ds.loadAPI(w, r)
+		case "watch":
+			ds.watchAPI(w, r)
 		}
 	case ResTypeCSS: // "css"
 		ds.cssFile(w, r, removeVersionFromFilename(resPath, ds.goldVersion))
@@ -205,8 +247,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ds.svgFile(w, r, resPath)
 	case ResTypePNG: // "png"
 		ds.pngFile(w, r, resPath)
-	//case "mod:": // module
-	//	ds.modulePage(w, r, path)
+	case ResTypeModule: // "mod"
+		ds.modulePage(w, r, resPath)
 	case ResTypePackage: // "pkg"
 		ds.packageDetailsPage(w, r, resPath)
 	case ResTypeDependency: // "dep"
@@ -229,6 +271,15 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.methodImplementationPage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeMethodSets: // "mts"
+		// As pkg might contains ".", so here we use ".." the seperator.
+		const sep = ".."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Type containing package is not specified")
+		} else {
+			ds.methodSetsPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	case ResTypeReference: // "ref"
 		// resPath doesn't contian unexported selectors with their package path prefixes for sure.
 		// Two forms: pkg..id or pkg..type.selector.
@@ -241,12 +292,21 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.identifierReferencePage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeCallGraph: // "cal"
+		// Two forms: pkg..function or pkg..type.method.
+		const sep = ".."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Function containing package is not specified")
+		} else {
+			ds.callGraphPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	}
 }
 
 var sem = make(chan struct{}, 10)
 
-func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
+func (ds *docServer) analyze(args []string, parseOptions code.ParseOptions, printUsage func(io.Writer)) {
 	ds.workingDirectory, _ = os.Getwd()
 
 	var stopWatch = util.NewStopWatch()
@@ -270,7 +330,9 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 		return ds.currentTranslationSafely().Text_Analyzing_Start()
 	})
 
-	if !ds.analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, args...) {
+	// The analyzer is published after it is ready.
+	analyzer := &code.CodeAnalyzer{}
+	if !analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, parseOptions, args...) {
 		if printUsage != nil {
 			printUsage(os.Stdout)
 		}
@@ -283,10 +345,11 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 	//	ds.mutex.Unlock()
 	//}
 
-	ds.analyzer.AnalyzePackages(ds.onAnalyzingSubTaskDone)
+	analyzer.AnalyzePackages(ds.onAnalyzingSubTaskDone)
 
 	{
 		ds.mutex.Lock()
+		ds.analyzer = analyzer
 		ds.phase = Phase_Analyzed
 		//ds.packagePages = make(map[string]packagePage, ds.analyzer.NumPackages())
 		//ds.implPages = make(map[implPageKey][]byte, ds.analyzer.RoughTypeNameCount())
@@ -11,6 +11,24 @@ body {color: #333; font-family: {{ .Fonts }};}
 a {color: #079;}
 a.path-duplicate {color: #9cd;}
 .module-version {color: #555; font-style: italic; font-size: smaller; text-decoration: none;}
+.platforms {color: #777; font-size: smaller;}
+.deprecated {color: #c60; font-size: smaller; border: 1px solid #ec9; border-radius: 3px; padding: 0 2px;}
+.deprecation-notice {color: #777; font-size: smaller;}
+.doc-heading {font-weight: bold;}
+.doc-code {color: #555;}
+.directive {color: #777; font-size: smaller; border: 1px solid #ccc; border-radius: 3px; padding: 0 2px;}
+.call-graph, .type-hierarchy, .method-sets, .asm-impls, .linknames, .embed-files {font-size: smaller;}
+table.method-sets td {vertical-align: top; padding-right: 32px;}
+.pointer-only-method {color: #c60;}
+table.function-metrics {font-size: smaller; border-collapse: collapse; margin-left: 16pt;}
+table.function-metrics th, table.function-metrics td {padding: 1px 8px;}
+table.function-metrics td {text-align: right;}
+table.function-metrics td:first-child {text-align: left;}
+.ill-typed a, .package-error {color: #c33;}
+pre.line-numbers span.package-error {margin-left: 44pt;}
+.api-added {color: #393;}
+.api-removed, .api-breaking {color: #c33;}
+.api-changed {color: #c93;}
 ol.package-list {line-height: 139%;}
 h3 {background: #ddd;}
 
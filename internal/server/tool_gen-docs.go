@@ -30,6 +30,7 @@ var (
 	testingMode            = false
 	genDocsMode            = false
 	buildIdUsesPages       = true  // might be false in gen mode
+	buildCallGraphPages    = true  // might be false in gen mode
 	enableSoruceNavigation = true  // false to disable method implementation pages and some code reading features
 	emphasizeWdPackages    = false // list packages in the current directory before other packages
 
@@ -247,6 +248,9 @@ Generate:
 	if !buildIdUsesPages && linkedPageInfo.resType == ResTypeReference {
 		panic("identifer-uses page (" + linkedPageInfo.resPath + ") should not be build")
 	}
+	if !buildCallGraphPages && linkedPageInfo.resType == ResTypeCallGraph {
+		panic("call-graph page (" + linkedPageInfo.resPath + ") should not be build")
+	}
 	if !enableSoruceNavigation && linkedPageInfo.resType == ResTypeImplementation {
 		panic("method-implementation page (" + linkedPageInfo.resPath + ") should not be build")
 	}
@@ -327,6 +331,7 @@ func GenDocs(outputDir string, args []string, lang string, options DocsGeneratio
 	}
 
 	buildIdUsesPages = !options.NoIdentifierUsesPages || forTesting
+	buildCallGraphPages = !options.NoCallGraphPages || forTesting
 	enableSoruceNavigation = !options.PlainSourceCodePages || forTesting
 	emphasizeWdPackages = options.EmphasizeWdPkgs || forTesting
 
@@ -337,7 +342,10 @@ func GenDocs(outputDir string, args []string, lang string, options DocsGeneratio
 		analyzer:    &code.CodeAnalyzer{},
 	}
 	ds.initSettings(lang)
-	ds.analyze(args, printUsage)
+	ds.analyze(args, options.ParseOptions, printUsage)
+	if options.APIDiffRefs != "" {
+		ds.genAPIDiff(options.APIDiffRefs, args, options.ParseOptions)
+	}
 
 	// ...
 	outputDir = filepath.Join(outputDir, "generated-"+time.Now().Format("20060102150405"))
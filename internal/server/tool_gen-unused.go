@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go101.org/gold/code"
+)
+
+func buildUnusedExportedsReport(args []string, options DocsGenerationOptions) []byte {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	var analyzer code.CodeAnalyzer
+	if !analyzer.ParsePackages(nil, options.ParseOptions, args...) {
+		log.Fatal("failed to parse packages")
+	}
+	analyzer.AnalyzePackages(nil)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalln("Getwd error:", err)
+	}
+
+	unuseds := analyzer.UnusedExporteds(packagesUnderDirectory(&analyzer, wd))
+	unuseds = filterUnusedExporteds(unuseds, options.UnusedIncludeMainPackages, options.UnusedIncludeInterfaceMethods)
+
+	var buf bytes.Buffer
+	for i, u := range unuseds {
+		if i == 0 || u.Pkg != unuseds[i-1].Pkg {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(u.Pkg.Path())
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "\t%s:%d: %s %s", filepath.Base(u.Position.Filename), u.Position.Line, u.Kind, u.Name)
+		if u.SatisfiesInterface {
+			buf.WriteString(" (satisfying interfaces)")
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// GenUnusedExportedsReport writes the report to stdout if outputDir is blank.
+func GenUnusedExportedsReport(outputDir string, args []string, options DocsGenerationOptions) {
+	report := buildUnusedExportedsReport(args, options)
+
+	if outputDir == "" {
+		os.Stdout.Write(report)
+		return
+	}
+
+	reportFilePath := filepath.Join(outputDir, "generated-unused-"+time.Now().Format("20060102150405"), "unused.txt")
+
+	if err := os.MkdirAll(filepath.Dir(reportFilePath), 0700); err != nil {
+		log.Fatalln("Mkdir error:", err)
+	}
+
+	if err := ioutil.WriteFile(reportFilePath, report, 0644); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+
+	log.Printf("Report generated at %s", reportFilePath)
+}
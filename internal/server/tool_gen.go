@@ -4,14 +4,26 @@ import (
 	"io"
 	"log"
 	"os"
+
+	"go101.org/gold/code"
 )
 
 type DocsGenerationOptions struct {
 	NoIdentifierUsesPages bool
+	NoCallGraphPages      bool
 	PlainSourceCodePages  bool
 	SilentMode            bool
 	IncreaseGCFrequency   bool
 	EmphasizeWdPkgs       bool
+
+	// For the "unused" intent.
+	UnusedIncludeMainPackages     bool
+	UnusedIncludeInterfaceMethods bool
+
+	// In the form OLD..NEW. Blank means not to generate the API diff page.
+	APIDiffRefs string
+
+	ParseOptions code.ParseOptions
 }
 
 func Gen(intent, outputDir, lang string, args []string, options DocsGenerationOptions, goldVersion string, printUsage func(io.Writer), viewDocsCommand func(string) string) {
@@ -29,6 +41,8 @@ func Gen(intent, outputDir, lang string, args []string, options DocsGenerationOp
 		GenDocs(outputDir, args, lang, options, goldVersion, printUsage, viewDocsCommand)
 	case "testdata":
 		GenTestData(outputDir, args, options.SilentMode, goldVersion, printUsage)
+	case "unused":
+		GenUnusedExportedsReport(outputDir, args, options)
 	}
 
 	// ...
@@ -2,6 +2,7 @@ package translations
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"go101.org/gold/code"
@@ -115,6 +116,10 @@ func (*Chinese) Text_Analyzing_CollectObjectReferences(d time.Duration) string {
 	return fmt.Sprintf("搜集代码元素对象引用：%s", d)
 }
 
+func (*Chinese) Text_Analyzing_BuildCallGraph(d time.Duration) string {
+	return fmt.Sprintf("构建函数调用关系图：%s", d)
+}
+
 func (*Chinese) Text_Analyzing_CacheSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("缓存源文件：%s", d)
 }
@@ -213,6 +218,34 @@ func (*Chinese) Text_FilterItem(fltr string) string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Module(modulePath string) string {
+	return fmt.Sprintf("模块：%s", modulePath)
+}
+
+func (*Chinese) Text_ModuleItem(item string) string {
+	switch item {
+	case "version":
+		return "版本"
+	case "goversion":
+		return "Go版本"
+	case "directory":
+		return "目录"
+	case "packages":
+		return "代码包"
+	case "requires":
+		return "需要的模块"
+	case "replaces":
+		return "模块替换"
+	case "requiredbys":
+		return "被这些模块需要"
+	}
+	return ""
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -249,6 +282,100 @@ func (*Chinese) Text_ImportStat(numImports, numImportedBys int, depPageURL strin
 
 func (*Chinese) Text_InvolvedFiles(num int) string { return "相关源文件" }
 
+func (*Chinese) Text_CodeLines() string { return "代码行数" }
+
+func (*Chinese) Text_CodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int, commentToCodeRatio float64) string {
+	return fmt.Sprintf("共%d行：%d行代码，%d行注释，%d行空行（注释行数和代码行数的比例为%.2f）", numLines, numCodeLines, numCommentLines, numBlankLines, commentToCodeRatio)
+}
+
+func (*Chinese) Text_FileCodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int) string {
+	return fmt.Sprintf("共%d行：%d行代码，%d行注释，%d行空行", numLines, numCodeLines, numCommentLines, numBlankLines)
+}
+
+func (*Chinese) Text_FunctionMetrics(numFunctions int) string {
+	return fmt.Sprintf("函数度量（%d）", numFunctions)
+}
+
+func (*Chinese) Text_FunctionMetricsColumn(column string) string {
+	switch column {
+	case "name":
+		return "函数"
+	case "statements":
+		return "语句数"
+	case "lines":
+		return "行数"
+	case "complexity":
+		return "圈复杂度"
+	case "nesting":
+		return "最大嵌套深度"
+	case "params":
+		return "参数数"
+	case "results":
+		return "结果数"
+	case "returns":
+		return "返回语句数"
+	default:
+		panic("unknown function metrics column: " + column)
+	}
+}
+
+func (*Chinese) Text_Platforms(primary string) string {
+	return fmt.Sprintf("平台（完整分析针对%s）", primary)
+}
+
+func (*Chinese) Text_OnlyForPlatforms(platforms string) string {
+	return fmt.Sprintf("[仅适用于%s]", platforms)
+}
+
+func (*Chinese) Text_OtherPlatformDeclarations(num int) string {
+	return "仅适用于其它平台的声明"
+}
+
+func (*Chinese) Text_AssemblyImplementations() string {
+	return "汇编实现"
+}
+
+func (*Chinese) Text_LinkedTo() string {
+	return "链接到："
+}
+
+func (*Chinese) Text_LinkedFrom() string {
+	return "被链接自："
+}
+
+func (*Chinese) Text_EmbeddedFiles(num int) string {
+	if num == 0 {
+		return "（无嵌入文件）"
+	}
+	return fmt.Sprintf("%d个嵌入文件：", num)
+}
+
+func (*Chinese) Text_Directives(num int) string {
+	return fmt.Sprintf("编译指令（%d）", num)
+}
+
+func (*Chinese) Text_Deprecated() string {
+	return "已弃用"
+}
+
+func (*Chinese) Text_DeprecatedIdentifiers(num int) string {
+	return fmt.Sprintf("已弃用的标识符（%d）", num)
+}
+
+func (*Chinese) Text_DeprecatedIdentifiersState(hidden bool) string {
+	if hidden {
+		return "它们在下面的列表中被隐藏了。"
+	}
+	return "它们在下面的列表中被标记了出来。"
+}
+
+func (*Chinese) Text_DeprecatedIdentifiersAction(hide bool) string {
+	if hide {
+		return "隐藏它们"
+	}
+	return "显示它们"
+}
+
 func (*Chinese) Text_ExportedValues(num int) string {
 	return "导出值"
 }
@@ -269,6 +396,20 @@ func (*Chinese) Text_TypeNameListShowOption(exportedsOnly bool) string {
 	}
 }
 
+func (*Chinese) Text_TestFunctions(kind string, num int) string {
+	switch kind {
+	case "test":
+		return "测试"
+	case "benchmark":
+		return "基准测试"
+	case "fuzz":
+		return "模糊测试"
+	case "example":
+		return "示例"
+	}
+	return ""
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -309,6 +450,33 @@ func (*Chinese) Text_AsTypesOf(num int) string {
 	return fmt.Sprintf("和此类型相关的值（%d+）", num)
 }
 
+func (*Chinese) Text_Instantiations(num int) string {
+	return fmt.Sprintf("此泛型类型的实例化类型（%d+）", num)
+}
+
+func (*Chinese) Text_MemoryLayout(size, padding int64) string {
+	if padding == 0 {
+		return fmt.Sprintf("内存布局（%d字节）", size)
+	}
+	return fmt.Sprintf("内存布局（%d字节，其中%d字节为填充）", size, padding)
+}
+
+func (*Chinese) Text_MemoryLayoutColumns() string {
+	return "  偏移  尺寸  对齐  字段"
+}
+
+func (*Chinese) Text_PaddingBytes(num int64) string {
+	return fmt.Sprintf("（%d字节填充）", num)
+}
+
+func (*Chinese) Text_MemoryLayoutSummary(size, align int64, platform string) string {
+	return fmt.Sprintf("尺寸：%d；对齐保证：%d（%s）", size, align, platform)
+}
+
+func (*Chinese) Text_SuggestedFieldOrder(size int64) string {
+	return fmt.Sprintf("建议的字段顺序（尺寸：%d）：", size)
+}
+
 func (*Chinese) Text_References(num int) string {
 	return fmt.Sprintf("引用（%d+）", num)
 }
@@ -358,6 +526,14 @@ func (*Chinese) Text_ObjectKind(kind string) string {
 		return "字段"
 	case "method":
 		return "方法"
+	case "type":
+		return "类型"
+	case "func":
+		return "函数"
+	case "var":
+		return "变量"
+	case "const":
+		return "常量"
 	default:
 		panic("unknown object kind name: " + kind)
 	}
@@ -367,6 +543,88 @@ func (*Chinese) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d处使用", num)
 }
 
+func (*Chinese) Text_LinkedFunctionUses(num int) string {
+	return fmt.Sprintf("（通过//go:linkname链接）%d处使用", num)
+}
+
+///////////////////////////////////////////////////////////////////
+// call graph page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_CallGraph() string {
+	return "调用关系"
+}
+
+func (*Chinese) Text_Callers(numCalls int) string {
+	return fmt.Sprintf("被调用（%d处）", numCalls)
+}
+
+func (*Chinese) Text_Callees(numCalls int) string {
+	return fmt.Sprintf("调用（%d处）", numCalls)
+}
+
+func (*Chinese) Text_CallThroughInterfaceMethod(method string) string {
+	return fmt.Sprintf("<i>（通过%s）</i>", method)
+}
+
+func (*Chinese) Text_PossibleCallees(num int) string {
+	if num == 0 {
+		return "<i>（无已知实现）</i>"
+	}
+	return fmt.Sprintf("%d个可能的被调用者", num)
+}
+
+func (*Chinese) Text_CurrentPackage() string {
+	return "<i>（当前代码包）</i>"
+}
+
+///////////////////////////////////////////////////////////////////
+// method sets page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_MethodSets() string {
+	return "方法集"
+}
+
+func (*Chinese) Text_MethodSetOf(pointer bool, numMethods int) string {
+	var t = "T"
+	if pointer {
+		t = "*T"
+	}
+	return fmt.Sprintf("%s的方法集（%d个方法）", t, numMethods)
+}
+
+func (*Chinese) Text_PromotedThrough(embeddingPath string) string {
+	return "（通过" + embeddingPath + "提升而来）"
+}
+
+func (*Chinese) Text_ImplementedByValueAndPointer() string {
+	return "T和*T均实现了此接口"
+}
+
+func (*Chinese) Text_ImplementedByPointerOnly() string {
+	return "只有*T实现了此接口"
+}
+
+///////////////////////////////////////////////////////////////////
+// type hierarchy graph
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_TypeHierarchy() string {
+	return "类型层次图"
+}
+
+func (*Chinese) Text_TypeHierarchyColumn(column string) string {
+	switch column {
+	case "implements":
+		return "所实现的接口"
+	case "implementedby":
+		return "实现者"
+	default:
+		panic("unknown type hierarchy column: " + column)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -379,6 +637,126 @@ func (*Chinese) Text_SourceFilePath() string { return "源文件：" }
 
 func (*Chinese) Text_GeneratedFrom() string { return "从此文件生成" }
 
+func (*Chinese) Text_BinaryFile(size int) string {
+	return fmt.Sprintf("（二进制文件，%d字节）", size)
+}
+
+///////////////////////////////////////////////////////////////////
+// unused exported identifiers page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Reports() string {
+	return "报告"
+}
+
+func (*Chinese) Text_UnusedExporteds() string {
+	return "未被使用的导出标识符"
+}
+
+func (*Chinese) Text_UnusedExportedsStat(numIdentifiers, numPackages int) string {
+	return fmt.Sprintf("当前目录下的%d个代码包中的%d个导出标识符未被其它代码包使用", numPackages, numIdentifiers)
+}
+
+func (*Chinese) Text_UnusedExportedsFilter(item string, excluded bool) string {
+	var state = "已包含"
+	if excluded {
+		state = "已排除"
+	}
+	switch item {
+	case "mainpackages":
+		return "main代码包：" + state
+	case "interfacemethods":
+		return "实现了接口的方法：" + state
+	default:
+		panic("unknown filter item: " + item)
+	}
+}
+
+func (*Chinese) Text_UnusedExportedsFilterAction(exclude bool) string {
+	if exclude {
+		return "排除"
+	}
+	return "包含"
+}
+
+func (*Chinese) Text_SatisfyingInterfaces() string {
+	return "<i>（实现了接口）</i>"
+}
+
+///////////////////////////////////////////////////////////////////
+// deprecated uses page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_DeprecatedUses() string {
+	return "对已弃用的标识符的使用"
+}
+
+func (*Chinese) Text_DeprecatedUsesStat(numUses, numPackages int) string {
+	return fmt.Sprintf("工作目录下的%[2]d个库包中共有%[1]d处使用了已弃用的标识符", numUses, numPackages)
+}
+
+///////////////////////////////////////////////////////////////////
+// most complex functions page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_MostComplexFunctions() string {
+	return "最复杂的函数"
+}
+
+func (*Chinese) Text_MostComplexFunctionsStat(numFunctions, maxNumFunctions int) string {
+	if numFunctions < maxNumFunctions {
+		return fmt.Sprintf("%d个函数（按圈复杂度排序）", numFunctions)
+	}
+	return fmt.Sprintf("圈复杂度最高的%d个函数", numFunctions)
+}
+
+///////////////////////////////////////////////////////////////////
+// API diff page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_APIChanges() string {
+	return "API变动"
+}
+
+func (*Chinese) Text_APIDiffBeingComputed() string {
+	return "正在计算API变动。此页面将自动刷新。"
+}
+
+func (*Chinese) Text_APIDiffStat(numPackages, numBreakingChanges int) string {
+	return fmt.Sprintf("%d个代码包的API有变动，其中%d处为不兼容变动", numPackages, numBreakingChanges)
+}
+
+func (c *Chinese) Text_APIChangeKind(kind string) string {
+	switch kind {
+	case "package":
+		return "代码包"
+	case "implements":
+		return "实现关系"
+	default:
+		return c.Text_ObjectKind(kind)
+	}
+}
+
+func (*Chinese) Text_BreakingChange() string {
+	return "[不兼容]"
+}
+
+///////////////////////////////////////////////////////////////////
+// errors page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Errors() string {
+	return "错误"
+}
+
+func (*Chinese) Text_IllTypedPackages(num int) string {
+	return fmt.Sprintf("%d个代码包含有错误", num)
+}
+
+func (*Chinese) Text_IllTyped() string {
+	return "[含有错误]"
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -387,12 +765,37 @@ func (*Chinese) Text_Statistics() string {
 	return "统计信息"
 }
 
+func (*Chinese) Text_StatisticsScopes() string {
+	return "统计范围"
+}
+
+func (*Chinese) Text_StatisticsScope(scope string) string {
+	switch {
+	case scope == "":
+		return "所有被分析的库包"
+	case scope == "std":
+		return "标准库包"
+	case scope == "wd":
+		return "当前目录下的库包"
+	case strings.HasPrefix(scope, "mod:"):
+		return "模块" + scope[len("mod:"):] + "中的库包"
+	case strings.HasPrefix(scope, "pkg:"):
+		return "库包" + scope[len("pkg:"):]
+	default:
+		panic("unknown statistics scope: " + scope)
+	}
+}
+
 func (*Chinese) Text_ChartTitle(chartName string) string {
 	switch chartName {
 	case "gosourcefiles-by-imports":
 		return "Go源文件数量按照引入数量的分布"
 	case "packages-by-dependencies":
 		return "库包数量按照依赖数量的分布"
+	case "gosourcefiles-by-codelines":
+		return "Go源文件数量按照代码行数的分布"
+	case "packages-by-codelines":
+		return "库包数量按照代码行数的分布"
 	case "exportedtypenames-by-kinds":
 		return "导出的类型名数量按照类型种类的分布"
 	case "exportedstructtypes-by-embeddingfields":
@@ -436,11 +839,36 @@ func (*Chinese) Text_StatisticsTitle(titleName string) string {
 		return "值（变量/常量/函数）"
 	case "others":
 		return "其它"
+	case "toplists":
+		return "排行榜"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
 }
 
+func (*Chinese) Text_TopNList(listName string) string {
+	switch listName {
+	case "most-imported-packages":
+		return "被引入次数最多的库包"
+	case "most-depending-packages":
+		return "依赖最多的库包"
+	case "packages-with-most-files":
+		return "源文件最多的库包"
+	case "packages-with-most-codelines":
+		return "代码行数最多的库包"
+	case "structs-with-most-fields":
+		return "字段最多的结构体类型（包括提升字段）"
+	case "interfaces-with-most-methods":
+		return "方法最多的接口类型"
+	case "most-implemented-interfaces":
+		return "被实现次数最多的接口类型"
+	case "most-referenced-identifiers":
+		return "被引用次数最多的标识符"
+	default:
+		panic("unknown top list: " + listName)
+	}
+}
+
 func (*Chinese) Text_PackageStatistics(values map[string]interface{}) string {
 	return fmt.Sprintf(`
 	共<a href="%s">%d个库包</a>，其中%d个是标准库包。
@@ -452,6 +880,12 @@ func (*Chinese) Text_PackageStatistics(values map[string]interface{}) string {
 
 	<img src="%s"></image>
 	<img src="%s"></image>
+
+	Go源文件共有%d行，其中%d行为注释行，%d行为空行。
+	注释行数和代码行数的比例为%.2f。
+
+	<img src="%s"></image>
+	<img src="%s"></image>
 `,
 		values["overviewPageURL"],
 		values["packageCount"],
@@ -464,6 +898,14 @@ func (*Chinese) Text_PackageStatistics(values map[string]interface{}) string {
 
 		values["gosourcefilesByImportsChartURL"],
 		values["packagesByDependenciesChartURL"],
+
+		values["codeLineCount"],
+		values["commentCodeLineCount"],
+		values["blankCodeLineCount"],
+		values["commentToCodeRatio"],
+
+		values["gosourcefilesByCodelinesChartURL"],
+		values["packagesByCodelinesChartURL"],
 	)
 }
 
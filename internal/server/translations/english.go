@@ -2,6 +2,7 @@ package translations
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"go101.org/gold/code"
@@ -132,6 +133,10 @@ func (*English) Text_Analyzing_CollectObjectReferences(d time.Duration) string {
 	return fmt.Sprintf("Collect Object References: %s", d)
 }
 
+func (*English) Text_Analyzing_BuildCallGraph(d time.Duration) string {
+	return fmt.Sprintf("Build Call Graph: %s", d)
+}
+
 func (*English) Text_Analyzing_CacheSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("Cache Source Files: %s", d)
 }
@@ -230,6 +235,34 @@ func (*English) Text_FilterItem(fltr string) string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Module(modulePath string) string {
+	return fmt.Sprintf("Module: %s", modulePath)
+}
+
+func (*English) Text_ModuleItem(item string) string {
+	switch item {
+	case "version":
+		return "Version"
+	case "goversion":
+		return "Go Version"
+	case "directory":
+		return "Directory"
+	case "packages":
+		return "Packages"
+	case "requires":
+		return "Requires"
+	case "replaces":
+		return "Replaces"
+	case "requiredbys":
+		return "Required By"
+	}
+	return ""
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -276,6 +309,103 @@ func (*English) Text_ImportStat(numImports, numImportedBys int, depPageURL strin
 
 func (*English) Text_InvolvedFiles(num int) string { return "Involved Source Files" }
 
+func (*English) Text_CodeLines() string { return "Code Lines" }
+
+func (*English) Text_CodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int, commentToCodeRatio float64) string {
+	return fmt.Sprintf("%d lines: %d code lines, %d comment lines and %d blank lines (comment-to-code ratio: %.2f)", numLines, numCodeLines, numCommentLines, numBlankLines, commentToCodeRatio)
+}
+
+func (*English) Text_FileCodeLineStat(numLines, numCodeLines, numCommentLines, numBlankLines int) string {
+	return fmt.Sprintf("%d lines: %d code, %d comment, %d blank", numLines, numCodeLines, numCommentLines, numBlankLines)
+}
+
+func (*English) Text_FunctionMetrics(numFunctions int) string {
+	return fmt.Sprintf("Function Metrics (%d)", numFunctions)
+}
+
+func (*English) Text_FunctionMetricsColumn(column string) string {
+	switch column {
+	case "name":
+		return "function"
+	case "statements":
+		return "statements"
+	case "lines":
+		return "lines"
+	case "complexity":
+		return "complexity"
+	case "nesting":
+		return "max nesting"
+	case "params":
+		return "params"
+	case "results":
+		return "results"
+	case "returns":
+		return "returns"
+	default:
+		panic("unknown function metrics column: " + column)
+	}
+}
+
+func (*English) Text_Platforms(primary string) string {
+	return fmt.Sprintf("Platforms (fully analyzed for %s)", primary)
+}
+
+func (*English) Text_OnlyForPlatforms(platforms string) string {
+	return fmt.Sprintf("[only for %s]", platforms)
+}
+
+func (*English) Text_OtherPlatformDeclarations(num int) string {
+	return "Declarations Only for Other Platforms"
+}
+
+func (*English) Text_AssemblyImplementations() string {
+	return "asm"
+}
+
+func (*English) Text_LinkedTo() string {
+	return "linked to:"
+}
+
+func (*English) Text_LinkedFrom() string {
+	return "linked from:"
+}
+
+func (*English) Text_EmbeddedFiles(num int) string {
+	switch num {
+	case 0:
+		return "(no embedded files)"
+	case 1:
+		return "one embedded file:"
+	}
+	return fmt.Sprintf("%d embedded files:", num)
+}
+
+func (*English) Text_Directives(num int) string {
+	return fmt.Sprintf("Directives (%d)", num)
+}
+
+func (*English) Text_Deprecated() string {
+	return "deprecated"
+}
+
+func (*English) Text_DeprecatedIdentifiers(num int) string {
+	return fmt.Sprintf("Deprecated Identifiers (%d)", num)
+}
+
+func (*English) Text_DeprecatedIdentifiersState(hidden bool) string {
+	if hidden {
+		return "They are hidden in the following lists."
+	}
+	return "They are marked in the following lists."
+}
+
+func (*English) Text_DeprecatedIdentifiersAction(hide bool) string {
+	if hide {
+		return "hide them"
+	}
+	return "show them"
+}
+
 func (*English) Text_ExportedValues(num int) string {
 	return "Exported Values"
 }
@@ -296,6 +426,20 @@ func (*English) Text_TypeNameListShowOption(exportedsOnly bool) string {
 	}
 }
 
+func (*English) Text_TestFunctions(kind string, num int) string {
+	switch kind {
+	case "test":
+		return "Tests"
+	case "benchmark":
+		return "Benchmarks"
+	case "fuzz":
+		return "Fuzz Tests"
+	case "example":
+		return "Examples"
+	}
+	return ""
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -348,6 +492,36 @@ func (*English) Text_AsTypesOf(num int) string {
 	return fmt.Sprintf("As Types Of (%d+)", num)
 }
 
+func (*English) Text_Instantiations(num int) string {
+	return fmt.Sprintf("Instantiations (%d+)", num)
+}
+
+func (*English) Text_MemoryLayout(size, padding int64) string {
+	if padding == 0 {
+		return fmt.Sprintf("Memory Layout (%d bytes)", size)
+	}
+	return fmt.Sprintf("Memory Layout (%d bytes, %d for padding)", size, padding)
+}
+
+func (*English) Text_MemoryLayoutColumns() string {
+	return "offset  size align  field"
+}
+
+func (*English) Text_PaddingBytes(num int64) string {
+	if num == 1 {
+		return "(one padding byte)"
+	}
+	return fmt.Sprintf("(%d padding bytes)", num)
+}
+
+func (*English) Text_MemoryLayoutSummary(size, align int64, platform string) string {
+	return fmt.Sprintf("size: %d, alignment: %d (%s)", size, align, platform)
+}
+
+func (*English) Text_SuggestedFieldOrder(size int64) string {
+	return fmt.Sprintf("suggested field order (size: %d):", size)
+}
+
 func (*English) Text_References(num int) string {
 	return fmt.Sprintf("References (%d+)", num)
 }
@@ -401,6 +575,14 @@ func (*English) Text_ObjectKind(kind string) string {
 		return "field"
 	case "method":
 		return "method"
+	case "type":
+		return "type"
+	case "func":
+		return "func"
+	case "var":
+		return "var"
+	case "const":
+		return "const"
 	default:
 		panic("unknown object kind name: " + kind)
 	}
@@ -413,6 +595,103 @@ func (*English) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d uses", num)
 }
 
+func (*English) Text_LinkedFunctionUses(num int) string {
+	if num == 1 {
+		return "(linked with //go:linkname) one use"
+	}
+	return fmt.Sprintf("(linked with //go:linkname) %d uses", num)
+}
+
+///////////////////////////////////////////////////////////////////
+// call graph page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_CallGraph() string {
+	return "call graph"
+}
+
+func (*English) Text_Callers(numCalls int) string {
+	if numCalls == 1 {
+		return "Called by (one call)"
+	}
+	return fmt.Sprintf("Called by (%d calls)", numCalls)
+}
+
+func (*English) Text_Callees(numCalls int) string {
+	if numCalls == 1 {
+		return "Calls (one call)"
+	}
+	return fmt.Sprintf("Calls (%d calls)", numCalls)
+}
+
+func (*English) Text_CallThroughInterfaceMethod(method string) string {
+	return fmt.Sprintf("<i>(through %s)</i>", method)
+}
+
+func (*English) Text_PossibleCallees(num int) string {
+	switch num {
+	case 0:
+		return "<i>(no known implementations)</i>"
+	case 1:
+		return "one possible callee"
+	}
+	return fmt.Sprintf("%d possible callees", num)
+}
+
+func (*English) Text_CurrentPackage() string {
+	return "<i>(current package)</i>"
+}
+
+///////////////////////////////////////////////////////////////////
+// method sets page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_MethodSets() string {
+	return "method sets"
+}
+
+func (*English) Text_MethodSetOf(pointer bool, numMethods int) string {
+	var t = "T"
+	if pointer {
+		t = "*T"
+	}
+	if numMethods == 1 {
+		return fmt.Sprintf("Method Set of %s (one method)", t)
+	}
+	return fmt.Sprintf("Method Set of %s (%d methods)", t, numMethods)
+}
+
+func (*English) Text_PromotedThrough(embeddingPath string) string {
+	return "(promoted through " + embeddingPath + ")"
+}
+
+func (*English) Text_ImplementedByValueAndPointer() string {
+	return "both T and *T implement it"
+}
+
+func (*English) Text_ImplementedByPointerOnly() string {
+	return "only *T implements it"
+}
+
+///////////////////////////////////////////////////////////////////
+// type hierarchy graph
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_TypeHierarchy() string {
+	return "type hierarchy"
+}
+
+func (*English) Text_TypeHierarchyColumn(column string) string {
+	switch column {
+	case "implements":
+		return "implements"
+	case "implementedby":
+		return "implemented by"
+	default:
+		panic("unknown type hierarchy column: " + column)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -425,6 +704,150 @@ func (*English) Text_SourceFilePath() string { return "Source File" }
 
 func (*English) Text_GeneratedFrom() string { return "Generated From" }
 
+func (*English) Text_BinaryFile(size int) string {
+	return fmt.Sprintf("(binary file, %d bytes)", size)
+}
+
+///////////////////////////////////////////////////////////////////
+// unused exported identifiers page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Reports() string {
+	return "Reports"
+}
+
+func (*English) Text_UnusedExporteds() string {
+	return "Unused Exported Identifiers"
+}
+
+func (*English) Text_UnusedExportedsStat(numIdentifiers, numPackages int) string {
+	var ids, pkgs = "identifiers", "packages"
+	if numIdentifiers == 1 {
+		ids = "identifier"
+	}
+	if numPackages == 1 {
+		pkgs = "package"
+	}
+	return fmt.Sprintf("%d exported %s in %d %s under the working directory are not used by other packages", numIdentifiers, ids, numPackages, pkgs)
+}
+
+func (*English) Text_UnusedExportedsFilter(item string, excluded bool) string {
+	var state = "included"
+	if excluded {
+		state = "excluded"
+	}
+	switch item {
+	case "mainpackages":
+		return "main packages: " + state
+	case "interfacemethods":
+		return "methods satisfying interfaces: " + state
+	default:
+		panic("unknown filter item: " + item)
+	}
+}
+
+func (*English) Text_UnusedExportedsFilterAction(exclude bool) string {
+	if exclude {
+		return "exclude"
+	}
+	return "include"
+}
+
+func (*English) Text_SatisfyingInterfaces() string {
+	return "<i>(satisfying interfaces)</i>"
+}
+
+///////////////////////////////////////////////////////////////////
+// deprecated uses page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_DeprecatedUses() string {
+	return "Uses of Deprecated Identifiers"
+}
+
+func (*English) Text_DeprecatedUsesStat(numUses, numPackages int) string {
+	var uses, pkgs = "uses", "packages"
+	if numUses == 1 {
+		uses = "use"
+	}
+	if numPackages == 1 {
+		pkgs = "package"
+	}
+	return fmt.Sprintf("%d %s of deprecated identifiers in %d %s under the working directory", numUses, uses, numPackages, pkgs)
+}
+
+///////////////////////////////////////////////////////////////////
+// most complex functions page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_MostComplexFunctions() string {
+	return "Most Complex Functions"
+}
+
+func (*English) Text_MostComplexFunctionsStat(numFunctions, maxNumFunctions int) string {
+	if numFunctions < maxNumFunctions {
+		return fmt.Sprintf("%d functions (by cyclomatic complexities)", numFunctions)
+	}
+	return fmt.Sprintf("Top %d functions (by cyclomatic complexities)", numFunctions)
+}
+
+///////////////////////////////////////////////////////////////////
+// API diff page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_APIChanges() string {
+	return "API Changes"
+}
+
+func (*English) Text_APIDiffBeingComputed() string {
+	return "The API changes are being computed. This page will be refreshed automatically."
+}
+
+func (*English) Text_APIDiffStat(numPackages, numBreakingChanges int) string {
+	var pkgs, changes = "packages", "changes"
+	if numPackages == 1 {
+		pkgs = "package"
+	}
+	if numBreakingChanges == 1 {
+		changes = "change"
+	}
+	return fmt.Sprintf("The APIs of %d %s are changed, with %d breaking %s", numPackages, pkgs, numBreakingChanges, changes)
+}
+
+func (e *English) Text_APIChangeKind(kind string) string {
+	switch kind {
+	case "package":
+		return "package"
+	case "implements":
+		return "implementation"
+	default:
+		return e.Text_ObjectKind(kind)
+	}
+}
+
+func (*English) Text_BreakingChange() string {
+	return "[breaking]"
+}
+
+///////////////////////////////////////////////////////////////////
+// errors page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Errors() string {
+	return "Errors"
+}
+
+func (*English) Text_IllTypedPackages(num int) string {
+	if num == 1 {
+		return "One package has errors"
+	}
+	return fmt.Sprintf("%d packages have errors", num)
+}
+
+func (*English) Text_IllTyped() string {
+	return "[has errors]"
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -433,12 +856,37 @@ func (*English) Text_Statistics() string {
 	return "Statistics"
 }
 
+func (*English) Text_StatisticsScopes() string {
+	return "Scopes"
+}
+
+func (*English) Text_StatisticsScope(scope string) string {
+	switch {
+	case scope == "":
+		return "all analyzed packages"
+	case scope == "std":
+		return "standard packages"
+	case scope == "wd":
+		return "packages under the working directory"
+	case strings.HasPrefix(scope, "mod:"):
+		return "packages in module " + scope[len("mod:"):]
+	case strings.HasPrefix(scope, "pkg:"):
+		return "package " + scope[len("pkg:"):]
+	default:
+		panic("unknown statistics scope: " + scope)
+	}
+}
+
 func (*English) Text_ChartTitle(chartName string) string {
 	switch chartName {
 	case "gosourcefiles-by-imports":
 		return "Numbers of Go Source Files by Import Counts"
 	case "packages-by-dependencies":
 		return "Numbers of Packages by Dependency Counts"
+	case "gosourcefiles-by-codelines":
+		return "Numbers of Go Source Files by Code Line Counts"
+	case "packages-by-codelines":
+		return "Numbers of Packages by Code Line Counts"
 	case "exportedtypenames-by-kinds":
 		return "Numbers of Exported Type Names by Kinds"
 	case "exportedstructtypes-by-embeddingfields":
@@ -482,11 +930,36 @@ func (*English) Text_StatisticsTitle(titleName string) string {
 		return "Values"
 	case "others":
 		return "Others"
+	case "toplists":
+		return "Top Lists"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
 }
 
+func (*English) Text_TopNList(listName string) string {
+	switch listName {
+	case "most-imported-packages":
+		return "Most Imported Packages (by importing package counts)"
+	case "most-depending-packages":
+		return "Packages with the Most Dependencies"
+	case "packages-with-most-files":
+		return "Packages with the Most Source Files"
+	case "packages-with-most-codelines":
+		return "Packages with the Most Code Lines"
+	case "structs-with-most-fields":
+		return "Struct Types with the Most Fields (including promoteds)"
+	case "interfaces-with-most-methods":
+		return "Interface Types with the Most Methods"
+	case "most-implemented-interfaces":
+		return "Most Implemented Interface Types"
+	case "most-referenced-identifiers":
+		return "Most Referenced Identifiers"
+	default:
+		panic("unknown top list: " + listName)
+	}
+}
+
 func (*English) Text_PackageStatistics(values map[string]interface{}) string {
 	return fmt.Sprintf(`
 	Total <a href="%s">%d packages</a>, %d of them are standard packages.
@@ -498,6 +971,13 @@ func (*English) Text_PackageStatistics(values map[string]interface{}) string {
 
 	<img src="%s"></image>
 	<img src="%s"></image>
+
+	Total %d lines in the Go source files, %d of them
+	are comment lines and %d are blank lines.
+	The comment-to-code ratio is %.2f.
+
+	<img src="%s"></image>
+	<img src="%s"></image>
 `,
 		values["overviewPageURL"],
 		values["packageCount"],
@@ -510,6 +990,14 @@ func (*English) Text_PackageStatistics(values map[string]interface{}) string {
 
 		values["gosourcefilesByImportsChartURL"],
 		values["packagesByDependenciesChartURL"],
+
+		values["codeLineCount"],
+		values["commentCodeLineCount"],
+		values["blankCodeLineCount"],
+		values["commentToCodeRatio"],
+
+		values["gosourcefilesByCodelinesChartURL"],
+		values["packagesByCodelinesChartURL"],
 	)
 }
 
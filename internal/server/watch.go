@@ -0,0 +1,315 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go101.org/gold/code"
+	"go101.org/gold/internal/util"
+)
+
+// In the watch mode, the source directories of the analyzed packages
+// are polled periodically. Polling is used instead of OS notifications,
+// for it is simple and portable, and the number of the directories is
+// moderate even for the std packages.
+//
+// The changes are applied in the background. The analysis results of a
+// package depend on the other packages (implementation relations,
+// identifier uses, ...), so all the packages are loaded and analyzed
+// again, in a new analyzer (and a new token.FileSet), so that nothing of
+// the old results is retained. The old results are still used to serve
+// pages before the new ones are ready. Then only the cached pages of the
+// affected packages and the pages crossing packages are dropped, and
+// only the opened pages of them are reloaded.
+
+const watchInterval = time.Second
+
+// watch never returns.
+func (ds *docServer) watch(args []string, parseOptions code.ParseOptions) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	ds.mutex.Lock()
+	dirs := sourceDirectories(ds.analyzer)
+	ds.mutex.Unlock()
+
+	signatures := signSourceDirs(dirs)
+	changedDirs := make(map[string]bool)
+	for {
+		time.Sleep(watchInterval)
+
+		var changed bool
+		for dir, sig := range signatures {
+			if newSig := util.SignDirectory(dir); newSig != sig {
+				signatures[dir] = newSig
+				changedDirs[dir] = true
+				changed = true
+			}
+		}
+
+		// Wait until the files stop changing, so that saving
+		// several files in a row only leads to one analysis.
+		if changed || len(changedDirs) == 0 {
+			continue
+		}
+
+		ds.watchLogger.Printf("Source files changed in %d directories. Re-analyzing ...", len(changedDirs))
+		if dirs, ok := ds.reanalyze(args, parseOptions, changedDirs); ok {
+			// New packages and files might be added.
+			signatures = signSourceDirs(dirs)
+		}
+		changedDirs = make(map[string]bool)
+	}
+}
+
+// reanalyze returns the source directories of the new analysis results.
+// ok is false if the analysis fails.
+func (ds *docServer) reanalyze(args []string, parseOptions code.ParseOptions, changedDirs map[string]bool) (dirs []string, ok bool) {
+	var stopWatch = util.NewStopWatch()
+
+	// The old analyzer is still used to serve pages, so it is
+	// only accessed with the lock held.
+	ds.mutex.Lock()
+	oldPkgs := watchedPackagesOf(ds.analyzer)
+	ds.mutex.Unlock()
+
+	analyzer := &code.CodeAnalyzer{}
+	if !analyzer.ParsePackages(nil, parseOptions, args...) {
+		ds.watchLogger.Println("Failed to parse packages. The old analysis results are still used.")
+		return nil, false
+	}
+	analyzer.AnalyzePackages(nil)
+	affectedPkgs := affectedPackages(oldPkgs, watchedPackagesOf(analyzer), changedDirs)
+	dirs = sourceDirectories(analyzer)
+
+	ds.mutex.Lock()
+	ds.analyzer = analyzer
+	ds.dropCachedPagesForPackages(affectedPkgs)
+	ds.sourceVersion++
+	if ds.packageVersions == nil {
+		ds.packageVersions = make(map[string]int, len(affectedPkgs))
+	}
+	for pkg := range affectedPkgs {
+		ds.packageVersions[pkg] = ds.sourceVersion
+	}
+	ds.mutex.Unlock()
+
+	ds.watchLogger.Printf("Done. %d packages are affected. (Total time: %s, used memory: %s)",
+		len(affectedPkgs), stopWatch.Duration(false), util.MemoryUse())
+	return dirs, true
+}
+
+// watchedPackage is what affectedPackages needs to know about a package.
+type watchedPackage struct {
+	files   []string
+	imports []string // package paths, sorted
+}
+
+func watchedPackagesOf(analyzer *code.CodeAnalyzer) map[string]*watchedPackage {
+	pkgs := make(map[string]*watchedPackage, analyzer.NumPackages())
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		wp := &watchedPackage{
+			files:   make([]string, 0, len(pkg.PPkg.GoFiles)+len(pkg.PPkg.OtherFiles)+len(pkg.PPkg.EmbedFiles)),
+			imports: make([]string, 0, len(pkg.Deps)),
+		}
+		wp.files = append(wp.files, pkg.PPkg.GoFiles...)
+		wp.files = append(wp.files, pkg.PPkg.OtherFiles...)
+		wp.files = append(wp.files, pkg.PPkg.EmbedFiles...)
+		for _, dep := range pkg.Deps {
+			wp.imports = append(wp.imports, dep.Path())
+		}
+		sort.Strings(wp.imports)
+		pkgs[pkg.Path()] = wp
+	}
+	return pkgs
+}
+
+// affectedPackages returns the paths of the packages whose pages need to
+// be rebuilt: the packages which are added, removed or changed (having
+// files in the changed directories, or different files or imports), the
+// packages depending on them (directly or indirectly), and the packages
+// directly imported by them (for the "imported by" lists).
+func affectedPackages(oldPkgs, newPkgs map[string]*watchedPackage, changedDirs map[string]bool) map[string]bool {
+	inChangedDirs := func(files []string) bool {
+		for _, f := range files {
+			if changedDirs[filepath.Dir(f)] {
+				return true
+			}
+		}
+		return false
+	}
+
+	dirty := make(map[string]bool)
+	for path, wp := range newPkgs {
+		if old := oldPkgs[path]; old == nil || !reflect.DeepEqual(old, wp) || inChangedDirs(wp.files) {
+			dirty[path] = true
+		}
+	}
+	for path := range oldPkgs {
+		if newPkgs[path] == nil {
+			dirty[path] = true
+		}
+	}
+	for spread := true; spread; {
+		spread = false
+		for path, wp := range newPkgs {
+			if dirty[path] {
+				continue
+			}
+			for _, dep := range wp.imports {
+				if dirty[dep] {
+					dirty[path] = true
+					spread = true
+					break
+				}
+			}
+		}
+	}
+
+	affected := make(map[string]bool, len(dirty)*2)
+	for path := range dirty {
+		affected[path] = true
+		for _, pkgs := range [...]map[string]*watchedPackage{oldPkgs, newPkgs} {
+			if wp := pkgs[path]; wp != nil {
+				for _, dep := range wp.imports {
+					affected[dep] = true
+				}
+			}
+		}
+	}
+	return affected
+}
+
+// dropCachedPagesForPackages must be called with ds.mutex locked.
+// The page options are kept, for they are the choices of users.
+func (ds *docServer) dropCachedPagesForPackages(pkgs map[string]bool) {
+	for key := range ds.cachedPages {
+		switch key.resType {
+		case ResTypePackage, ResTypeDependency:
+			// The res of the package list part of the overview page is "".
+			if pkgPath := key.res.(string); pkgPath != "" && !pkgs[pkgPath] {
+				continue
+			}
+		case ResTypeSource:
+			if !pkgs[key.res.([2]string)[0]] {
+				continue
+			}
+		case ResTypeNone, ResTypeModule, ResTypeImplementation, ResTypeReference, ResTypeCallGraph, ResTypeMethodSets, ResTypeSVG:
+			// These pages (and the charts and graphs) cross packages.
+		default: // css, js, png
+			continue
+		}
+		delete(ds.cachedPages, key)
+	}
+}
+
+// sourceDirectories returns the directories of the source files of
+// all analyzed packages, and the directories of the non-std modules
+// (for their go.mod and go.sum files).
+func sourceDirectories(analyzer *code.CodeAnalyzer) []string {
+	dirs := make(map[string]struct{}, analyzer.NumPackages())
+	addDirsOf := func(files []string) {
+		for _, f := range files {
+			dirs[filepath.Dir(f)] = struct{}{}
+		}
+	}
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		// CompiledGoFiles are not used here, for the cgo generated
+		// ones are in the build cache directory.
+		addDirsOf(pkg.PPkg.GoFiles)
+		addDirsOf(pkg.PPkg.OtherFiles)
+		addDirsOf(pkg.PPkg.IgnoredFiles)
+	}
+	for i := 0; i < analyzer.NumModules(); i++ {
+		if m := analyzer.ModuleAt(i); m != analyzer.StandardModule() && m.Dir != "" {
+			dirs[m.Dir] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		result = append(result, dir)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// signSourceDirs returns the signatures of the specified directories.
+func signSourceDirs(dirs []string) map[string]string {
+	signatures := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		signatures[dir] = util.SignDirectory(dir)
+	}
+	return signatures
+}
+
+// watchAPI returns the source version of the page at the "path" query.
+// For the page of a package (or its source files or dependencies),
+// the version is only changed when the package is affected.
+func (ds *docServer) watchAPI(w http.ResponseWriter, r *http.Request) {
+	// The path is location.pathname, which is escaped.
+	path, _ := url.PathUnescape(strings.TrimPrefix(r.FormValue("path"), "/"))
+	pkgPath := ""
+	if len(path) >= 5 && path[3] == ':' {
+		switch res := path[4:]; pageResType(path[:3]) {
+		case ResTypePackage, ResTypeDependency:
+			pkgPath = res
+		case ResTypeSource:
+			if i := strings.LastIndex(res, "/"); i >= 0 {
+				pkgPath = res[:i]
+			}
+		}
+	}
+
+	ds.mutex.Lock()
+	version := ds.sourceVersion
+	if pkgPath != "" {
+		version = ds.packageVersions[pkgPath]
+	}
+	ds.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version": version,
+	})
+}
+
+// Reload the current page when the source files are re-analyzed.
+var watchJsFile = []byte(`
+(function() {
+	var url = window.location.protocol + '//' + window.location.host + '/api:watch?path=' + encodeURIComponent(window.location.pathname);
+	var version = null;
+
+	function check() {
+		var xhr = new XMLHttpRequest();
+		xhr.open('GET', url);
+		xhr.onreadystatechange = function() {
+			if (xhr.readyState != 4) {
+				return;
+			}
+			if (xhr.status == 200) {
+				var v = JSON.parse(xhr.response).version;
+				if (version == null) {
+					version = v;
+				} else if (v != version) {
+					window.location.reload();
+					return;
+				}
+			}
+			setTimeout(check, 2000);
+		};
+		xhr.send(null);
+	}
+
+	check();
+})();
+`)